@@ -10,6 +10,137 @@ info:
 
 
 paths:
+  /api/v1/orders:
+    get:
+      tags:
+        - Orders
+      summary: Получить страницу заказов по фильтрам
+      description: Pages are ordered by date_created and order_uid. Orders without date_created are not listed, they are available by id
+      parameters:
+        - in: query
+          name: customer_id
+          schema:
+            type: string
+          example: test
+        - in: query
+          name: date_from
+          schema:
+            type: string
+            format: date-time
+          example: 2021-11-01T00:00:00Z
+          description: date_created from, inclusive
+        - in: query
+          name: date_to
+          schema:
+            type: string
+            format: date-time
+          example: 2021-12-01T00:00:00Z
+          description: date_created to, inclusive
+        - in: query
+          name: delivery_service
+          schema:
+            type: string
+          example: meest
+        - in: query
+          name: entry
+          schema:
+            type: string
+          example: WBIL
+        - in: query
+          name: locale
+          schema:
+            type: string
+          example: en
+        - in: query
+          name: currency
+          schema:
+            type: string
+          example: USD
+        - in: query
+          name: sort
+          schema:
+            type: string
+            enum: [date_created, -date_created]
+            default: -date_created
+        - in: query
+          name: limit
+          schema:
+            type: integer
+            minimum: 1
+            maximum: 100
+            default: 20
+        - in: query
+          name: cursor
+          schema:
+            type: string
+          description: next_cursor of the previous page, requested with the same sort
+
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/SuccessResponseListOrders'
+        '400':
+          description: Bad request
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '500':
+          description: Interval Server Error
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+    post:
+      tags:
+        - Orders
+      summary: Принять заказ
+      description: The order is validated and published to the order.create subject with Nats-Msg-Id set to order_uid, the consumer writes it to the database. The order is available on status_url after it is written.
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/OrderCreateRequest'
+
+      responses:
+        '202':
+          description: Accepted
+          headers:
+            Location:
+              description: status_url
+              schema:
+                type: string
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/SuccessResponseCreateOrder'
+        '400':
+          description: Invalid json or order validation failed, body contains the violated fields
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '500':
+          description: Interval Server Error
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '503':
+          description: Order is not published to jetstream
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
   /api/v1/orders/{id}:
     get:
       tags:
@@ -24,14 +155,274 @@ paths:
           required: true
           example: 5d110e48-9e6b-4928-b436-14194b30d54f
           description: Order id
+        - in: header
+          name: If-None-Match
+          schema:
+            type: string
+          required: false
+          example: '"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"'
+          description: ETag из предыдущего ответа, при совпадении возвращается 304 без тела
 
       responses:
         '200':
           description: OK
+          headers:
+            ETag:
+              description: Strong ETag - sha256 json заказа
+              schema:
+                type: string
+            Last-Modified:
+              description: Время последней смены статуса, для заказов без истории статусов - date_created
+              schema:
+                type: string
+            Cache-Control:
+              description: Значение server.http.order_cache_control
+              schema:
+                type: string
           content:
             application/json:
               schema:
                 $ref: '#/components/schemas/SuccessResponseGetOrder'
+        '304':
+          description: Not modified, ETag совпал с If-None-Match
+          headers:
+            ETag:
+              schema:
+                type: string
+            Cache-Control:
+              schema:
+                type: string
+        '400':
+          description: Bad request
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '404':
+          description: Not found
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '500':
+          description: Interval Server Error
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+  /api/v1/orders/by-track-number/{track_number}:
+    get:
+      tags:
+        - Orders
+      summary: Получить заказ по track_number
+      parameters:
+        - in: path
+          name: track_number
+          schema:
+            type: string
+            maxLength: 500
+          required: true
+          example: WBILMTESTTRACK3
+
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/SuccessResponseGetOrder'
+        '404':
+          description: Not found
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '500':
+          description: Interval Server Error
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+  /api/v1/orders/by-request-id/{request_id}:
+    get:
+      tags:
+        - Orders
+      summary: Получить заказ по request_id платежа
+      parameters:
+        - in: path
+          name: request_id
+          schema:
+            type: string
+            format: uuid
+          required: true
+          example: 5d110e48-9e6b-4928-b436-14194b30d54f
+
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/SuccessResponseGetOrder'
+        '400':
+          description: Bad request
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '404':
+          description: Not found
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '409':
+          description: Several orders have the request_id
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '500':
+          description: Interval Server Error
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+  /api/v1/orders/by-rid/{rid}:
+    get:
+      tags:
+        - Orders
+      summary: Получить заказ по rid товара
+      parameters:
+        - in: path
+          name: rid
+          schema:
+            type: string
+            maxLength: 500
+          required: true
+          example: ab4219087a764ae0btest
+
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/SuccessResponseGetOrder'
+        '404':
+          description: Not found
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '409':
+          description: Several orders have the rid
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '500':
+          description: Interval Server Error
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+  /api/v1/customers/{id}:
+    get:
+      tags:
+        - Customers
+      summary: Получить сводку по заказам покупателя
+      parameters:
+        - in: path
+          name: id
+          schema:
+            type: string
+            maxLength: 500
+          required: true
+          example: test
+          description: Customer id
+
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/SuccessResponseCustomerSummary'
+        '400':
+          description: Bad request
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '404':
+          description: Not found
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+        '500':
+          description: Interval Server Error
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/ErrorResponse'
+
+  /api/v1/customers/{id}/orders:
+    get:
+      tags:
+        - Customers
+      summary: Получить страницу заказов покупателя
+      description: Accepts the same filters, sort and cursor as /api/v1/orders, customer_id is taken from the path
+      parameters:
+        - in: path
+          name: id
+          schema:
+            type: string
+            maxLength: 500
+          required: true
+          example: test
+          description: Customer id
+        - in: query
+          name: sort
+          schema:
+            type: string
+            enum: [date_created, -date_created]
+            default: -date_created
+        - in: query
+          name: limit
+          schema:
+            type: integer
+            minimum: 1
+            maximum: 100
+            default: 20
+        - in: query
+          name: cursor
+          schema:
+            type: string
+          description: next_cursor of the previous page, requested with the same sort
+
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/SuccessResponseListOrders'
         '400':
           description: Bad request
           content:
@@ -231,15 +622,121 @@ components:
               example: 99
             date_created:
               type: string
-              example: 2021-11-26 06:22:19 +0000 UTC
+              example: 2021-11-26T06:22:19Z
             oof_shard:
               type: string
               example: '1'
+            status:
+              type: string
+              enum: [created, paid, assembled, shipped, delivered, cancelled]
+              example: paid
+            timeline:
+              type: array
+              items:
+                properties:
+                  status:
+                    type: string
+                    example: paid
+                  reason:
+                    type: string
+                    example: ''
+                  changed_at:
+                    type: string
+                    format: date-time
+                    example: 2021-11-26T07:00:00Z
         
         error:
           type: string
           example: ""
           
+    SuccessResponseListOrders:
+      properties:
+        code:
+          type: string
+          example: OK
+        status:
+          type: string
+          enum: [ok, fail]
+        body:
+          properties:
+            orders:
+              type: array
+              items:
+                $ref: '#/components/schemas/SuccessResponseGetOrder/properties/body'
+            next_cursor:
+              type: string
+              description: empty on the last page
+              example: eyJkYXRlX2NyZWF0ZWQiOiIyMDIxLTExLTI2VDA2OjIyOjE5WiIsIm9yZGVyX3VpZCI6IjVkMTEwZTQ4LTllNmItNDkyOC1iNDM2LTE0MTk0YjMwZDU0ZiJ9
+        error:
+          type: string
+          example: ""
+
+    SuccessResponseCustomerSummary:
+      properties:
+        code:
+          type: string
+          example: OK
+        status:
+          type: string
+          enum: [ok, fail]
+        body:
+          properties:
+            customer_id:
+              type: string
+              example: test
+            orders_count:
+              type: integer
+              example: 1
+            first_order_at:
+              type: string
+              format: date-time
+              nullable: true
+              example: 2021-11-26T06:22:19Z
+            last_order_at:
+              type: string
+              format: date-time
+              nullable: true
+              example: 2021-11-26T06:22:19Z
+            total_spent:
+              type: object
+              description: sum of order payments by currency
+              additionalProperties:
+                type: number
+              example:
+                USD: 1817
+        error:
+          type: string
+          example: ""
+
+    OrderCreateRequest:
+      description: SuccessResponseGetOrder body without status and timeline
+      allOf:
+        - $ref: '#/components/schemas/SuccessResponseGetOrder/properties/body'
+
+    SuccessResponseCreateOrder:
+      properties:
+        code:
+          type: string
+          example: Accepted
+        status:
+          type: string
+          enum: [ok, fail]
+        body:
+          properties:
+            order_uid:
+              type: string
+              example: 5d110e48-9e6b-4928-b436-14194b30d54f
+            status_url:
+              type: string
+              example: /api/v1/orders/5d110e48-9e6b-4928-b436-14194b30d54f
+            duplicate:
+              type: boolean
+              description: order with the order_uid was already accepted
+              example: false
+        error:
+          type: string
+          example: ""
+
     ErrorResponse:
       properties:
         code:
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"wb_test_task/api/internal/config"
 	"wb_test_task/api/internal/delivery/http"
+	"wb_test_task/api/internal/publisher"
 	"wb_test_task/api/internal/services"
 	psql "wb_test_task/api/internal/storage/psql"
 	"wb_test_task/api/internal/storage/redis"
@@ -16,6 +17,7 @@ type Application struct {
 	httpServer   *http.Server
 	postgres     *psql.Storage
 	cache        *redis.Cache
+	publisher    *publisher.Publisher
 	service      *services.Service
 	cancelTracer func(ctx context.Context)
 }
@@ -36,9 +38,17 @@ func New(ctx context.Context, configFile string) (*Application, error) {
 		return &Application{}, errors.Wrap(err, "fail to init postgres database")
 	}
 
+	orderPublisher, err := publisher.New(cfg.Broker.NatsPublisher)
+	if err != nil {
+		return &Application{}, errors.Wrap(err, "fail to init nats publisher")
+	}
+
 	service := services.New(services.Depends{
-		OrderStorage: postgres.OrderStorage,
-		OrderCache:   cache.OrderCache,
+		OrderStorage:    postgres.OrderStorage,
+		OrderCache:      cache.OrderCache,
+		CustomerStorage: postgres.CustomerStorage,
+		CustomerCache:   cache.CustomerCache,
+		OrderPublisher:  orderPublisher,
 	})
 
 	// init tracer
@@ -58,6 +68,7 @@ func New(ctx context.Context, configFile string) (*Application, error) {
 		cfg:          cfg,
 		httpServer:   http.New(cfg.Server.HttpServer, service),
 		postgres:     postgres,
+		publisher:    orderPublisher,
 		cancelTracer: cancelTracer,
 	}, nil
 }
@@ -74,5 +85,8 @@ func (a *Application) Shutdown(ctx context.Context) error {
 	if err := a.httpServer.Shutdown(ctx); err != nil {
 		return errors.Wrap(err, "fail to shutdown http server")
 	}
+	if err := a.publisher.Shutdown(); err != nil {
+		return errors.Wrap(err, "fail to shutdown nats publisher")
+	}
 	return nil
 }
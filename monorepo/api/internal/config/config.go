@@ -5,12 +5,14 @@ import (
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 	"os"
+	"wb_test_task/libs/natsconn"
 )
 
 type Config struct {
 	Server   Server   `yaml:"server"`
 	Database Database `yaml:"database"`
 	Cache    Cache    `yaml:"cache"`
+	Broker   Broker   `yaml:"broker"`
 	Jaeger   Jaeger   `yaml:"jaeger"`
 }
 
@@ -20,6 +22,9 @@ type Server struct {
 
 type HttpServer struct {
 	Port string `yaml:"port"`
+	// OrderCacheControl заголовок Cache-Control ответа с заказом, по умолчанию клиент
+	// переспрашивает заказ с If-None-Match при каждом запросе
+	OrderCacheControl string `yaml:"order_cache_control" default:"no-cache"`
 }
 
 type Database struct {
@@ -40,6 +45,23 @@ type RedisCache struct {
 	Address   string `yaml:"address"`
 	Password  string `yaml:"password"`
 	TtlSecond int    `yaml:"ttl_second" default:"3600"`
+	// CustomerSummaryTtlSecond время жизни сводки покупателя, ограничивает устаревание сводки,
+	// если consumer не смог ее удалить
+	CustomerSummaryTtlSecond int `yaml:"customer_summary_ttl_second" default:"300"`
+}
+
+type Broker struct {
+	NatsPublisher NatsPublisher `yaml:"nats"`
+}
+
+// NatsPublisher публикация заказов, принятых по http, в jetstream
+type NatsPublisher struct {
+	Url        string          `yaml:"url" default:"nats://localhost:4222"`
+	Connection natsconn.Config `yaml:"connection"`
+	// Subject subject заказов, должен входить в stream consumer
+	Subject          string `yaml:"subject" default:"order.create"`
+	Source           string `yaml:"source" default:"api"`
+	PublishTimeoutMs int    `yaml:"publish_timeout_ms" default:"5000"`
 }
 
 type Jaeger struct {
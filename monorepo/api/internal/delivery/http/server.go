@@ -32,8 +32,9 @@ func New(cfg config.HttpServer, service *services.Service) *Server {
 
 	// init v1api
 	v1api.New(server.Group("/api/v1"), v1api.Depends{
-		Cfg:          cfg,
-		OrderService: service.OrderService,
+		Cfg:             cfg,
+		OrderService:    service.OrderService,
+		CustomerService: service.CustomerService,
 	})
 
 	server.HideBanner = true
@@ -6,19 +6,22 @@ import (
 )
 
 type API struct {
-	cfg          config.HttpServer
-	orderService orderService
+	cfg             config.HttpServer
+	orderService    orderService
+	customerService customerService
 }
 
 type Depends struct {
-	Cfg          config.HttpServer
-	OrderService orderService
+	Cfg             config.HttpServer
+	OrderService    orderService
+	CustomerService customerService
 }
 
 func New(group *echo.Group, depends Depends) *API {
 	api := &API{
-		cfg:          depends.Cfg,
-		orderService: depends.OrderService,
+		cfg:             depends.Cfg,
+		orderService:    depends.OrderService,
+		customerService: depends.CustomerService,
 	}
 	api.initControllers(group)
 	return api
@@ -27,4 +30,5 @@ func New(group *echo.Group, depends Depends) *API {
 // initControllers инициализация контроллеров
 func (a *API) initControllers(group *echo.Group) {
 	a.orderController(group.Group("/orders"))
+	a.customerController(group.Group("/customers"))
 }
@@ -0,0 +1,71 @@
+package v1api
+
+import (
+	"context"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/delivery/http/view"
+	"wb_test_task/api/internal/domain"
+)
+
+// maxCustomerIDLength длина users.id в базе
+const maxCustomerIDLength = 500
+
+//go:generate mockgen -source=customer.go -destination=mocks/customer_mock.go
+type customerService interface {
+	GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error)
+	ListOrders(ctx context.Context, customerID string, filter *domain.OrderFilter) (*domain.OrderPage, error)
+}
+
+func (a *API) customerController(g *echo.Group) {
+	g.GET("/:id", func(c echo.Context) error {
+		return a.getCustomerSummary(c)
+	})
+	g.GET("/:id/orders", func(c echo.Context) error {
+		return a.listCustomerOrders(c)
+	})
+}
+
+func (a *API) getCustomerSummary(c echo.Context) error {
+	id := c.Param("id")
+	if err := validateCustomerID(id); err != nil {
+		return view.ErrorResponse(c, err)
+	}
+
+	summary, err := a.customerService.GetSummary(c.Request().Context(), id)
+	if err != nil {
+		return view.ErrorResponseSwitch(c, err)
+	}
+
+	return view.SuccessResponse(c, http.StatusOK, summary)
+}
+
+func (a *API) listCustomerOrders(c echo.Context) error {
+	id := c.Param("id")
+	if err := validateCustomerID(id); err != nil {
+		return view.ErrorResponse(c, err)
+	}
+
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return view.ErrorResponse(c, err)
+	}
+
+	page, err := a.customerService.ListOrders(c.Request().Context(), id, filter)
+	if err != nil {
+		return view.ErrorResponseSwitch(c, err)
+	}
+
+	return view.SuccessResponse(c, http.StatusOK, page)
+}
+
+func validateCustomerID(id string) error {
+	if len(id) == 0 || len(id) > maxCustomerIDLength {
+		return common.WrapError{Code: http.StatusBadRequest, Err: view.ErrInvalidCustomerID,
+			Msg: fmt.Sprintf("customer id must be from 1 to %d characters", maxCustomerIDLength)}
+	}
+
+	return nil
+}
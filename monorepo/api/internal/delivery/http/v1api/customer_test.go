@@ -0,0 +1,110 @@
+package v1api
+
+import (
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wb_test_task/api/internal/common"
+	mock_v1api "wb_test_task/api/internal/delivery/http/v1api/mocks"
+	"wb_test_task/api/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+func TestGetCustomerSummary(t *testing.T) {
+	lastOrderAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	notExists := common.WrapError{Err: domain.ErrCustomerNotExists, Msg: domain.ErrCustomerNotExists.Error()}
+
+	testCases := []struct {
+		name                 string
+		path                 string
+		mockBehavior         func(s *mock_v1api.MockcustomerService)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			path: "/customers/test",
+			mockBehavior: func(s *mock_v1api.MockcustomerService) {
+				s.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{
+					CustomerID:   "test",
+					OrdersCount:  1,
+					FirstOrderAt: &lastOrderAt,
+					LastOrderAt:  &lastOrderAt,
+					TotalSpent:   map[string]json.Number{"USD": "1817"},
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: `{"code":"OK","status":"ok","body":{"customer_id":"test","orders_count":1,` +
+				`"first_order_at":"2021-11-26T06:22:19Z","last_order_at":"2021-11-26T06:22:19Z","total_spent":{"USD":1817}},"error":""}` + "\n",
+		},
+		{
+			name:                 "Invalid customer id",
+			path:                 "/customers/" + strings.Repeat("a", maxCustomerIDLength+1),
+			mockBehavior:         func(s *mock_v1api.MockcustomerService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"customer id must be from 1 to 500 characters"}` + "\n",
+		},
+		{
+			name: "Customer does not exists",
+			path: "/customers/test",
+			mockBehavior: func(s *mock_v1api.MockcustomerService) {
+				s.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, notExists)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: `{"code":"Not Found","status":"fail","body":null,"error":"customer does not exists"}` + "\n",
+		},
+		{
+			name: "OK. Customer orders",
+			path: "/customers/test/orders?limit=1",
+			mockBehavior: func(s *mock_v1api.MockcustomerService) {
+				s.EXPECT().ListOrders(gomock.Any(), "test", &domain.OrderFilter{Sort: domain.SortDateCreatedDesc, Limit: 1}).
+					Return(&domain.OrderPage{Orders: []*model.Order{}}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: `{"code":"OK","status":"ok","body":{"orders":[],"next_cursor":""},"error":""}` + "\n",
+		},
+		{
+			name:                 "Customer orders. Invalid filter",
+			path:                 "/customers/test/orders?limit=0",
+			mockBehavior:         func(s *mock_v1api.MockcustomerService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"limit must be from 1 to 100"}` + "\n",
+		},
+		{
+			name: "Customer orders. Customer does not exists",
+			path: "/customers/test/orders",
+			mockBehavior: func(s *mock_v1api.MockcustomerService) {
+				s.EXPECT().ListOrders(gomock.Any(), "test", gomock.Any()).Return(&domain.OrderPage{Orders: []*model.Order{}}, notExists)
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: `{"code":"Not Found","status":"fail","body":null,"error":"customer does not exists"}` + "\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			customerService := mock_v1api.NewMockcustomerService(ct)
+			test.mockBehavior(customerService)
+
+			e := echo.New()
+			api := API{customerService: customerService}
+			api.customerController(e.Group("/customers"))
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.Equal(t, test.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: customer.go
+
+// Package mock_v1api is a generated GoMock package.
+package mock_v1api
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "wb_test_task/api/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockcustomerService is a mock of customerService interface.
+type MockcustomerService struct {
+	ctrl     *gomock.Controller
+	recorder *MockcustomerServiceMockRecorder
+}
+
+// MockcustomerServiceMockRecorder is the mock recorder for MockcustomerService.
+type MockcustomerServiceMockRecorder struct {
+	mock *MockcustomerService
+}
+
+// NewMockcustomerService creates a new mock instance.
+func NewMockcustomerService(ctrl *gomock.Controller) *MockcustomerService {
+	mock := &MockcustomerService{ctrl: ctrl}
+	mock.recorder = &MockcustomerServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcustomerService) EXPECT() *MockcustomerServiceMockRecorder {
+	return m.recorder
+}
+
+// GetSummary mocks base method.
+func (m *MockcustomerService) GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSummary", ctx, customerID)
+	ret0, _ := ret[0].(*domain.CustomerSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSummary indicates an expected call of GetSummary.
+func (mr *MockcustomerServiceMockRecorder) GetSummary(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSummary", reflect.TypeOf((*MockcustomerService)(nil).GetSummary), ctx, customerID)
+}
+
+// ListOrders mocks base method.
+func (m *MockcustomerService) ListOrders(ctx context.Context, customerID string, filter *domain.OrderFilter) (*domain.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, customerID, filter)
+	ret0, _ := ret[0].(*domain.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockcustomerServiceMockRecorder) ListOrders(ctx, customerID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockcustomerService)(nil).ListOrders), ctx, customerID, filter)
+}
@@ -7,6 +7,7 @@ package mock_v1api
 import (
 	context "context"
 	reflect "reflect"
+	domain "wb_test_task/api/internal/domain"
 	model "wb_test_task/libs/model"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,21 @@ func (m *MockorderService) EXPECT() *MockorderServiceMockRecorder {
 	return m.recorder
 }
 
+// Create mocks base method.
+func (m *MockorderService) Create(ctx context.Context, order *model.Order) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, order)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockorderServiceMockRecorder) Create(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockorderService)(nil).Create), ctx, order)
+}
+
 // GetByID mocks base method.
 func (m *MockorderService) GetByID(ctx context.Context, id string) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -49,3 +65,64 @@ func (mr *MockorderServiceMockRecorder) GetByID(ctx, id interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockorderService)(nil).GetByID), ctx, id)
 }
+
+// GetByIDWithETag mocks base method.
+func (m *MockorderService) GetByIDWithETag(ctx context.Context, id string) (*model.Order, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDWithETag", ctx, id)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByIDWithETag indicates an expected call of GetByIDWithETag.
+func (mr *MockorderServiceMockRecorder) GetByIDWithETag(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDWithETag", reflect.TypeOf((*MockorderService)(nil).GetByIDWithETag), ctx, id)
+}
+
+// GetByKey mocks base method.
+func (m *MockorderService) GetByKey(ctx context.Context, key domain.LookupKey, value string) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByKey", ctx, key, value)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByKey indicates an expected call of GetByKey.
+func (mr *MockorderServiceMockRecorder) GetByKey(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByKey", reflect.TypeOf((*MockorderService)(nil).GetByKey), ctx, key, value)
+}
+
+// GetETag mocks base method.
+func (m *MockorderService) GetETag(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetETag", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetETag indicates an expected call of GetETag.
+func (mr *MockorderServiceMockRecorder) GetETag(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetETag", reflect.TypeOf((*MockorderService)(nil).GetETag), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockorderService) List(ctx context.Context, filter *domain.OrderFilter) (*domain.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].(*domain.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockorderServiceMockRecorder) List(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockorderService)(nil).List), ctx, filter)
+}
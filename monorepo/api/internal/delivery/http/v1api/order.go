@@ -2,23 +2,63 @@ package v1api
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 	"wb_test_task/api/internal/common"
 	"wb_test_task/api/internal/delivery/http/view"
+	"wb_test_task/api/internal/domain"
 	"wb_test_task/libs/model"
 )
 
+const (
+	defaultOrderListLimit = 20
+	maxOrderListLimit     = 100
+	// maxLookupValueLength длина track_number и rid в базе
+	maxLookupValueLength = 500
+)
+
+// заголовков ETag и If-None-Match нет среди констант echo
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
 //go:generate mockgen -source=order.go -destination=mocks/mock.go
 type orderService interface {
 	GetByID(ctx context.Context, id string) (*model.Order, error)
+	GetByIDWithETag(ctx context.Context, id string) (*model.Order, string, error)
+	GetETag(ctx context.Context, id string) (string, error)
+	List(ctx context.Context, filter *domain.OrderFilter) (*domain.OrderPage, error)
+	GetByKey(ctx context.Context, key domain.LookupKey, value string) (*model.Order, error)
+	Create(ctx context.Context, order *model.Order) (bool, error)
 }
 
 func (a *API) orderController(g *echo.Group) {
+	g.GET("", func(c echo.Context) error {
+		return a.listOrders(c)
+	})
+	g.POST("", func(c echo.Context) error {
+		return a.createOrder(c)
+	})
 	g.GET("/:id", func(c echo.Context) error {
 		return a.getOrder(c)
 	})
+	g.GET("/by-track-number/:track_number", func(c echo.Context) error {
+		return a.getOrderByKey(c, domain.LookupTrackNumber)
+	})
+	g.GET("/by-request-id/:request_id", func(c echo.Context) error {
+		return a.getOrderByKey(c, domain.LookupRequestID)
+	})
+	g.GET("/by-rid/:rid", func(c echo.Context) error {
+		return a.getOrderByKey(c, domain.LookupRid)
+	})
 }
 
 func (a *API) getOrder(c echo.Context) error {
@@ -27,7 +67,62 @@ func (a *API) getOrder(c echo.Context) error {
 		return view.ErrorResponse(c, err)
 	}
 
-	order, err := a.orderService.GetByID(c.Request().Context(), id)
+	// If-None-Match сначала сверяется с ETag из кэша, чтобы не читать заказ
+	ifNoneMatch := c.Request().Header.Get(headerIfNoneMatch)
+	if len(ifNoneMatch) != 0 {
+		if etag, err := a.orderService.GetETag(c.Request().Context(), id); err == nil && etagMatch(ifNoneMatch, etag) {
+			a.setOrderCacheHeaders(c, etag)
+			return c.NoContent(http.StatusNotModified)
+		}
+	}
+
+	order, etag, err := a.orderService.GetByIDWithETag(c.Request().Context(), id)
+	if err != nil {
+		return view.ErrorResponseSwitch(c, err)
+	}
+
+	a.setOrderCacheHeaders(c, etag)
+	if lastModified, ok := domain.OrderLastModified(order); ok {
+		c.Response().Header().Set(echo.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	if len(ifNoneMatch) != 0 && etagMatch(ifNoneMatch, etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return view.SuccessResponse(c, http.StatusOK, order)
+}
+
+// setOrderCacheHeaders выставить ETag и Cache-Control ответа с заказом
+func (a *API) setOrderCacheHeaders(c echo.Context, etag string) {
+	c.Response().Header().Set(headerETag, etag)
+	if len(a.cfg.OrderCacheControl) != 0 {
+		c.Response().Header().Set(echo.HeaderCacheControl, a.cfg.OrderCacheControl)
+	}
+}
+
+// etagMatch совпадает ли ETag с одним из ETag заголовка If-None-Match. If-None-Match
+// сравнивается слабо: префикс W/ не учитывается, * совпадает с любым ETag
+func etagMatch(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// getOrderByKey вернуть заказ по альтернативному ключу, значение ключа - path параметр с именем ключа
+func (a *API) getOrderByKey(c echo.Context, key domain.LookupKey) error {
+	value := c.Param(string(key))
+	if err := validateLookupValue(key, value); err != nil {
+		return view.ErrorResponse(c, err)
+	}
+
+	order, err := a.orderService.GetByKey(c.Request().Context(), key, value)
 	if err != nil {
 		return view.ErrorResponseSwitch(c, err)
 	}
@@ -35,6 +130,19 @@ func (a *API) getOrder(c echo.Context) error {
 	return view.SuccessResponse(c, http.StatusOK, order)
 }
 
+func validateLookupValue(key domain.LookupKey, value string) error {
+	if len(value) == 0 || len(value) > maxLookupValueLength {
+		return common.WrapError{Code: http.StatusBadRequest, Err: view.ErrInvalidLookupValue, Msg: fmt.Sprintf("%s must be from 1 to %d characters", key, maxLookupValueLength)}
+	}
+	if key == domain.LookupRequestID {
+		if _, err := uuid.Parse(value); err != nil {
+			return common.WrapError{Code: http.StatusBadRequest, Err: view.ErrInvalidLookupValue, Msg: "invalid request_id uuid"}
+		}
+	}
+
+	return nil
+}
+
 func validateOrderID(id string) error {
 	if _, err := uuid.Parse(id); err != nil {
 		return common.WrapError{Code: http.StatusBadRequest, Err: view.ErrInvalidOrderID, Msg: "invalid order uuid id"}
@@ -42,3 +150,109 @@ func validateOrderID(id string) error {
 
 	return nil
 }
+
+// createOrder принять заказ и опубликовать его в jetstream, заказ записывает consumer,
+// поэтому в ответе возвращается адрес, по которому заказ появится после записи
+func (a *API) createOrder(c echo.Context) error {
+	var order model.Order
+	if err := json.NewDecoder(c.Request().Body).Decode(&order); err != nil {
+		return view.ErrorResponse(c, common.WrapError{Code: http.StatusBadRequest, Err: view.ErrInvalidOrderBody, Msg: "invalid order json: " + err.Error()})
+	}
+
+	duplicate, err := a.orderService.Create(c.Request().Context(), &order)
+	if err != nil {
+		return view.ErrorResponseSwitch(c, err)
+	}
+
+	statusURL := strings.TrimSuffix(c.Request().URL.Path, "/") + "/" + url.PathEscape(order.OrderUid)
+	c.Response().Header().Set(echo.HeaderLocation, statusURL)
+	return view.SuccessResponse(c, http.StatusAccepted, domain.OrderAccepted{
+		OrderUid:  order.OrderUid,
+		StatusURL: statusURL,
+		Duplicate: duplicate,
+	})
+}
+
+func (a *API) listOrders(c echo.Context) error {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		return view.ErrorResponse(c, err)
+	}
+
+	page, err := a.orderService.List(c.Request().Context(), filter)
+	if err != nil {
+		return view.ErrorResponseSwitch(c, err)
+	}
+
+	return view.SuccessResponse(c, http.StatusOK, page)
+}
+
+// parseOrderFilter разобрать фильтры, сортировку и страницу списка заказов из query параметров
+func parseOrderFilter(c echo.Context) (*domain.OrderFilter, error) {
+	filter := &domain.OrderFilter{
+		CustomerID:      c.QueryParam("customer_id"),
+		DeliveryService: c.QueryParam("delivery_service"),
+		Entry:           c.QueryParam("entry"),
+		Locale:          c.QueryParam("locale"),
+		Currency:        c.QueryParam("currency"),
+		Sort:            domain.SortDateCreatedDesc,
+		Limit:           defaultOrderListLimit,
+	}
+
+	if sort := c.QueryParam("sort"); len(sort) != 0 {
+		if sort != domain.SortDateCreatedAsc && sort != domain.SortDateCreatedDesc {
+			return nil, invalidOrderFilter(fmt.Sprintf("sort must be %s or %s", domain.SortDateCreatedAsc, domain.SortDateCreatedDesc))
+		}
+		filter.Sort = sort
+	}
+
+	if limit := c.QueryParam("limit"); len(limit) != 0 {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 || value > maxOrderListLimit {
+			return nil, invalidOrderFilter(fmt.Sprintf("limit must be from 1 to %d", maxOrderListLimit))
+		}
+		filter.Limit = value
+	}
+
+	var err error
+	if filter.DateFrom, err = parseDateParam(c, "date_from"); err != nil {
+		return nil, err
+	}
+	if filter.DateTo, err = parseDateParam(c, "date_to"); err != nil {
+		return nil, err
+	}
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateFrom.After(*filter.DateTo) {
+		return nil, invalidOrderFilter("date_from must not be after date_to")
+	}
+
+	if token := c.QueryParam("cursor"); len(token) != 0 {
+		cursor, err := domain.DecodeCursor(token)
+		if err != nil {
+			return nil, invalidOrderFilter(err.Error())
+		}
+		if cursor.Sort != filter.Sort {
+			return nil, invalidOrderFilter(fmt.Sprintf("cursor was issued for sort %s", cursor.Sort))
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
+
+// parseDateParam разобрать дату RFC3339 из query параметра, nil - параметр не передан
+func parseDateParam(c echo.Context, param string) (*time.Time, error) {
+	value := c.QueryParam(param)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, invalidOrderFilter(param + " must be RFC3339 date")
+	}
+	return &date, nil
+}
+
+func invalidOrderFilter(msg string) error {
+	return common.WrapError{Code: http.StatusBadRequest, Err: view.ErrInvalidOrderFilter, Msg: msg}
+}
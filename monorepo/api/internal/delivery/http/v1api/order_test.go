@@ -11,8 +11,11 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/config"
 	mock_v1api "wb_test_task/api/internal/delivery/http/v1api/mocks"
 	"wb_test_task/api/internal/domain"
 	"wb_test_task/libs/model"
@@ -30,6 +33,8 @@ func init() {
 }
 
 func TestGetOrder(t *testing.T) {
+	const etag = `"0123456789abcdef"`
+
 	testCases := []struct {
 		name                 string
 		expectedStatusCode   int
@@ -40,7 +45,9 @@ func TestGetOrder(t *testing.T) {
 		httpParam struct {
 			key, value string
 		}
-		mockBehavior func(s *mock_v1api.MockorderService, ctx context.Context, id string)
+		ifNoneMatch     string
+		expectedHeaders map[string]string
+		mockBehavior    func(s *mock_v1api.MockorderService, ctx context.Context, id string)
 	}{
 		{
 			name:               "OK",
@@ -48,7 +55,7 @@ func TestGetOrder(t *testing.T) {
 			mockInput:          struct{ orderID string }{orderID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
 			httpParam:          struct{ key, value string }{key: "id", value: "5d110e48-9e6b-4928-b436-14194b30d54f"},
 			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
-				s.EXPECT().GetByID(ctx, id).Return(&model.Order{
+				s.EXPECT().GetByIDWithETag(ctx, id).Return(&model.Order{
 					OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
 					TrackNumber: "WBILMTESTTRACK3",
 					Entry:       "WBIL",
@@ -96,7 +103,12 @@ func TestGetOrder(t *testing.T) {
 					SmID:              99,
 					DateCreated:       "2021-11-26 06:22:19 +0000 UTC",
 					OofShard:          "1",
-				}, nil)
+				}, etag, nil)
+			},
+			expectedHeaders: map[string]string{
+				headerETag:              etag,
+				echo.HeaderCacheControl: "no-cache",
+				echo.HeaderLastModified: "Fri, 26 Nov 2021 06:22:19 GMT",
 			},
 			expectedResponseBody: fmt.Sprintf(`{"code":"OK","status":"ok","body":{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","track_number":"WBILMTESTTRACK3","entry":"WBIL","delivery":{"name":"Test Testov","phone":"+9720000000","zip":"2639809","city":"Kiryat Mozkin","address":"Ploshad Mira 15","region":"Kraiot","email":"test@gmail.com"},"payment":{"transaction":"5d110e48-9e6b-4928-b436-14194b30d54f","request_id":"5d110e48-9e6b-4928-b436-14194b30d54f","currency":"USD","provider":"wbpay","amount":1817,"payment_dt":1637907727,"bank":"alpha","delivery_cost":1500,"goods_total":317,"custom_fee":0},"items":[{"chrt_id":9934930,"track_number":"WBILMTESTTRACK3","price":453,"rid":"ab4219087a764ae0btest","name":"Mascaras","sale":30,"size":"0","total_price":317,"nm_id":2389212,"brand":"Vivienne Sabo","status":202}],"locale":"en","internal_signature":"","customer_id":"test","delivery_service":"meest","shard_key":"","sm_id":99,"date_created":"2021-11-26 06:22:19 +0000 UTC","oof_shard":"1"},"error":""}%s`, "\n"),
 		},
@@ -106,7 +118,7 @@ func TestGetOrder(t *testing.T) {
 			httpParam:          struct{ key, value string }{key: "id", value: "invalid"},
 			mockInput:          struct{ orderID string }{orderID: "invalid"},
 			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
-				s.EXPECT().GetByID(ctx, id).AnyTimes()
+				s.EXPECT().GetByIDWithETag(ctx, id).AnyTimes()
 			},
 			expectedResponseBody: fmt.Sprintf(`{"code":"Bad Request","status":"fail","body":null,"error":"invalid order uuid id"}%s`, "\n"),
 		},
@@ -116,7 +128,7 @@ func TestGetOrder(t *testing.T) {
 			httpParam:          struct{ key, value string }{key: "", value: ""},
 			mockInput:          struct{ orderID string }{orderID: ""},
 			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
-				s.EXPECT().GetByID(ctx, id).AnyTimes()
+				s.EXPECT().GetByIDWithETag(ctx, id).AnyTimes()
 			},
 			expectedResponseBody: fmt.Sprintf(`{"code":"Bad Request","status":"fail","body":null,"error":"invalid order uuid id"}%s`, "\n"),
 		},
@@ -126,8 +138,9 @@ func TestGetOrder(t *testing.T) {
 			mockInput:          struct{ orderID string }{orderID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
 			httpParam:          struct{ key, value string }{key: "id", value: "5d110e48-9e6b-4928-b436-14194b30d54f"},
 			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
-				s.EXPECT().GetByID(ctx, id).Return(
+				s.EXPECT().GetByIDWithETag(ctx, id).Return(
 					&model.Order{Items: []*model.Product{}},
+					"",
 					common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()},
 				)
 			},
@@ -139,10 +152,78 @@ func TestGetOrder(t *testing.T) {
 			mockInput:          struct{ orderID string }{orderID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
 			httpParam:          struct{ key, value string }{key: "id", value: "5d110e48-9e6b-4928-b436-14194b30d54f"},
 			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
-				s.EXPECT().GetByID(ctx, id).Return(&model.Order{Items: []*model.Product{}}, errors.New("unexpected error"))
+				s.EXPECT().GetByIDWithETag(ctx, id).Return(&model.Order{Items: []*model.Product{}}, "", errors.New("unexpected error"))
 			},
 			expectedResponseBody: fmt.Sprintf(`{"code":"Internal Server Error","status":"fail","body":null,"error":"unexpected error"}%s`, "\n"),
 		},
+		{
+			name:               "Not modified. ETag from cache",
+			expectedStatusCode: http.StatusNotModified,
+			mockInput:          struct{ orderID string }{orderID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			httpParam:          struct{ key, value string }{key: "id", value: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			ifNoneMatch:        etag,
+			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
+				s.EXPECT().GetETag(ctx, id).Return(etag, nil)
+			},
+			expectedHeaders: map[string]string{
+				headerETag:              etag,
+				echo.HeaderCacheControl: "no-cache",
+			},
+		},
+		{
+			name:               "Not modified. Weak ETag in list",
+			expectedStatusCode: http.StatusNotModified,
+			mockInput:          struct{ orderID string }{orderID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			httpParam:          struct{ key, value string }{key: "id", value: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			ifNoneMatch:        `"stale", W/` + etag,
+			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
+				s.EXPECT().GetETag(ctx, id).Return(etag, nil)
+			},
+			expectedHeaders: map[string]string{headerETag: etag},
+		},
+		{
+			name:               "Not modified. ETag is not cached",
+			expectedStatusCode: http.StatusNotModified,
+			mockInput:          struct{ orderID string }{orderID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			httpParam:          struct{ key, value string }{key: "id", value: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			ifNoneMatch:        etag,
+			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
+				s.EXPECT().GetETag(ctx, id).Return("", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
+				s.EXPECT().GetByIDWithETag(ctx, id).Return(&model.Order{
+					OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+					Items:       []*model.Product{},
+					DateCreated: "2021-11-26T06:22:19Z",
+					Timeline: []*model.StatusChange{
+						{Status: "created", ChangedAt: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)},
+						{Status: "paid", ChangedAt: time.Date(2021, 11, 26, 7, 0, 0, 0, time.UTC)},
+					},
+				}, etag, nil)
+			},
+			expectedHeaders: map[string]string{
+				headerETag:              etag,
+				echo.HeaderLastModified: "Fri, 26 Nov 2021 07:00:00 GMT",
+			},
+		},
+		{
+			name:               "Modified",
+			expectedStatusCode: http.StatusOK,
+			mockInput:          struct{ orderID string }{orderID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			httpParam:          struct{ key, value string }{key: "id", value: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			ifNoneMatch:        `"stale"`,
+			mockBehavior: func(s *mock_v1api.MockorderService, ctx context.Context, id string) {
+				s.EXPECT().GetETag(ctx, id).Return(etag, nil)
+				s.EXPECT().GetByIDWithETag(ctx, id).Return(&model.Order{
+					OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+					Items:       []*model.Product{},
+					DateCreated: "2021-11-26T06:22:19Z",
+				}, etag, nil)
+			},
+			expectedHeaders: map[string]string{
+				headerETag:              etag,
+				echo.HeaderLastModified: "Fri, 26 Nov 2021 06:22:19 GMT",
+			},
+			expectedResponseBody: fmt.Sprintf(`{"code":"OK","status":"ok","body":{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","track_number":"","entry":"","delivery":{"name":"","phone":"","zip":"","city":"","address":"","region":"","email":""},"payment":{"transaction":"","request_id":"","currency":"","provider":"","amount":0,"payment_dt":0,"bank":"","delivery_cost":0,"goods_total":0,"custom_fee":0},"items":[],"locale":"","internal_signature":"","customer_id":"","delivery_service":"","shard_key":"","sm_id":0,"date_created":"2021-11-26T06:22:19Z","oof_shard":""},"error":""}%s`, "\n"),
+		},
 	}
 
 	for _, test := range testCases {
@@ -154,6 +235,9 @@ func TestGetOrder(t *testing.T) {
 			test.mockBehavior(orderService, context.Background(), test.mockInput.orderID)
 
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(test.ifNoneMatch) != 0 {
+				req.Header.Set(headerIfNoneMatch, test.ifNoneMatch)
+			}
 			rec := httptest.NewRecorder()
 
 			e := echo.New()
@@ -161,12 +245,318 @@ func TestGetOrder(t *testing.T) {
 			c.SetParamNames(test.httpParam.key)
 			c.SetParamValues(test.httpParam.value)
 
-			api := API{orderService: orderService}
+			api := API{cfg: config.HttpServer{OrderCacheControl: "no-cache"}, orderService: orderService}
 
 			if assert.NoError(t, api.getOrder(c)) {
 				assert.Equal(t, test.expectedStatusCode, rec.Code)
 				assert.Equal(t, test.expectedResponseBody, rec.Body.String())
+				for header, value := range test.expectedHeaders {
+					assert.Equal(t, value, rec.Header().Get(header), header)
+				}
 			}
 		})
 	}
 }
+
+func TestListOrders(t *testing.T) {
+	cursor := domain.OrderCursor{
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+		Sort:        domain.SortDateCreatedAsc,
+	}
+	dateFrom := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name                 string
+		query                string
+		mockBehavior         func(s *mock_v1api.MockorderService)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			query: "customer_id=test&date_from=2021-11-01T00:00:00Z&sort=date_created&limit=1&cursor=" + cursor.Encode(),
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().List(gomock.Any(), &domain.OrderFilter{
+					CustomerID: "test",
+					DateFrom:   &dateFrom,
+					Sort:       domain.SortDateCreatedAsc,
+					Limit:      1,
+					Cursor:     &cursor,
+				}).Return(&domain.OrderPage{
+					Orders:     []*model.Order{{OrderUid: "9c1e0f0e-6f7a-4a7e-9a63-3e2a6c5b1d11", Items: []*model.Product{}}},
+					NextCursor: "next",
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: `{"code":"OK","status":"ok","body":{"orders":[{"order_uid":"9c1e0f0e-6f7a-4a7e-9a63-3e2a6c5b1d11",` +
+				`"track_number":"","entry":"","delivery":{"name":"","phone":"","zip":"","city":"","address":"","region":"","email":""},` +
+				`"payment":{"transaction":"","request_id":"","currency":"","provider":"","amount":0,"payment_dt":0,"bank":"",` +
+				`"delivery_cost":0,"goods_total":0,"custom_fee":0},"items":[],"locale":"","internal_signature":"","customer_id":"",` +
+				`"delivery_service":"","shard_key":"","sm_id":0,"date_created":"","oof_shard":""}],"next_cursor":"next"},"error":""}` + "\n",
+		},
+		{
+			name:  "Default sort and limit",
+			query: "",
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().List(gomock.Any(), &domain.OrderFilter{Sort: domain.SortDateCreatedDesc, Limit: defaultOrderListLimit}).
+					Return(&domain.OrderPage{Orders: []*model.Order{}}, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: `{"code":"OK","status":"ok","body":{"orders":[],"next_cursor":""},"error":""}` + "\n",
+		},
+		{
+			name:                 "Invalid limit",
+			query:                "limit=1000",
+			mockBehavior:         func(s *mock_v1api.MockorderService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"limit must be from 1 to 100"}` + "\n",
+		},
+		{
+			name:                 "Invalid sort",
+			query:                "sort=track_number",
+			mockBehavior:         func(s *mock_v1api.MockorderService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"sort must be date_created or -date_created"}` + "\n",
+		},
+		{
+			name:                 "Invalid date range",
+			query:                "date_from=2021-12-01T00:00:00Z&date_to=2021-11-01T00:00:00Z",
+			mockBehavior:         func(s *mock_v1api.MockorderService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"date_from must not be after date_to"}` + "\n",
+		},
+		{
+			name:                 "Invalid cursor",
+			query:                "cursor=invalid",
+			mockBehavior:         func(s *mock_v1api.MockorderService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"invalid cursor"}` + "\n",
+		},
+		{
+			name:                 "Cursor of another sort",
+			query:                "sort=-date_created&cursor=" + cursor.Encode(),
+			mockBehavior:         func(s *mock_v1api.MockorderService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"cursor was issued for sort date_created"}` + "\n",
+		},
+		{
+			name:  "Invalid filter value",
+			query: "locale=xx",
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().List(gomock.Any(), gomock.Any()).Return(&domain.OrderPage{Orders: []*model.Order{}},
+					common.WrapError{Err: domain.ErrInvalidSyntax, Msg: `invalid input value for enum locale_type: "xx"`})
+			},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"invalid input value for enum locale_type: \"xx\""}` + "\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			orderService := mock_v1api.NewMockorderService(ct)
+			test.mockBehavior(orderService)
+
+			req := httptest.NewRequest(http.MethodGet, "/?"+test.query, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+
+			api := API{orderService: orderService}
+
+			if assert.NoError(t, api.listOrders(c)) {
+				assert.Equal(t, test.expectedStatusCode, rec.Code)
+				assert.Equal(t, test.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetOrderByKey(t *testing.T) {
+	const orderUid = "5d110e48-9e6b-4928-b436-14194b30d54f"
+	order := &model.Order{OrderUid: orderUid, Items: []*model.Product{}}
+	orderBody := `{"code":"OK","status":"ok","body":{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f",` +
+		`"track_number":"","entry":"","delivery":{"name":"","phone":"","zip":"","city":"","address":"","region":"","email":""},` +
+		`"payment":{"transaction":"","request_id":"","currency":"","provider":"","amount":0,"payment_dt":0,"bank":"",` +
+		`"delivery_cost":0,"goods_total":0,"custom_fee":0},"items":[],"locale":"","internal_signature":"","customer_id":"",` +
+		`"delivery_service":"","shard_key":"","sm_id":0,"date_created":"","oof_shard":""},"error":""}` + "\n"
+
+	testCases := []struct {
+		name                 string
+		path                 string
+		mockBehavior         func(s *mock_v1api.MockorderService)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK. By track number",
+			path: "/orders/by-track-number/WBILMTESTTRACK3",
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().GetByKey(gomock.Any(), domain.LookupTrackNumber, "WBILMTESTTRACK3").Return(order, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: orderBody,
+		},
+		{
+			name: "OK. By request id",
+			path: "/orders/by-request-id/" + orderUid,
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().GetByKey(gomock.Any(), domain.LookupRequestID, orderUid).Return(order, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: orderBody,
+		},
+		{
+			name: "OK. By rid",
+			path: "/orders/by-rid/ab4219087a764ae0btest",
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().GetByKey(gomock.Any(), domain.LookupRid, "ab4219087a764ae0btest").Return(order, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: orderBody,
+		},
+		{
+			name:                 "Invalid request id",
+			path:                 "/orders/by-request-id/invalid",
+			mockBehavior:         func(s *mock_v1api.MockorderService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"invalid request_id uuid"}` + "\n",
+		},
+		{
+			name: "Order does not exists",
+			path: "/orders/by-track-number/WBILMTESTTRACK3",
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().GetByKey(gomock.Any(), domain.LookupTrackNumber, "WBILMTESTTRACK3").Return(&model.Order{Items: []*model.Product{}},
+					common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
+			},
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: `{"code":"Not Found","status":"fail","body":null,"error":"order does not exists"}` + "\n",
+		},
+		{
+			name: "Several orders match the rid",
+			path: "/orders/by-rid/ab4219087a764ae0btest",
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().GetByKey(gomock.Any(), domain.LookupRid, "ab4219087a764ae0btest").Return(&model.Order{Items: []*model.Product{}},
+					common.WrapError{Err: domain.ErrAmbiguousLookup, Msg: `several orders match rid "ab4219087a764ae0btest"`})
+			},
+			expectedStatusCode:   http.StatusConflict,
+			expectedResponseBody: `{"code":"Conflict","status":"fail","body":null,"error":"several orders match rid \"ab4219087a764ae0btest\""}` + "\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			orderService := mock_v1api.NewMockorderService(ct)
+			test.mockBehavior(orderService)
+
+			e := echo.New()
+			api := API{orderService: orderService}
+			api.orderController(e.Group("/orders"))
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.Equal(t, test.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	const orderUid = "5d110e48-9e6b-4928-b436-14194b30d54f"
+	body := `{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","track_number":"WBILMTESTTRACK3","customer_id":"test"}`
+	order := &model.Order{OrderUid: orderUid, TrackNumber: "WBILMTESTTRACK3", CustomerID: "test"}
+
+	testCases := []struct {
+		name                 string
+		body                 string
+		mockBehavior         func(s *mock_v1api.MockorderService)
+		expectedStatusCode   int
+		expectedLocation     string
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			body: body,
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().Create(gomock.Any(), order).Return(false, nil)
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/orders/" + orderUid,
+			expectedResponseBody: `{"code":"Accepted","status":"ok","body":{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f",` +
+				`"status_url":"/api/v1/orders/5d110e48-9e6b-4928-b436-14194b30d54f","duplicate":false},"error":""}` + "\n",
+		},
+		{
+			name: "OK. Duplicate order",
+			body: body,
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().Create(gomock.Any(), order).Return(true, nil)
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedLocation:   "/api/v1/orders/" + orderUid,
+			expectedResponseBody: `{"code":"Accepted","status":"ok","body":{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f",` +
+				`"status_url":"/api/v1/orders/5d110e48-9e6b-4928-b436-14194b30d54f","duplicate":true},"error":""}` + "\n",
+		},
+		{
+			name:                 "Invalid json",
+			body:                 `{"order_uid":`,
+			mockBehavior:         func(s *mock_v1api.MockorderService) {},
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":null,"error":"invalid order json: unexpected EOF"}` + "\n",
+		},
+		{
+			name: "Validation error",
+			body: body,
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				violations := model.Violations{{Field: "locale", Message: "must be one of [en]"}}
+				s.EXPECT().Create(gomock.Any(), order).Return(false,
+					common.WrapError{Err: domain.ErrOrderValidation, Msg: violations.Error(), Body: violations})
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: `{"code":"Bad Request","status":"fail","body":[{"field":"locale","message":"must be one of [en]"}],` +
+				`"error":"locale: must be one of [en]"}` + "\n",
+		},
+		{
+			name: "Error from publisher",
+			body: body,
+			mockBehavior: func(s *mock_v1api.MockorderService) {
+				s.EXPECT().Create(gomock.Any(), order).Return(false,
+					common.WrapError{Err: domain.ErrOrderNotPublished, Msg: "fail to publish order: nats: no responders available for request"})
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedResponseBody: `{"code":"Service Unavailable","status":"fail","body":null,` +
+				`"error":"fail to publish order: nats: no responders available for request"}` + "\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			orderService := mock_v1api.NewMockorderService(ct)
+			test.mockBehavior(orderService)
+
+			e := echo.New()
+			api := API{orderService: orderService}
+			api.orderController(e.Group("/api/v1/orders"))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.Equal(t, test.expectedLocation, rec.Header().Get(echo.HeaderLocation))
+			assert.Equal(t, test.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
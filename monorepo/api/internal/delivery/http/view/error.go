@@ -3,5 +3,9 @@ package view
 import "github.com/pkg/errors"
 
 var (
-	ErrInvalidOrderID = errors.New("invalid order id")
+	ErrInvalidOrderID     = errors.New("invalid order id")
+	ErrInvalidOrderFilter = errors.New("invalid order filter")
+	ErrInvalidLookupValue = errors.New("invalid order lookup value")
+	ErrInvalidCustomerID  = errors.New("invalid customer id")
+	ErrInvalidOrderBody   = errors.New("invalid order body")
 )
@@ -57,10 +57,16 @@ func ErrorResponseSwitch(c echo.Context, err error) error {
 	}
 
 	switch httpErr.Err {
-	case domain.ErrOrderNotExists, domain.ErrItemsNotExists:
+	case domain.ErrOrderNotExists, domain.ErrItemsNotExists, domain.ErrCustomerNotExists:
 		return ErrorResponse(c, common.WrapError{Code: http.StatusNotFound, Err: httpErr.Err, Msg: httpErr.Msg})
 	case ErrInvalidOrderID, domain.ErrInvalidSyntax:
 		return ErrorResponse(c, common.WrapError{Code: http.StatusBadRequest, Err: httpErr.Err, Msg: httpErr.Msg})
+	case domain.ErrOrderValidation:
+		return ErrorResponse(c, common.WrapError{Code: http.StatusBadRequest, Err: httpErr.Err, Msg: httpErr.Msg, Body: httpErr.Body})
+	case domain.ErrOrderNotPublished:
+		return ErrorResponse(c, common.WrapError{Code: http.StatusServiceUnavailable, Err: httpErr.Err, Msg: httpErr.Msg})
+	case domain.ErrAmbiguousLookup:
+		return ErrorResponse(c, common.WrapError{Code: http.StatusConflict, Err: httpErr.Err, Msg: httpErr.Msg})
 	default:
 		return ErrorResponse(c, common.WrapError{Code: http.StatusInternalServerError, Err: httpErr.Err, Msg: httpErr.Msg})
 	}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CustomerSummary сводка по заказам покупателя
+type CustomerSummary struct {
+	CustomerID  string `json:"customer_id"`
+	OrdersCount int    `json:"orders_count"`
+	// FirstOrderAt, LastOrderAt даты первого и последнего заказа, nil - у покупателя нет заказов
+	FirstOrderAt *time.Time `json:"first_order_at"`
+	LastOrderAt  *time.Time `json:"last_order_at"`
+	// TotalSpent сумма оплат заказов по валютам. Суммы DECIMAL передаются как есть, без округления float64
+	TotalSpent map[string]json.Number `json:"total_spent"`
+}
@@ -6,6 +6,13 @@ var (
 	ErrOrderNotExists = errors.New("order does not exists")
 	ErrItemsNotExists = errors.New("items not exists")
 	ErrInvalidSyntax  = errors.New("invalid syntax value")
+	// ErrAmbiguousLookup по ключу поиска найдено несколько заказов
+	ErrAmbiguousLookup   = errors.New("several orders match the lookup key")
+	ErrCustomerNotExists = errors.New("customer does not exists")
+	// ErrOrderValidation заказ нарушает бизнес-правила, нарушения передаются в Body
+	ErrOrderValidation = errors.New("order validation failed")
+	// ErrOrderNotPublished заказ не опубликован в jetstream
+	ErrOrderNotPublished = errors.New("order is not published")
 )
 
 var (
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"time"
+	"wb_test_task/libs/model"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// LookupKey альтернативный ключ поиска заказа
+type LookupKey string
+
+const (
+	LookupTrackNumber LookupKey = "track_number"
+	LookupRequestID   LookupKey = "request_id"
+	LookupRid         LookupKey = "rid"
+)
+
+const (
+	SortDateCreatedAsc  = "date_created"
+	SortDateCreatedDesc = "-date_created"
+)
+
+// OrderAccepted заказ, принятый по http и опубликованный для записи consumer
+type OrderAccepted struct {
+	OrderUid string `json:"order_uid"`
+	// StatusURL адрес заказа, заказ доступен после записи consumer
+	StatusURL string `json:"status_url"`
+	// Duplicate заказ с этим order_uid уже был принят в окне дедупликации jetstream
+	Duplicate bool `json:"duplicate"`
+}
+
+// OrderFilter фильтры и страница списка заказов. Пустые фильтры не применяются
+type OrderFilter struct {
+	CustomerID      string
+	DateFrom        *time.Time
+	DateTo          *time.Time
+	DeliveryService string
+	Entry           string
+	Locale          string
+	Currency        string
+	// Sort порядок по date_created: SortDateCreatedAsc или SortDateCreatedDesc
+	Sort   string
+	Limit  int
+	Cursor *OrderCursor
+}
+
+// Desc заказы отсортированы от новых к старым
+func (f *OrderFilter) Desc() bool {
+	return f.Sort != SortDateCreatedAsc
+}
+
+// OrderCursor позиция последнего заказа страницы, следующая страница начинается после него.
+// Sort порядок страницы, для которой выдан курсор: с другим порядком позиция не имеет смысла
+type OrderCursor struct {
+	DateCreated time.Time `json:"date_created"`
+	OrderUid    string    `json:"order_uid"`
+	Sort        string    `json:"sort"`
+}
+
+// OrderPage страница списка заказов, NextCursor пустой на последней странице
+type OrderPage struct {
+	Orders     []*model.Order `json:"orders"`
+	NextCursor string         `json:"next_cursor"`
+}
+
+// Encode закодировать курсор в токен
+func (c OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разобрать токен курсора
+func DecodeCursor(token string) (*OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor OrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.OrderUid) == 0 || cursor.DateCreated.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != SortDateCreatedAsc && cursor.Sort != SortDateCreatedDesc {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// OrderLastModified время последнего изменения заказа: последняя смена статуса, для заказов
+// без истории статусов - date_created. false - время изменения неизвестно
+func OrderLastModified(order *model.Order) (time.Time, bool) {
+	var lastModified time.Time
+	for _, change := range order.Timeline {
+		if change.ChangedAt.After(lastModified) {
+			lastModified = change.ChangedAt
+		}
+	}
+	if !lastModified.IsZero() {
+		return lastModified, true
+	}
+
+	if date, err := model.ParseDateCreated(order.DateCreated); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: publisher.go
+
+// Package mock_publisher is a generated GoMock package.
+package mock_publisher
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	nats "github.com/nats-io/nats.go"
+	jetstream "github.com/nats-io/nats.go/jetstream"
+)
+
+// MockjetStream is a mock of jetStream interface.
+type MockjetStream struct {
+	ctrl     *gomock.Controller
+	recorder *MockjetStreamMockRecorder
+}
+
+// MockjetStreamMockRecorder is the mock recorder for MockjetStream.
+type MockjetStreamMockRecorder struct {
+	mock *MockjetStream
+}
+
+// NewMockjetStream creates a new mock instance.
+func NewMockjetStream(ctrl *gomock.Controller) *MockjetStream {
+	mock := &MockjetStream{ctrl: ctrl}
+	mock.recorder = &MockjetStreamMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockjetStream) EXPECT() *MockjetStreamMockRecorder {
+	return m.recorder
+}
+
+// PublishMsg mocks base method.
+func (m *MockjetStream) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, msg}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsg", varargs...)
+	ret0, _ := ret[0].(*jetstream.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsg indicates an expected call of PublishMsg.
+func (mr *MockjetStreamMockRecorder) PublishMsg(ctx, msg interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, msg}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsg", reflect.TypeOf((*MockjetStream)(nil).PublishMsg), varargs...)
+}
@@ -0,0 +1,122 @@
+package publisher
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"time"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/config"
+	"wb_test_task/api/internal/domain"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+	"wb_test_task/libs/model"
+	"wb_test_task/libs/natsconn"
+	"wb_test_task/libs/tracing"
+)
+
+// orderCreateSchemaVersion версия схемы order.create, которую разбирает consumer
+const orderCreateSchemaVersion = 2
+
+//go:generate mockgen -source=publisher.go -destination=mocks/mock.go
+type jetStream interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// Publisher публикация заказов в jetstream
+type Publisher struct {
+	conn    *nats.Conn
+	js      jetStream
+	subject string
+	source  string
+	timeout time.Duration
+}
+
+func New(cfg config.NatsPublisher) (*Publisher, error) {
+	opts, err := natsconn.Options(cfg.Connection)
+	if err != nil {
+		return &Publisher{}, errors.Wrap(err, "fail to init nats connection options")
+	}
+	opts = append(opts, natsconn.EventOptions(natsconn.ZapLogger{InfoFunc: logger.Info, WarnFunc: logger.Warn}, nil)...)
+
+	conn, err := nats.Connect(cfg.Url, opts...)
+	if err != nil {
+		return &Publisher{}, errors.Wrap(err, "fail to connect to nats")
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return &Publisher{}, errors.Wrap(err, "fail to create jetstream")
+	}
+
+	return newPublisher(conn, js, cfg), nil
+}
+
+func newPublisher(conn *nats.Conn, js jetStream, cfg config.NatsPublisher) *Publisher {
+	return &Publisher{
+		conn:    conn,
+		js:      js,
+		subject: cfg.Subject,
+		source:  cfg.Source,
+		timeout: time.Duration(cfg.PublishTimeoutMs) * time.Millisecond,
+	}
+}
+
+// PublishOrderCreate опубликовать заказ с id сообщения order_uid. Возвращает true, если
+// jetstream уже принял заказ с этим order_uid в окне дедупликации stream
+func (p *Publisher) PublishOrderCreate(ctx context.Context, order *model.Order) (bool, error) {
+	ctx, span := tracer.StartTrace(ctx, "nats-publisher-publish-"+p.subject)
+	span.SetAttributes(attribute.String("order-id", order.OrderUid))
+	defer span.End()
+
+	msg, err := p.newOrderCreateMsg(ctx, order)
+	if err != nil {
+		return false, common.WrapError{Err: err, Msg: "fail to encode order"}
+	}
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	ack, err := p.js.PublishMsg(ctx, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, common.WrapError{Err: domain.ErrOrderNotPublished, Msg: errors.Wrap(err, "fail to publish order").Error()}
+	}
+
+	return ack.Duplicate, nil
+}
+
+// newOrderCreateMsg закодировать заказ в JSON сообщение с версией схемы и контекстом трассировки
+// в заголовках, Nats-Msg-Id - order_uid, чтобы jetstream отбросил повторную отправку заказа
+func (p *Publisher) newOrderCreateMsg(ctx context.Context, order *model.Order) (*nats.Msg, error) {
+	header := nats.Header{}
+	header.Set(jetstream.MsgIDHeader, order.OrderUid)
+	tracing.Inject(ctx, header)
+	envelope.Metadata{
+		SchemaVersion: orderCreateSchemaVersion,
+		Source:        p.source,
+		ProducedAt:    time.Now(),
+	}.SetHeader(header)
+
+	data, err := codec.Encode(header, order, codec.ContentTypeJSON, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &nats.Msg{Subject: p.subject, Header: header, Data: data}, nil
+}
+
+// Shutdown дождаться отправки опубликованных сообщений и закрыть соединение
+func (p *Publisher) Shutdown() error {
+	return p.conn.Drain()
+}
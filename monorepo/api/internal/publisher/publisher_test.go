@@ -0,0 +1,102 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/api/internal/config"
+	"wb_test_task/api/internal/domain"
+	mock_publisher "wb_test_task/api/internal/publisher/mocks"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+	"wb_test_task/libs/model"
+)
+
+// msgMatcher проверяет subject, заголовки и payload сообщения order.create
+type msgMatcher struct {
+	order *model.Order
+}
+
+func (m msgMatcher) Matches(x interface{}) bool {
+	msg, ok := x.(*nats.Msg)
+	if !ok || msg.Subject != "order.create" || msg.Header.Get(jetstream.MsgIDHeader) != m.order.OrderUid {
+		return false
+	}
+
+	meta, payload, err := envelope.Open(msg.Header, msg.Data)
+	if err != nil || meta.SchemaVersion != orderCreateSchemaVersion || meta.Source != "api" {
+		return false
+	}
+	if msg.Header.Get(codec.HeaderContentType) != codec.ContentTypeJSON {
+		return false
+	}
+
+	var order model.Order
+	if err := json.Unmarshal(payload, &order); err != nil {
+		return false
+	}
+	return order.OrderUid == m.order.OrderUid && order.TrackNumber == m.order.TrackNumber
+}
+
+func (m msgMatcher) String() string {
+	return "order.create message of order " + m.order.OrderUid
+}
+
+func TestPublishOrderCreate(t *testing.T) {
+	order := &model.Order{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", TrackNumber: "WBILMTESTTRACK3"}
+
+	testCases := []struct {
+		name              string
+		mock              func(js *mock_publisher.MockjetStream)
+		expectedDuplicate bool
+		errMsg            string
+	}{
+		{
+			name: "OK",
+			mock: func(js *mock_publisher.MockjetStream) {
+				js.EXPECT().PublishMsg(gomock.Any(), msgMatcher{order: order}).
+					Return(&jetstream.PubAck{Stream: "orders", Sequence: 1}, nil)
+			},
+		},
+		{
+			name: "OK. Duplicate message",
+			mock: func(js *mock_publisher.MockjetStream) {
+				js.EXPECT().PublishMsg(gomock.Any(), msgMatcher{order: order}).
+					Return(&jetstream.PubAck{Stream: "orders", Sequence: 1, Duplicate: true}, nil)
+			},
+			expectedDuplicate: true,
+		},
+		{
+			name: "Error from jetstream",
+			mock: func(js *mock_publisher.MockjetStream) {
+				js.EXPECT().PublishMsg(gomock.Any(), gomock.Any()).Return(nil, errors.New("nats: no responders available for request"))
+			},
+			errMsg: domain.ErrOrderNotPublished.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			js := mock_publisher.NewMockjetStream(ct)
+			test.mock(js)
+
+			publisher := newPublisher(nil, js, config.NatsPublisher{Subject: "order.create", Source: "api", PublishTimeoutMs: 1000})
+			duplicate, err := publisher.PublishOrderCreate(context.Background(), order)
+
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedDuplicate, duplicate)
+		})
+	}
+}
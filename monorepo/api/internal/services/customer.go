@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"wb_test_task/api/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+//go:generate mockgen -source=customer.go -destination=mocks/customer_mock.go
+type customerStorage interface {
+	GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error)
+}
+
+type customerCache interface {
+	GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error)
+	GetSummaryVersion(ctx context.Context, customerID string) (string, error)
+	SetSummary(ctx context.Context, summary *domain.CustomerSummary, version string) error
+}
+
+type customerService struct {
+	store  customerStorage
+	cache  customerCache
+	orders orderStorage
+}
+
+func newCustomerService(store customerStorage, cache customerCache, orders orderStorage) *customerService {
+	return &customerService{
+		store:  store,
+		cache:  cache,
+		orders: orders,
+	}
+}
+
+// GetSummary вернуть сводку по заказам покупателя
+func (c *customerService) GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error) {
+	ctx, span := tracer.StartTrace(ctx, "service-get-customer-summary")
+	span.SetAttributes(attribute.String("customer-id", customerID))
+	defer span.End()
+
+	summary, err := c.cache.GetSummary(ctx, customerID)
+	if !errors.Is(err, domain.ErrCustomerNotExists) && err != nil {
+		logger.Warn("service: fail to get customer summary from cache", zap.Error(err))
+	}
+
+	if len(summary.CustomerID) == 0 {
+		// версия читается до базы: если consumer удалит сводку во время расчета, устаревшая сводка не попадет в кэш
+		version, versionErr := c.cache.GetSummaryVersion(ctx, customerID)
+		if versionErr != nil {
+			logger.Warn("service: fail to get customer summary version from cache", zap.Error(versionErr))
+		}
+
+		summary, err = c.store.GetSummary(ctx, customerID)
+		if err != nil {
+			return &domain.CustomerSummary{}, err
+		}
+
+		if versionErr == nil {
+			if err := c.cache.SetSummary(ctx, summary, version); err != nil {
+				logger.Warn("service: fail to set customer summary in redis cache", zap.Error(err))
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+// ListOrders вернуть страницу заказов покупателя, для неизвестного покупателя - ErrCustomerNotExists
+func (c *customerService) ListOrders(ctx context.Context, customerID string, filter *domain.OrderFilter) (*domain.OrderPage, error) {
+	ctx, span := tracer.StartTrace(ctx, "service-list-customer-orders")
+	span.SetAttributes(attribute.String("customer-id", customerID))
+	defer span.End()
+
+	if _, err := c.GetSummary(ctx, customerID); err != nil {
+		return &domain.OrderPage{Orders: []*model.Order{}}, err
+	}
+
+	filter.CustomerID = customerID
+	return c.orders.List(ctx, filter)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/domain"
+	mock_services "wb_test_task/api/internal/services/mocks"
+	"wb_test_task/libs/model"
+)
+
+func TestGetSummary(t *testing.T) {
+	lastOrderAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	summary := &domain.CustomerSummary{
+		CustomerID:   "test",
+		OrdersCount:  1,
+		FirstOrderAt: &lastOrderAt,
+		LastOrderAt:  &lastOrderAt,
+		TotalSpent:   map[string]json.Number{"USD": "1817"},
+	}
+	notExists := common.WrapError{Err: domain.ErrCustomerNotExists, Msg: domain.ErrCustomerNotExists.Error()}
+
+	testCases := []struct {
+		name           string
+		mock           func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage)
+		expectedResult *domain.CustomerSummary
+		errMsg         string
+	}{
+		{
+			name: "OK. Summary exists in cache",
+			mock: func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage) {
+				cache.EXPECT().GetSummary(gomock.Any(), "test").Return(summary, nil)
+			},
+			expectedResult: summary,
+		},
+		{
+			name: "OK. Summary does not exists in cache",
+			mock: func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage) {
+				cache.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, notExists)
+				gomock.InOrder(
+					cache.EXPECT().GetSummaryVersion(gomock.Any(), "test").Return("3", nil),
+					storage.EXPECT().GetSummary(gomock.Any(), "test").Return(summary, nil),
+					cache.EXPECT().SetSummary(gomock.Any(), summary, "3").Return(nil),
+				)
+			},
+			expectedResult: summary,
+		},
+		{
+			name: "OK. Error from cache",
+			mock: func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage) {
+				cache.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, errors.New("unexpected error"))
+				cache.EXPECT().GetSummaryVersion(gomock.Any(), "test").Return("3", nil)
+				storage.EXPECT().GetSummary(gomock.Any(), "test").Return(summary, nil)
+				cache.EXPECT().SetSummary(gomock.Any(), summary, "3").Return(errors.New("unexpected error"))
+			},
+			expectedResult: summary,
+		},
+		{
+			name: "OK. Error from summary version, summary is not cached",
+			mock: func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage) {
+				cache.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, notExists)
+				cache.EXPECT().GetSummaryVersion(gomock.Any(), "test").Return("", errors.New("unexpected error"))
+				storage.EXPECT().GetSummary(gomock.Any(), "test").Return(summary, nil)
+			},
+			expectedResult: summary,
+		},
+		{
+			name: "Customer does not exists",
+			mock: func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage) {
+				cache.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, notExists)
+				cache.EXPECT().GetSummaryVersion(gomock.Any(), "test").Return("3", nil)
+				storage.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, notExists)
+			},
+			expectedResult: &domain.CustomerSummary{},
+			errMsg:         domain.ErrCustomerNotExists.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			cache := mock_services.NewMockcustomerCache(ct)
+			storage := mock_services.NewMockcustomerStorage(ct)
+			test.mock(cache, storage)
+
+			service := newCustomerService(storage, cache, mock_services.NewMockorderStorage(ct))
+			result, err := service.GetSummary(context.Background(), "test")
+
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
+
+func TestListCustomerOrders(t *testing.T) {
+	summary := &domain.CustomerSummary{CustomerID: "test", TotalSpent: map[string]json.Number{}}
+	page := &domain.OrderPage{Orders: []*model.Order{{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", Items: []*model.Product{}}}}
+
+	testCases := []struct {
+		name           string
+		mock           func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage, orders *mock_services.MockorderStorage)
+		expectedResult *domain.OrderPage
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage, orders *mock_services.MockorderStorage) {
+				cache.EXPECT().GetSummary(gomock.Any(), "test").Return(summary, nil)
+				orders.EXPECT().List(gomock.Any(), &domain.OrderFilter{CustomerID: "test", Sort: domain.SortDateCreatedDesc, Limit: 20}).
+					Return(page, nil)
+			},
+			expectedResult: page,
+		},
+		{
+			name: "Customer does not exists",
+			mock: func(cache *mock_services.MockcustomerCache, storage *mock_services.MockcustomerStorage, orders *mock_services.MockorderStorage) {
+				notExists := common.WrapError{Err: domain.ErrCustomerNotExists, Msg: domain.ErrCustomerNotExists.Error()}
+				cache.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, notExists)
+				cache.EXPECT().GetSummaryVersion(gomock.Any(), "test").Return("3", nil)
+				storage.EXPECT().GetSummary(gomock.Any(), "test").Return(&domain.CustomerSummary{}, notExists)
+			},
+			expectedResult: &domain.OrderPage{Orders: []*model.Order{}},
+			errMsg:         domain.ErrCustomerNotExists.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			cache := mock_services.NewMockcustomerCache(ct)
+			storage := mock_services.NewMockcustomerStorage(ct)
+			orders := mock_services.NewMockorderStorage(ct)
+			test.mock(cache, storage, orders)
+
+			service := newCustomerService(storage, cache, orders)
+			result, err := service.ListOrders(context.Background(), "test", &domain.OrderFilter{Sort: domain.SortDateCreatedDesc, Limit: 20})
+
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: customer.go
+
+// Package mock_services is a generated GoMock package.
+package mock_services
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "wb_test_task/api/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockcustomerStorage is a mock of customerStorage interface.
+type MockcustomerStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockcustomerStorageMockRecorder
+}
+
+// MockcustomerStorageMockRecorder is the mock recorder for MockcustomerStorage.
+type MockcustomerStorageMockRecorder struct {
+	mock *MockcustomerStorage
+}
+
+// NewMockcustomerStorage creates a new mock instance.
+func NewMockcustomerStorage(ctrl *gomock.Controller) *MockcustomerStorage {
+	mock := &MockcustomerStorage{ctrl: ctrl}
+	mock.recorder = &MockcustomerStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcustomerStorage) EXPECT() *MockcustomerStorageMockRecorder {
+	return m.recorder
+}
+
+// GetSummary mocks base method.
+func (m *MockcustomerStorage) GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSummary", ctx, customerID)
+	ret0, _ := ret[0].(*domain.CustomerSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSummary indicates an expected call of GetSummary.
+func (mr *MockcustomerStorageMockRecorder) GetSummary(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSummary", reflect.TypeOf((*MockcustomerStorage)(nil).GetSummary), ctx, customerID)
+}
+
+// MockcustomerCache is a mock of customerCache interface.
+type MockcustomerCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockcustomerCacheMockRecorder
+}
+
+// MockcustomerCacheMockRecorder is the mock recorder for MockcustomerCache.
+type MockcustomerCacheMockRecorder struct {
+	mock *MockcustomerCache
+}
+
+// NewMockcustomerCache creates a new mock instance.
+func NewMockcustomerCache(ctrl *gomock.Controller) *MockcustomerCache {
+	mock := &MockcustomerCache{ctrl: ctrl}
+	mock.recorder = &MockcustomerCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcustomerCache) EXPECT() *MockcustomerCacheMockRecorder {
+	return m.recorder
+}
+
+// GetSummary mocks base method.
+func (m *MockcustomerCache) GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSummary", ctx, customerID)
+	ret0, _ := ret[0].(*domain.CustomerSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSummary indicates an expected call of GetSummary.
+func (mr *MockcustomerCacheMockRecorder) GetSummary(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSummary", reflect.TypeOf((*MockcustomerCache)(nil).GetSummary), ctx, customerID)
+}
+
+// GetSummaryVersion mocks base method.
+func (m *MockcustomerCache) GetSummaryVersion(ctx context.Context, customerID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSummaryVersion", ctx, customerID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSummaryVersion indicates an expected call of GetSummaryVersion.
+func (mr *MockcustomerCacheMockRecorder) GetSummaryVersion(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSummaryVersion", reflect.TypeOf((*MockcustomerCache)(nil).GetSummaryVersion), ctx, customerID)
+}
+
+// SetSummary mocks base method.
+func (m *MockcustomerCache) SetSummary(ctx context.Context, summary *domain.CustomerSummary, version string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSummary", ctx, summary, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSummary indicates an expected call of SetSummary.
+func (mr *MockcustomerCacheMockRecorder) SetSummary(ctx, summary, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSummary", reflect.TypeOf((*MockcustomerCache)(nil).SetSummary), ctx, summary, version)
+}
@@ -7,6 +7,7 @@ package mock_services
 import (
 	context "context"
 	reflect "reflect"
+	domain "wb_test_task/api/internal/domain"
 	model "wb_test_task/libs/model"
 
 	gomock "github.com/golang/mock/gomock"
@@ -50,6 +51,36 @@ func (mr *MockorderStorageMockRecorder) GetByID(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockorderStorage)(nil).GetByID), ctx, id)
 }
 
+// GetOrderUid mocks base method.
+func (m *MockorderStorage) GetOrderUid(ctx context.Context, key domain.LookupKey, value string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderUid", ctx, key, value)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderUid indicates an expected call of GetOrderUid.
+func (mr *MockorderStorageMockRecorder) GetOrderUid(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderUid", reflect.TypeOf((*MockorderStorage)(nil).GetOrderUid), ctx, key, value)
+}
+
+// List mocks base method.
+func (m *MockorderStorage) List(ctx context.Context, filter *domain.OrderFilter) (*domain.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].(*domain.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockorderStorageMockRecorder) List(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockorderStorage)(nil).List), ctx, filter)
+}
+
 // MockorderCache is a mock of orderCache interface.
 type MockorderCache struct {
 	ctrl     *gomock.Controller
@@ -73,31 +104,129 @@ func (m *MockorderCache) EXPECT() *MockorderCacheMockRecorder {
 	return m.recorder
 }
 
-// GetByID mocks base method.
-func (m *MockorderCache) GetByID(ctx context.Context, id string) (*model.Order, error) {
+// GetByIDWithETag mocks base method.
+func (m *MockorderCache) GetByIDWithETag(ctx context.Context, id string) (*model.Order, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret := m.ctrl.Call(m, "GetByIDWithETag", ctx, id)
 	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetByIDWithETag indicates an expected call of GetByIDWithETag.
+func (mr *MockorderCacheMockRecorder) GetByIDWithETag(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDWithETag", reflect.TypeOf((*MockorderCache)(nil).GetByIDWithETag), ctx, id)
+}
+
+// GetETag mocks base method.
+func (m *MockorderCache) GetETag(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetETag", ctx, id)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByID indicates an expected call of GetByID.
-func (mr *MockorderCacheMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+// GetETag indicates an expected call of GetETag.
+func (mr *MockorderCacheMockRecorder) GetETag(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockorderCache)(nil).GetByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetETag", reflect.TypeOf((*MockorderCache)(nil).GetETag), ctx, id)
+}
+
+// GetOrderUid mocks base method.
+func (m *MockorderCache) GetOrderUid(ctx context.Context, key domain.LookupKey, value string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderUid", ctx, key, value)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderUid indicates an expected call of GetOrderUid.
+func (mr *MockorderCacheMockRecorder) GetOrderUid(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderUid", reflect.TypeOf((*MockorderCache)(nil).GetOrderUid), ctx, key, value)
+}
+
+// GetVersion mocks base method.
+func (m *MockorderCache) GetVersion(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockorderCacheMockRecorder) GetVersion(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockorderCache)(nil).GetVersion), ctx, id)
 }
 
 // Set mocks base method.
-func (m *MockorderCache) Set(ctx context.Context, key string, order *model.Order) error {
+func (m *MockorderCache) Set(ctx context.Context, key string, order *model.Order, version string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", ctx, key, order)
+	ret := m.ctrl.Call(m, "Set", ctx, key, order, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Set indicates an expected call of Set.
-func (mr *MockorderCacheMockRecorder) Set(ctx, key, order interface{}) *gomock.Call {
+func (mr *MockorderCacheMockRecorder) Set(ctx, key, order, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockorderCache)(nil).Set), ctx, key, order, version)
+}
+
+// SetOrderUid mocks base method.
+func (m *MockorderCache) SetOrderUid(ctx context.Context, key domain.LookupKey, value, orderUid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOrderUid", ctx, key, value, orderUid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOrderUid indicates an expected call of SetOrderUid.
+func (mr *MockorderCacheMockRecorder) SetOrderUid(ctx, key, value, orderUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOrderUid", reflect.TypeOf((*MockorderCache)(nil).SetOrderUid), ctx, key, value, orderUid)
+}
+
+// MockorderPublisher is a mock of orderPublisher interface.
+type MockorderPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockorderPublisherMockRecorder
+}
+
+// MockorderPublisherMockRecorder is the mock recorder for MockorderPublisher.
+type MockorderPublisherMockRecorder struct {
+	mock *MockorderPublisher
+}
+
+// NewMockorderPublisher creates a new mock instance.
+func NewMockorderPublisher(ctrl *gomock.Controller) *MockorderPublisher {
+	mock := &MockorderPublisher{ctrl: ctrl}
+	mock.recorder = &MockorderPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockorderPublisher) EXPECT() *MockorderPublisherMockRecorder {
+	return m.recorder
+}
+
+// PublishOrderCreate mocks base method.
+func (m *MockorderPublisher) PublishOrderCreate(ctx context.Context, order *model.Order) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishOrderCreate", ctx, order)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishOrderCreate indicates an expected call of PublishOrderCreate.
+func (mr *MockorderPublisherMockRecorder) PublishOrderCreate(ctx, order interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockorderCache)(nil).Set), ctx, key, order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOrderCreate", reflect.TypeOf((*MockorderPublisher)(nil).PublishOrderCreate), ctx, order)
 }
@@ -2,12 +2,14 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/dany-ykl/logger"
 	"github.com/dany-ykl/tracer"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"time"
+	"wb_test_task/api/internal/common"
 	"wb_test_task/api/internal/domain"
 	"wb_test_task/libs/model"
 )
@@ -15,22 +17,34 @@ import (
 //go:generate mockgen -source=order.go -destination=mocks/mock.go
 type orderStorage interface {
 	GetByID(ctx context.Context, id string) (*model.Order, error)
+	List(ctx context.Context, filter *domain.OrderFilter) (*domain.OrderPage, error)
+	GetOrderUid(ctx context.Context, key domain.LookupKey, value string) (string, error)
 }
 
 type orderCache interface {
-	GetByID(ctx context.Context, id string) (*model.Order, error)
-	Set(ctx context.Context, key string, order *model.Order) error
+	GetByIDWithETag(ctx context.Context, id string) (*model.Order, string, error)
+	GetETag(ctx context.Context, id string) (string, error)
+	GetVersion(ctx context.Context, id string) (string, error)
+	Set(ctx context.Context, key string, order *model.Order, version string) error
+	GetOrderUid(ctx context.Context, key domain.LookupKey, value string) (string, error)
+	SetOrderUid(ctx context.Context, key domain.LookupKey, value, orderUid string) error
+}
+
+type orderPublisher interface {
+	PublishOrderCreate(ctx context.Context, order *model.Order) (bool, error)
 }
 
 type orderService struct {
-	store orderStorage
-	cache orderCache
+	store     orderStorage
+	cache     orderCache
+	publisher orderPublisher
 }
 
-func newOrderService(store orderStorage, cache orderCache) *orderService {
+func newOrderService(store orderStorage, cache orderCache, publisher orderPublisher) *orderService {
 	return &orderService{
-		store: store,
-		cache: cache,
+		store:     store,
+		cache:     cache,
+		publisher: publisher,
 	}
 }
 
@@ -40,13 +54,96 @@ func (o *orderService) GetByID(ctx context.Context, id string) (*model.Order, er
 	span.SetAttributes(attribute.String("order-id", id))
 	defer span.End()
 
-	order, err := o.cache.GetByID(ctx, id)
+	order, _, err := o.getByID(ctx, id)
+	return order, err
+}
+
+// GetByIDWithETag вернуть order по id и strong ETag его json представления
+func (o *orderService) GetByIDWithETag(ctx context.Context, id string) (*model.Order, string, error) {
+	ctx, span := tracer.StartTrace(ctx, "service-get-order-with-etag")
+	span.SetAttributes(attribute.String("order-id", id))
+	defer span.End()
+
+	return o.getByID(ctx, id)
+}
+
+// getByID прочитать order и ETag из кэша, при промахе из базы с записью в кэш. ETag хранится в кэше
+// рядом с заказом, при чтении из базы считается от тех же байтов, которые пишутся в кэш
+func (o *orderService) getByID(ctx context.Context, id string) (*model.Order, string, error) {
+	order, etag, err := o.cache.GetByIDWithETag(ctx, id)
 	if !errors.Is(err, domain.ErrOrderNotExists) && err != nil {
-		logger.Warn("service: fail to get order from cache", zap.Error(err))
+		logger.Warn("service: fail to get order with etag from cache", zap.Error(err))
 	}
 
 	if len(order.OrderUid) == 0 {
+		version, versionErr := o.getCacheVersion(ctx, id)
+
 		order, err = o.store.GetByID(ctx, id)
+		if err != nil {
+			return &model.Order{Items: []*model.Product{}}, "", err
+		}
+
+		data, err := json.Marshal(order)
+		if err != nil {
+			return &model.Order{Items: []*model.Product{}}, "", common.WrapError{Err: err, Msg: "fail to marshal order"}
+		}
+		etag = model.ETag(data)
+
+		if versionErr == nil {
+			o.setCache(ctx, order, version)
+		}
+	}
+
+	return order, etag, nil
+}
+
+// getCacheVersion прочитать версию заказа в кэше до чтения из базы: если consumer изменит заказ
+// во время чтения, устаревший заказ не попадет в кэш
+func (o *orderService) getCacheVersion(ctx context.Context, id string) (string, error) {
+	version, err := o.cache.GetVersion(ctx, id)
+	if err != nil {
+		logger.Warn("service: fail to get order version from cache", zap.Error(err))
+	}
+
+	return version, err
+}
+
+// setCache записать прочитанный из базы заказ в кэш, если его версия не изменилась
+func (o *orderService) setCache(ctx context.Context, order *model.Order, version string) {
+	if err := o.cache.Set(ctx, order.OrderUid, order, version); err != nil {
+		logger.Warn("service: fail to set order in redis cache", zap.Error(err))
+	}
+}
+
+// GetETag вернуть ETag заказа из кэша без чтения заказа. Если ETag в кэше нет, возвращается
+// ErrOrderNotExists, заказ нужно прочитать через GetByIDWithETag
+func (o *orderService) GetETag(ctx context.Context, id string) (string, error) {
+	ctx, span := tracer.StartTrace(ctx, "service-get-order-etag")
+	span.SetAttributes(attribute.String("order-id", id))
+	defer span.End()
+
+	etag, err := o.cache.GetETag(ctx, id)
+	if !errors.Is(err, domain.ErrOrderNotExists) && err != nil {
+		logger.Warn("service: fail to get order etag from cache", zap.Error(err))
+	}
+
+	return etag, err
+}
+
+// GetByKey вернуть order по альтернативному ключу: order_uid ищется во вторичном ключе кэша,
+// затем в базе, сам заказ читается через GetByID
+func (o *orderService) GetByKey(ctx context.Context, key domain.LookupKey, value string) (*model.Order, error) {
+	ctx, span := tracer.StartTrace(ctx, "service-get-order-by-"+string(key))
+	span.SetAttributes(attribute.String(string(key), value))
+	defer span.End()
+
+	orderUid, err := o.cache.GetOrderUid(ctx, key, value)
+	if !errors.Is(err, domain.ErrOrderNotExists) && err != nil {
+		logger.Warn("service: fail to get order uid from cache", zap.Error(err))
+	}
+
+	if len(orderUid) == 0 {
+		orderUid, err = o.store.GetOrderUid(ctx, key, value)
 		if err != nil {
 			return &model.Order{Items: []*model.Product{}}, err
 		}
@@ -55,11 +152,41 @@ func (o *orderService) GetByID(ctx context.Context, id string) (*model.Order, er
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
-			if err := o.cache.Set(ctx, order.OrderUid, order); err != nil {
-				logger.Warn("service: fail to set order in redis cache", zap.Error(err))
+			if err := o.cache.SetOrderUid(ctx, key, value, orderUid); err != nil {
+				logger.Warn("service: fail to set order uid in redis cache", zap.Error(err))
 			}
 		}()
 	}
 
-	return order, nil
+	return o.GetByID(ctx, orderUid)
+}
+
+// Create проверить бизнес-правила заказа и опубликовать его для записи consumer. Возвращает true,
+// если заказ с этим order_uid уже был опубликован. Статус и история статусов клиента отбрасываются
+func (o *orderService) Create(ctx context.Context, order *model.Order) (bool, error) {
+	ctx, span := tracer.StartTrace(ctx, "service-create-order")
+	span.SetAttributes(attribute.String("order-id", order.OrderUid))
+	defer span.End()
+
+	// статус нового заказа задает consumer, иначе повтор с другим статусом считался бы конфликтом
+	order.Status, order.Timeline = "", nil
+
+	if violations := model.ValidateOrder(order); len(violations) != 0 {
+		return false, common.WrapError{
+			Err:  domain.ErrOrderValidation,
+			Msg:  violations.Error(),
+			Body: violations,
+		}
+	}
+
+	return o.publisher.PublishOrderCreate(ctx, order)
+}
+
+// List вернуть страницу заказов по фильтрам, список читается из базы без кэша
+func (o *orderService) List(ctx context.Context, filter *domain.OrderFilter) (*domain.OrderPage, error) {
+	ctx, span := tracer.StartTrace(ctx, "service-list-orders")
+	span.SetAttributes(attribute.Int("limit", filter.Limit))
+	defer span.End()
+
+	return o.store.List(ctx, filter)
 }
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/dany-ykl/logger"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
@@ -27,8 +28,6 @@ func init() {
 }
 
 func TestGetByID(t *testing.T) {
-	runtime.GOMAXPROCS(1)
-
 	order := &model.Order{
 		OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
 		TrackNumber: "WBILMTESTTRACK3",
@@ -97,7 +96,7 @@ func TestGetByID(t *testing.T) {
 				order *model.Order
 			}{id: "5d110e48-9e6b-4928-b436-14194b30d54f"},
 			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, ctx context.Context, id string, order *model.Order) {
-				cache.EXPECT().GetByID(ctx, id).Return(order, nil)
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), id).Return(order, `"etag"`, nil)
 			},
 			expectedResult: order,
 			wantErr:        false,
@@ -110,8 +109,9 @@ func TestGetByID(t *testing.T) {
 				order *model.Order
 			}{id: "5d110e48-9e6b-4928-b436-14194b30d54f", order: order},
 			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, ctx context.Context, id string, order *model.Order) {
-				cache.EXPECT().GetByID(ctx, id).Return(&model.Order{Items: []*model.Product{}}, common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
-				storage.EXPECT().GetByID(ctx, id).Return(&model.Order{Items: []*model.Product{}}, common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), id).Return(&model.Order{Items: []*model.Product{}}, "", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
+				cache.EXPECT().GetVersion(gomock.Any(), id).Return("2", nil)
+				storage.EXPECT().GetByID(gomock.Any(), id).Return(&model.Order{Items: []*model.Product{}}, common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
 			},
 			expectedResult: &model.Order{Items: []*model.Product{}},
 			wantErr:        true,
@@ -124,9 +124,25 @@ func TestGetByID(t *testing.T) {
 				order *model.Order
 			}{id: "5d110e48-9e6b-4928-b436-14194b30d54f", order: order},
 			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, ctx context.Context, id string, order *model.Order) {
-				cache.EXPECT().GetByID(ctx, id).Return(&model.Order{Items: []*model.Product{}}, errors.New("unexpected error"))
-				storage.EXPECT().GetByID(ctx, id).Return(order, nil)
-				cache.EXPECT().Set(ctx, id, order).Return(nil).AnyTimes()
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), id).Return(&model.Order{Items: []*model.Product{}}, "", errors.New("unexpected error"))
+				cache.EXPECT().GetVersion(gomock.Any(), id).Return("2", nil)
+				storage.EXPECT().GetByID(gomock.Any(), id).Return(order, nil)
+				cache.EXPECT().Set(gomock.Any(), id, order, "2").Return(nil)
+			},
+			expectedResult: order,
+			wantErr:        false,
+			errMsg:         "",
+		},
+		{
+			name: "OK. Error from cache version, order is not cached",
+			mockInput: struct {
+				id    string
+				order *model.Order
+			}{id: "5d110e48-9e6b-4928-b436-14194b30d54f", order: order},
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, ctx context.Context, id string, order *model.Order) {
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), id).Return(&model.Order{Items: []*model.Product{}}, "", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
+				cache.EXPECT().GetVersion(gomock.Any(), id).Return("", errors.New("unexpected error"))
+				storage.EXPECT().GetByID(gomock.Any(), id).Return(order, nil)
 			},
 			expectedResult: order,
 			wantErr:        false,
@@ -139,8 +155,10 @@ func TestGetByID(t *testing.T) {
 				order *model.Order
 			}{id: "5d110e48-9e6b-4928-b436-14194b30d54f", order: order},
 			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, ctx context.Context, id string, order *model.Order) {
-				cache.EXPECT().GetByID(ctx, id).Return(&model.Order{Items: []*model.Product{}}, common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
-				storage.EXPECT().GetByID(ctx, id).Return(order, nil)
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), id).Return(&model.Order{Items: []*model.Product{}}, "", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()})
+				cache.EXPECT().GetVersion(gomock.Any(), id).Return("2", nil)
+				storage.EXPECT().GetByID(gomock.Any(), id).Return(order, nil)
+				cache.EXPECT().Set(gomock.Any(), id, order, "2").Return(nil)
 			},
 			expectedResult: order,
 			wantErr:        false,
@@ -158,7 +176,7 @@ func TestGetByID(t *testing.T) {
 
 			test.mock(cache, storage, context.Background(), test.mockInput.id, order)
 
-			service := newOrderService(storage, cache)
+			service := newOrderService(storage, cache, mock_services.NewMockorderPublisher(ct))
 			result, err := service.GetByID(context.Background(), test.mockInput.id)
 
 			if test.wantErr {
@@ -172,3 +190,362 @@ func TestGetByID(t *testing.T) {
 		})
 	}
 }
+
+func TestList(t *testing.T) {
+	filter := &domain.OrderFilter{CustomerID: "test", Sort: domain.SortDateCreatedDesc, Limit: 20}
+	page := &domain.OrderPage{
+		Orders:     []*model.Order{{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", Items: []*model.Product{}}},
+		NextCursor: "cursor",
+	}
+
+	testCases := []struct {
+		name           string
+		mock           func(storage *mock_services.MockorderStorage)
+		expectedResult *domain.OrderPage
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func(storage *mock_services.MockorderStorage) {
+				storage.EXPECT().List(gomock.Any(), filter).Return(page, nil)
+			},
+			expectedResult: page,
+		},
+		{
+			name: "Error from storage",
+			mock: func(storage *mock_services.MockorderStorage) {
+				storage.EXPECT().List(gomock.Any(), filter).Return(&domain.OrderPage{Orders: []*model.Order{}}, errors.New("unexpected error"))
+			},
+			expectedResult: &domain.OrderPage{Orders: []*model.Order{}},
+			errMsg:         "unexpected error",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			storage := mock_services.NewMockorderStorage(ct)
+			test.mock(storage)
+
+			service := newOrderService(storage, mock_services.NewMockorderCache(ct), mock_services.NewMockorderPublisher(ct))
+			result, err := service.List(context.Background(), filter)
+
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
+
+func TestGetByKey(t *testing.T) {
+	runtime.GOMAXPROCS(1)
+
+	const orderUid = "5d110e48-9e6b-4928-b436-14194b30d54f"
+	order := &model.Order{OrderUid: orderUid, TrackNumber: "WBILMTESTTRACK3", Items: []*model.Product{}}
+	notExists := common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()}
+
+	testCases := []struct {
+		name           string
+		key            domain.LookupKey
+		value          string
+		mock           func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, key domain.LookupKey, value string)
+		expectedResult *model.Order
+		errMsg         string
+	}{
+		{
+			name:  "OK. Order uid and order exist in cache",
+			key:   domain.LookupTrackNumber,
+			value: "WBILMTESTTRACK3",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, key domain.LookupKey, value string) {
+				cache.EXPECT().GetOrderUid(gomock.Any(), key, value).Return(orderUid, nil)
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), orderUid).Return(order, `"etag"`, nil)
+			},
+			expectedResult: order,
+		},
+		{
+			name:  "OK. Order uid does not exists in cache",
+			key:   domain.LookupRid,
+			value: "ab4219087a764ae0btest",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, key domain.LookupKey, value string) {
+				cache.EXPECT().GetOrderUid(gomock.Any(), key, value).Return("", notExists)
+				storage.EXPECT().GetOrderUid(gomock.Any(), key, value).Return(orderUid, nil)
+				cache.EXPECT().SetOrderUid(gomock.Any(), key, value, orderUid).Return(nil).AnyTimes()
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), orderUid).Return(order, `"etag"`, nil)
+			},
+			expectedResult: order,
+		},
+		{
+			name:  "OK. Error from cache",
+			key:   domain.LookupRequestID,
+			value: "9934930a-9e6b-4928-b436-14194b30d54f",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, key domain.LookupKey, value string) {
+				cache.EXPECT().GetOrderUid(gomock.Any(), key, value).Return("", errors.New("unexpected error"))
+				storage.EXPECT().GetOrderUid(gomock.Any(), key, value).Return(orderUid, nil)
+				cache.EXPECT().SetOrderUid(gomock.Any(), key, value, orderUid).Return(nil).AnyTimes()
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), orderUid).Return(&model.Order{Items: []*model.Product{}}, "", notExists)
+				cache.EXPECT().GetVersion(gomock.Any(), orderUid).Return("2", nil)
+				storage.EXPECT().GetByID(gomock.Any(), orderUid).Return(order, nil)
+				cache.EXPECT().Set(gomock.Any(), orderUid, order, "2").Return(nil)
+			},
+			expectedResult: order,
+		},
+		{
+			name:  "Order does not exists",
+			key:   domain.LookupTrackNumber,
+			value: "WBILMTESTTRACK3",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, key domain.LookupKey, value string) {
+				cache.EXPECT().GetOrderUid(gomock.Any(), key, value).Return("", notExists)
+				storage.EXPECT().GetOrderUid(gomock.Any(), key, value).Return("", notExists)
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			errMsg:         domain.ErrOrderNotExists.Error(),
+		},
+		{
+			name:  "Several orders match the key",
+			key:   domain.LookupRid,
+			value: "ab4219087a764ae0btest",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage, key domain.LookupKey, value string) {
+				cache.EXPECT().GetOrderUid(gomock.Any(), key, value).Return("", notExists)
+				storage.EXPECT().GetOrderUid(gomock.Any(), key, value).Return("", common.WrapError{Err: domain.ErrAmbiguousLookup, Msg: "several orders"})
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			errMsg:         domain.ErrAmbiguousLookup.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			cache := mock_services.NewMockorderCache(ct)
+			storage := mock_services.NewMockorderStorage(ct)
+			test.mock(cache, storage, test.key, test.value)
+
+			service := newOrderService(storage, cache, mock_services.NewMockorderPublisher(ct))
+			result, err := service.GetByKey(context.Background(), test.key, test.value)
+
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
+
+func TestGetByIDWithETag(t *testing.T) {
+	const (
+		orderUid = "5d110e48-9e6b-4928-b436-14194b30d54f"
+		etag     = `"0123456789abcdef"`
+	)
+	order := &model.Order{OrderUid: orderUid, TrackNumber: "WBILMTESTTRACK3", Items: []*model.Product{}}
+	data, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notExists := common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()}
+
+	testCases := []struct {
+		name           string
+		mock           func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage)
+		expectedResult *model.Order
+		expectedETag   string
+		errMsg         string
+	}{
+		{
+			name: "OK. Order and etag exist in cache",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage) {
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), orderUid).Return(order, etag, nil)
+			},
+			expectedResult: order,
+			expectedETag:   etag,
+		},
+		{
+			name: "OK. Order does not exists in cache",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage) {
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), orderUid).Return(&model.Order{Items: []*model.Product{}}, "", notExists)
+				cache.EXPECT().GetVersion(gomock.Any(), orderUid).Return("2", nil)
+				storage.EXPECT().GetByID(gomock.Any(), orderUid).Return(order, nil)
+				cache.EXPECT().Set(gomock.Any(), orderUid, order, "2").Return(nil)
+			},
+			expectedResult: order,
+			expectedETag:   model.ETag(data),
+		},
+		{
+			name: "OK. Error from cache",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage) {
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), orderUid).Return(&model.Order{Items: []*model.Product{}}, "", errors.New("unexpected error"))
+				cache.EXPECT().GetVersion(gomock.Any(), orderUid).Return("2", nil)
+				storage.EXPECT().GetByID(gomock.Any(), orderUid).Return(order, nil)
+				cache.EXPECT().Set(gomock.Any(), orderUid, order, "2").Return(nil)
+			},
+			expectedResult: order,
+			expectedETag:   model.ETag(data),
+		},
+		{
+			name: "Order does not exists",
+			mock: func(cache *mock_services.MockorderCache, storage *mock_services.MockorderStorage) {
+				cache.EXPECT().GetByIDWithETag(gomock.Any(), orderUid).Return(&model.Order{Items: []*model.Product{}}, "", notExists)
+				cache.EXPECT().GetVersion(gomock.Any(), orderUid).Return("2", nil)
+				storage.EXPECT().GetByID(gomock.Any(), orderUid).Return(&model.Order{Items: []*model.Product{}}, notExists)
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			errMsg:         domain.ErrOrderNotExists.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			cache := mock_services.NewMockorderCache(ct)
+			storage := mock_services.NewMockorderStorage(ct)
+			test.mock(cache, storage)
+
+			service := newOrderService(storage, cache, mock_services.NewMockorderPublisher(ct))
+			result, etag, err := service.GetByIDWithETag(context.Background(), orderUid)
+
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.Equal(t, test.expectedETag, etag)
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	newOrder := func() *model.Order {
+		return &model.Order{
+			OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+			TrackNumber: "WBILMTESTTRACK3",
+			Entry:       "WBIL",
+			Delivery: model.Delivery{
+				Name:    "Test Testov",
+				Phone:   "+9720000000",
+				Zip:     "2639809",
+				City:    "Kiryat Mozkin",
+				Address: "Ploshad Mira 15",
+				Region:  "Kraiot",
+				Email:   "test@gmail.com",
+			},
+			Payment: model.Payment{
+				Transaction:  "5d110e48-9e6b-4928-b436-14194b30d54f",
+				RequestID:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+				Currency:     "USD",
+				Provider:     "wbpay",
+				Amount:       1817,
+				PaymentDt:    1637907727,
+				Bank:         "alpha",
+				DeliveryCost: 1500,
+				GoodsTotal:   317,
+			},
+			Items: []*model.Product{
+				{
+					ChrtID:      9934930,
+					TrackNumber: "WBILMTESTTRACK3",
+					Price:       453,
+					Rid:         "ab4219087a764ae0btest",
+					Name:        "Mascaras",
+					Sale:        30,
+					Size:        "0",
+					TotalPrice:  317,
+					NmID:        2389212,
+					Brand:       "Vivienne Sabo",
+					Status:      202,
+				},
+			},
+			Locale:          "en",
+			CustomerID:      "test",
+			DeliveryService: "meest",
+			SmID:            99,
+			DateCreated:     "2021-11-26T06:22:19Z",
+			OofShard:        "1",
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		modify            func(order *model.Order)
+		mock              func(publisher *mock_services.MockorderPublisher)
+		expectedDuplicate bool
+		errMsg            string
+	}{
+		{
+			name:   "OK",
+			modify: func(order *model.Order) {},
+			mock: func(publisher *mock_services.MockorderPublisher) {
+				publisher.EXPECT().PublishOrderCreate(gomock.Any(), newOrder()).Return(false, nil)
+			},
+		},
+		{
+			name:   "OK. Duplicate order",
+			modify: func(order *model.Order) {},
+			mock: func(publisher *mock_services.MockorderPublisher) {
+				publisher.EXPECT().PublishOrderCreate(gomock.Any(), newOrder()).Return(true, nil)
+			},
+			expectedDuplicate: true,
+		},
+		{
+			name: "OK. Status and timeline from client are dropped",
+			modify: func(order *model.Order) {
+				order.Status = "delivered"
+				order.Timeline = []*model.StatusChange{{Status: "delivered"}}
+			},
+			mock: func(publisher *mock_services.MockorderPublisher) {
+				publisher.EXPECT().PublishOrderCreate(gomock.Any(), newOrder()).Return(false, nil)
+			},
+		},
+		{
+			name: "Validation error",
+			modify: func(order *model.Order) {
+				order.OrderUid = "invalid"
+				order.Payment.Currency = "EUR"
+			},
+			mock:   func(publisher *mock_services.MockorderPublisher) {},
+			errMsg: domain.ErrOrderValidation.Error(),
+		},
+		{
+			name:   "Error from publisher",
+			modify: func(order *model.Order) {},
+			mock: func(publisher *mock_services.MockorderPublisher) {
+				publisher.EXPECT().PublishOrderCreate(gomock.Any(), newOrder()).
+					Return(false, common.WrapError{Err: domain.ErrOrderNotPublished, Msg: "nats: no responders available for request"})
+			},
+			errMsg: domain.ErrOrderNotPublished.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			publisher := mock_services.NewMockorderPublisher(ct)
+			test.mock(publisher)
+
+			order := newOrder()
+			test.modify(order)
+
+			service := newOrderService(mock_services.NewMockorderStorage(ct), mock_services.NewMockorderCache(ct), publisher)
+			duplicate, err := service.Create(context.Background(), order)
+
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedDuplicate, duplicate)
+		})
+	}
+}
@@ -1,16 +1,21 @@
 package services
 
 type Service struct {
-	OrderService *orderService
+	OrderService    *orderService
+	CustomerService *customerService
 }
 
 type Depends struct {
-	OrderStorage orderStorage
-	OrderCache   orderCache
+	OrderStorage    orderStorage
+	OrderCache      orderCache
+	CustomerStorage customerStorage
+	CustomerCache   customerCache
+	OrderPublisher  orderPublisher
 }
 
 func New(depends Depends) *Service {
 	return &Service{
-		OrderService: newOrderService(depends.OrderStorage, depends.OrderCache),
+		OrderService:    newOrderService(depends.OrderStorage, depends.OrderCache, depends.OrderPublisher),
+		CustomerService: newCustomerService(depends.CustomerStorage, depends.CustomerCache, depends.OrderStorage),
 	}
 }
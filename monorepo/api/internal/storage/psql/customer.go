@@ -0,0 +1,67 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/dany-ykl/tracer"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/domain"
+)
+
+type customerStorage struct {
+	pool pool
+}
+
+func newCustomerStorage(pool pool) *customerStorage {
+	return &customerStorage{pool: pool}
+}
+
+// GetSummary вернуть количество заказов, даты первого и последнего заказа и сумму оплат
+// по валютам покупателя. Сводка читается одним запросом, чтобы количество и суммы были
+// из одного снимка базы
+func (c *customerStorage) GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-get-customer-summary")
+	span.SetAttributes(attribute.String("customer-id", customerID))
+	defer span.End()
+
+	query := `
+		SELECT u.id, count(o.order_uid), min(o.date_created), max(o.date_created),
+		       COALESCE((
+		           SELECT jsonb_object_agg(s.currency, s.amount)
+		           FROM (
+		               SELECT t.currency, sum(t.amount) AS amount
+		               FROM orders so
+		               JOIN transaction t
+		                   ON so.order_uid=t.id
+		               WHERE so.customer_id=u.id
+		               GROUP BY t.currency
+		           ) s
+		       ), '{}')
+		FROM users u
+		LEFT JOIN orders o
+			ON o.customer_id=u.id
+		WHERE u.id=$1
+		GROUP BY u.id
+	`
+
+	var (
+		summary      = domain.CustomerSummary{TotalSpent: map[string]json.Number{}}
+		firstOrderAt *time.Time
+		lastOrderAt  *time.Time
+	)
+	err := c.pool.QueryRow(ctx, query, customerID).
+		Scan(&summary.CustomerID, &summary.OrdersCount, &firstOrderAt, &lastOrderAt, &summary.TotalSpent)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &domain.CustomerSummary{}, common.WrapError{Err: domain.ErrCustomerNotExists, Msg: domain.ErrCustomerNotExists.Error()}
+		}
+		return &domain.CustomerSummary{}, common.WrapError{Err: err, Msg: "fail to get customer summary"}
+	}
+	summary.FirstOrderAt, summary.LastOrderAt = firstOrderAt, lastOrderAt
+
+	return &summary, nil
+}
@@ -0,0 +1,93 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/api/internal/domain"
+)
+
+func TestGetSummary(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newCustomerStorage(mock)
+
+	firstOrderAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	lastOrderAt := firstOrderAt.Add(24 * time.Hour)
+
+	selectSummaryQuery := `SELECT u.id, count\(o.order_uid\), min\(o.date_created\), max\(o.date_created\), ` +
+		`COALESCE\(\( SELECT jsonb_object_agg\(s.currency, s.amount\) FROM \( SELECT t.currency, sum\(t.amount\) AS amount ` +
+		`FROM orders so JOIN transaction t ON so.order_uid=t.id WHERE so.customer_id=u.id GROUP BY t.currency \) s \), '{}'\) ` +
+		`FROM users u LEFT JOIN orders o ON o.customer_id=u.id WHERE u.id=\$1 GROUP BY u.id`
+	summaryColumns := []string{"id", "count", "min", "max", "total_spent"}
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		expectedResult *domain.CustomerSummary
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(selectSummaryQuery).WithArgs("test").
+					WillReturnRows(pgxmock.NewRows(summaryColumns).AddRow("test", 3, &firstOrderAt, &lastOrderAt,
+						map[string]json.Number{"USD": "3634.10", "RUB": "1500"}))
+			},
+			expectedResult: &domain.CustomerSummary{
+				CustomerID:   "test",
+				OrdersCount:  3,
+				FirstOrderAt: &firstOrderAt,
+				LastOrderAt:  &lastOrderAt,
+				TotalSpent:   map[string]json.Number{"USD": "3634.10", "RUB": "1500"},
+			},
+		},
+		{
+			name: "OK. Customer without orders",
+			mock: func() {
+				mock.ExpectQuery(selectSummaryQuery).WithArgs("test").
+					WillReturnRows(pgxmock.NewRows(summaryColumns).AddRow("test", 0, nil, nil, map[string]json.Number{}))
+			},
+			expectedResult: &domain.CustomerSummary{CustomerID: "test", TotalSpent: map[string]json.Number{}},
+		},
+		{
+			name: "Customer does not exists",
+			mock: func() {
+				mock.ExpectQuery(selectSummaryQuery).WithArgs("test").WillReturnRows(pgxmock.NewRows(summaryColumns))
+			},
+			expectedResult: &domain.CustomerSummary{},
+			errMsg:         domain.ErrCustomerNotExists.Error(),
+		},
+		{
+			name: "Error from database",
+			mock: func() {
+				mock.ExpectQuery(selectSummaryQuery).WithArgs("test").WillReturnError(errors.New("unexpected error"))
+			},
+			expectedResult: &domain.CustomerSummary{},
+			errMsg:         "unexpected error",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			result, err := storage.GetSummary(context.Background(), "test")
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
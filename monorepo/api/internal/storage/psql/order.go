@@ -30,7 +30,7 @@ func (o *orderStorage) GetByID(ctx context.Context, id string) (*model.Order, er
 	query := `
 		SELECT 	o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
        			o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.oof_shard,
-       			o.date_created, d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+       			o.date_created, o.status, d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
        			t.id, t.request_id, t.currency, t.provider, t.amount, t.payment_dt,
        			t.bank, t.delivery_cost, t.goods_total, t.custom_fee
 		FROM orders o
@@ -48,7 +48,7 @@ func (o *orderStorage) GetByID(ctx context.Context, id string) (*model.Order, er
 
 	err := o.pool.QueryRow(ctx, query, id).Scan(
 		&order.OrderUid, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature, &order.CustomerID, &order.DeliveryService,
-		&order.ShardKey, &order.SmID, &order.OofShard, &createDate, &order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
+		&order.ShardKey, &order.SmID, &order.OofShard, &createDate, &order.Status, &order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
 		&order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email, &order.Payment.Transaction,
 		&order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDt,
 		&order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
@@ -68,7 +68,7 @@ func (o *orderStorage) GetByID(ctx context.Context, id string) (*model.Order, er
 		return &model.Order{Items: []*model.Product{}}, common.WrapError{Err: err, Msg: "fail to get order by id"}
 	}
 
-	order.DateCreated = createDate.String()
+	order.DateCreated = model.FormatDateCreated(createDate)
 
 	products, err := o.getProductByOrderTrackNumber(ctx, order.TrackNumber)
 	if err != nil {
@@ -76,9 +76,51 @@ func (o *orderStorage) GetByID(ctx context.Context, id string) (*model.Order, er
 	}
 	order.Items = products
 
+	timeline, err := o.getStatusHistory(ctx, order.OrderUid)
+	if err != nil {
+		return &model.Order{Items: []*model.Product{}}, err
+	}
+	order.Timeline = timeline
+
 	return &order, nil
 }
 
+// getStatusHistory вернуть историю статусов заказа
+func (o *orderStorage) getStatusHistory(ctx context.Context, orderUid string) ([]*model.StatusChange, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-get-order-status-history")
+	span.SetAttributes(attribute.String("order-id", orderUid))
+	defer span.End()
+
+	query := `
+		SELECT status, COALESCE(reason, ''), changed_at
+		FROM order_status_history
+		WHERE order_uid=$1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := o.pool.Query(ctx, query, orderUid)
+	if err != nil {
+		return []*model.StatusChange{}, common.WrapError{Err: err, Msg: "fail to get order status history"}
+	}
+	defer rows.Close()
+
+	var timeline []*model.StatusChange
+	for rows.Next() {
+		var change model.StatusChange
+		if err := rows.Scan(&change.Status, &change.Reason, &change.ChangedAt); err != nil {
+			return []*model.StatusChange{}, common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+
+		timeline = append(timeline, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []*model.StatusChange{}, common.WrapError{Err: err, Msg: "fail to get order status history"}
+	}
+
+	return timeline, nil
+}
+
 // getProductByOrderTrackNumber вернуть items по order track number
 func (o *orderStorage) getProductByOrderTrackNumber(ctx context.Context, trackNumber string) ([]*model.Product, error) {
 	ctx, span := tracer.StartTrace(ctx, "psql-storage-get-product-by-order-tracknumber")
@@ -0,0 +1,208 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/tracer"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"strings"
+	"time"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+// List вернуть страницу заказов по фильтрам. Страницы строятся по ключу (date_created, order_uid),
+// курсор следующей страницы указывает на последний заказ страницы. Заказы без date_created
+// не попадают в список: у них нет позиции в ключе страниц, они доступны по id
+func (o *orderStorage) List(ctx context.Context, filter *domain.OrderFilter) (*domain.OrderPage, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-list-orders")
+	span.SetAttributes(attribute.Int("limit", filter.Limit), attribute.String("sort", filter.Sort))
+	defer span.End()
+
+	query, args := listOrdersQuery(filter)
+
+	rows, err := o.pool.Query(ctx, query, args...)
+	if err != nil {
+		return &domain.OrderPage{Orders: []*model.Order{}}, listError(err)
+	}
+	defer rows.Close()
+
+	var (
+		orders       = make([]*model.Order, 0, filter.Limit+1)
+		datesByUid   = make(map[string]time.Time, filter.Limit+1)
+		trackNumbers = make([]string, 0, filter.Limit+1)
+	)
+	for rows.Next() {
+		var (
+			order      model.Order
+			createDate time.Time
+		)
+		err := rows.Scan(
+			&order.OrderUid, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature, &order.CustomerID, &order.DeliveryService,
+			&order.ShardKey, &order.SmID, &order.OofShard, &createDate, &order.Status, &order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
+			&order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email, &order.Payment.Transaction,
+			&order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDt,
+			&order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
+		)
+		if err != nil {
+			return &domain.OrderPage{Orders: []*model.Order{}}, common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+
+		order.DateCreated = model.FormatDateCreated(createDate)
+		order.Items = []*model.Product{}
+		orders = append(orders, &order)
+		datesByUid[order.OrderUid] = createDate
+	}
+	if err := rows.Err(); err != nil {
+		return &domain.OrderPage{Orders: []*model.Order{}}, listError(err)
+	}
+
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > filter.Limit {
+		page.Orders = orders[:filter.Limit]
+		last := page.Orders[len(page.Orders)-1]
+		page.NextCursor = domain.OrderCursor{
+			DateCreated: datesByUid[last.OrderUid],
+			OrderUid:    last.OrderUid,
+			Sort:        filter.Sort,
+		}.Encode()
+	}
+
+	if len(page.Orders) == 0 {
+		return page, nil
+	}
+
+	for _, order := range page.Orders {
+		trackNumbers = append(trackNumbers, order.TrackNumber)
+	}
+	products, err := o.getProductsByTrackNumbers(ctx, trackNumbers)
+	if err != nil {
+		return &domain.OrderPage{Orders: []*model.Order{}}, err
+	}
+	for _, order := range page.Orders {
+		if items, ok := products[order.TrackNumber]; ok {
+			order.Items = items
+		}
+	}
+
+	return page, nil
+}
+
+// listOrdersQuery собрать запрос страницы заказов, запрашивается на один заказ больше limit,
+// чтобы узнать, есть ли следующая страница
+func listOrdersQuery(filter *domain.OrderFilter) (string, []any) {
+	var (
+		args       []any
+		conditions = []string{"o.date_created IS NOT NULL"}
+	)
+	where := func(condition string, values ...any) {
+		placeholders := make([]any, 0, len(values))
+		for _, value := range values {
+			args = append(args, value)
+			placeholders = append(placeholders, len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if len(filter.CustomerID) != 0 {
+		where("o.customer_id=$%d", filter.CustomerID)
+	}
+	if filter.DateFrom != nil {
+		where("o.date_created>=$%d", filter.DateFrom.UTC())
+	}
+	if filter.DateTo != nil {
+		where("o.date_created<=$%d", filter.DateTo.UTC())
+	}
+	if len(filter.DeliveryService) != 0 {
+		where("o.delivery_service=$%d", filter.DeliveryService)
+	}
+	if len(filter.Entry) != 0 {
+		where("o.entry=$%d", filter.Entry)
+	}
+	if len(filter.Locale) != 0 {
+		where("o.locale=$%d", filter.Locale)
+	}
+	if len(filter.Currency) != 0 {
+		where("t.currency=$%d", filter.Currency)
+	}
+
+	direction, compare := "ASC", ">"
+	if filter.Desc() {
+		direction, compare = "DESC", "<"
+	}
+	if filter.Cursor != nil {
+		where("(o.date_created, o.order_uid)"+compare+"($%d, $%d)", filter.Cursor.DateCreated.UTC(), filter.Cursor.OrderUid)
+	}
+
+	args = append(args, filter.Limit+1)
+
+	query := fmt.Sprintf(`
+		SELECT 	o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
+       			o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.oof_shard,
+       			o.date_created, o.status, d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+       			t.id, t.request_id, t.currency, t.provider, t.amount, t.payment_dt,
+       			t.bank, t.delivery_cost, t.goods_total, t.custom_fee
+		FROM orders o
+		JOIN delivery d
+    		ON o.order_uid=d.order_uid
+		JOIN transaction t
+    		ON o.order_uid=t.id
+		WHERE %s
+		ORDER BY o.date_created %s, o.order_uid %s
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), direction, direction, len(args))
+
+	return query, args
+}
+
+// listError ошибка запроса списка заказов, неверные значения фильтров возвращаются как ErrInvalidSyntax
+func listError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == domain.CodeInvalidSyntax {
+		return common.WrapError{Err: domain.ErrInvalidSyntax, Msg: pgErr.Message}
+	}
+	return common.WrapError{Err: err, Msg: "fail to list orders"}
+}
+
+// getProductsByTrackNumbers вернуть items заказов по их track number
+func (o *orderStorage) getProductsByTrackNumbers(ctx context.Context, trackNumbers []string) (map[string][]*model.Product, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-get-products-by-order-tracknumbers")
+	span.SetAttributes(attribute.Int("orders", len(trackNumbers)))
+	defer span.End()
+
+	query := `
+		SELECT chrt_id, track_number, price, rid, name, sale,
+		    size, total_price, nm_id, brand, status
+		FROM product
+		WHERE track_number = ANY($1)
+	`
+
+	rows, err := o.pool.Query(ctx, query, trackNumbers)
+	if err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get items by track numbers"}
+	}
+	defer rows.Close()
+
+	products := make(map[string][]*model.Product, len(trackNumbers))
+	for rows.Next() {
+		var product model.Product
+		err := rows.Scan(&product.ChrtID, &product.TrackNumber, &product.Price,
+			&product.Rid, &product.Name, &product.Sale, &product.Size, &product.TotalPrice,
+			&product.NmID, &product.Brand, &product.Status,
+		)
+		if err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+
+		products[product.TrackNumber] = append(products[product.TrackNumber], &product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get items by track numbers"}
+	}
+
+	return products, nil
+}
@@ -0,0 +1,142 @@
+package psql
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/api/internal/domain"
+)
+
+func TestList(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newOrderStorage(mock)
+
+	first := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	second := first.Add(-time.Hour)
+	cursor := &domain.OrderCursor{
+		DateCreated: first.Add(time.Hour),
+		OrderUid:    "0f7b6b43-5f0c-4bc4-9d5a-8d5e3a4c2b10",
+		Sort:        domain.SortDateCreatedAsc,
+	}
+
+	orderRows := []string{
+		"o.order_uid", "o.track_number", "o.entry", "o.locale", "o.internal_signature",
+		"o.customer_id", "o.delivery_service", "o.shardkey", "o.sm_id", "o.oof_shard",
+		"o.date_created", "o.status", "d.name", "d.phone", "d.zip", "d.city", "d.address", "d.region", "d.email",
+		"t.id", "t.request_id", "t.currency", "t.provider", "t.amount", "t.payment_dt",
+		"t.bank", "t.delivery_cost", "t.goods_total", "t.custom_fee",
+	}
+	itemRows := []string{"chrt_id", "track_number", "price", "rid", "name", "sale",
+		"size", "total_price", "nm_id", "brand", "status"}
+
+	addOrder := func(rows *pgxmock.Rows, orderUid, trackNumber string, dateCreated time.Time) {
+		rows.AddRow(
+			orderUid, trackNumber, "WBIL", "en", "", "test", "meest",
+			"9", 99, "1", dateCreated, "paid", "Test Testov", "+9720000000", "2639809", "Kiryat Mozkin",
+			"Ploshad Mira 15", "Kraiot", "test@gmail.com", orderUid, orderUid,
+			"USD", "wbpay", float64(1817), int64(1637907727), "alpha", float64(1500), 317, 0,
+		)
+	}
+
+	selectOrdersQuery := `SELECT o.order_uid, .+ FROM orders o JOIN delivery d ON o.order_uid=d.order_uid JOIN transaction t ON o.order_uid=t.id`
+	selectItemsQuery := `SELECT chrt_id, .+ FROM product WHERE track_number = ANY\(\$1\)`
+
+	testCases := []struct {
+		name           string
+		filter         *domain.OrderFilter
+		mock           func()
+		expectedOrders []string
+		expectedItems  int
+		expectedCursor string
+		errMsg         string
+	}{
+		{
+			name:   "First page with next cursor",
+			filter: &domain.OrderFilter{CustomerID: "test", Currency: "USD", Sort: domain.SortDateCreatedDesc, Limit: 1},
+			mock: func() {
+				rows := mock.NewRows(orderRows)
+				addOrder(rows, "5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK1", first)
+				addOrder(rows, "9c1e0f0e-6f7a-4a7e-9a63-3e2a6c5b1d11", "WBILMTESTTRACK2", second)
+				mock.ExpectQuery(selectOrdersQuery+` WHERE o.date_created IS NOT NULL AND o.customer_id=\$1 AND t.currency=\$2 `+
+					`ORDER BY o.date_created DESC, o.order_uid DESC LIMIT \$3`).
+					WithArgs("test", "USD", 2).WillReturnRows(rows)
+
+				items := mock.NewRows(itemRows)
+				items.AddRow(int64(9934930), "WBILMTESTTRACK1", float64(453), "ab4219087a764ae0btest", "Mascaras", 30,
+					"0", float64(317), int64(2389212), "Vivienne Sabo", 202)
+				mock.ExpectQuery(selectItemsQuery).WithArgs([]string{"WBILMTESTTRACK1"}).WillReturnRows(items)
+			},
+			expectedOrders: []string{"5d110e48-9e6b-4928-b436-14194b30d54f"},
+			expectedItems:  1,
+			expectedCursor: domain.OrderCursor{
+				DateCreated: first,
+				OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+				Sort:        domain.SortDateCreatedDesc,
+			}.Encode(),
+		},
+		{
+			name:   "Last page after cursor in ascending order",
+			filter: &domain.OrderFilter{Sort: domain.SortDateCreatedAsc, Limit: 2, Cursor: cursor},
+			mock: func() {
+				rows := mock.NewRows(orderRows)
+				addOrder(rows, "5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK1", first)
+				mock.ExpectQuery(selectOrdersQuery+` WHERE o.date_created IS NOT NULL AND \(o.date_created, o.order_uid\)>\(\$1, \$2\) `+
+					`ORDER BY o.date_created ASC, o.order_uid ASC LIMIT \$3`).
+					WithArgs(cursor.DateCreated, cursor.OrderUid, 3).WillReturnRows(rows)
+				mock.ExpectQuery(selectItemsQuery).WithArgs([]string{"WBILMTESTTRACK1"}).WillReturnRows(mock.NewRows(itemRows))
+			},
+			expectedOrders: []string{"5d110e48-9e6b-4928-b436-14194b30d54f"},
+		},
+		{
+			name:   "Empty page",
+			filter: &domain.OrderFilter{DeliveryService: "dhl", Limit: 20},
+			mock: func() {
+				mock.ExpectQuery(selectOrdersQuery).WithArgs("dhl", 21).WillReturnRows(mock.NewRows(orderRows))
+			},
+			expectedOrders: []string{},
+		},
+		{
+			name:   "Invalid filter value",
+			filter: &domain.OrderFilter{Locale: "xx", Limit: 20},
+			mock: func() {
+				mock.ExpectQuery(selectOrdersQuery).WithArgs("xx", 21).
+					WillReturnError(&pgconn.PgError{Code: domain.CodeInvalidSyntax, Message: `invalid input value for enum locale_type: "xx"`})
+			},
+			expectedOrders: []string{},
+			errMsg:         domain.ErrInvalidSyntax.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			page, err := storage.List(context.Background(), test.filter)
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			orderUids := make([]string, 0, len(page.Orders))
+			items := 0
+			for _, order := range page.Orders {
+				orderUids = append(orderUids, order.OrderUid)
+				assert.NotNil(t, order.Items)
+				items += len(order.Items)
+			}
+			assert.Equal(t, test.expectedOrders, orderUids)
+			assert.Equal(t, test.expectedItems, items)
+			assert.Equal(t, test.expectedCursor, page.NextCursor)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
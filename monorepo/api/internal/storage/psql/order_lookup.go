@@ -0,0 +1,78 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/tracer"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/domain"
+)
+
+// lookupQueries запросы order_uid по альтернативному ключу, запрашивается до двух заказов,
+// чтобы отличить неоднозначный ключ
+var lookupQueries = map[domain.LookupKey]string{
+	domain.LookupTrackNumber: `
+		SELECT order_uid::text FROM orders WHERE track_number=$1 LIMIT 2
+	`,
+	domain.LookupRequestID: `
+		SELECT id::text FROM transaction WHERE request_id=$1 LIMIT 2
+	`,
+	domain.LookupRid: `
+		SELECT DISTINCT o.order_uid::text
+		FROM product p
+		JOIN orders o
+			ON o.track_number=p.track_number
+		WHERE p.rid=$1
+		LIMIT 2
+	`,
+}
+
+// GetOrderUid вернуть order_uid заказа по альтернативному ключу
+func (o *orderStorage) GetOrderUid(ctx context.Context, key domain.LookupKey, value string) (string, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-get-order-uid-by-"+string(key))
+	span.SetAttributes(attribute.String(string(key), value))
+	defer span.End()
+
+	query, ok := lookupQueries[key]
+	if !ok {
+		return "", common.WrapError{Err: fmt.Errorf("unknown lookup key %q", key), Msg: "fail to get order uid"}
+	}
+
+	rows, err := o.pool.Query(ctx, query, value)
+	if err != nil {
+		return "", lookupError(err)
+	}
+	defer rows.Close()
+
+	var orderUids []string
+	for rows.Next() {
+		var orderUid string
+		if err := rows.Scan(&orderUid); err != nil {
+			return "", common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+		orderUids = append(orderUids, orderUid)
+	}
+	if err := rows.Err(); err != nil {
+		return "", lookupError(err)
+	}
+
+	switch len(orderUids) {
+	case 0:
+		return "", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()}
+	case 1:
+		return orderUids[0], nil
+	default:
+		return "", common.WrapError{Err: domain.ErrAmbiguousLookup, Msg: fmt.Sprintf("several orders match %s %q", key, value)}
+	}
+}
+
+func lookupError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == domain.CodeInvalidSyntax {
+		return common.WrapError{Err: domain.ErrInvalidSyntax, Msg: pgErr.Message}
+	}
+	return common.WrapError{Err: err, Msg: "fail to get order uid"}
+}
@@ -0,0 +1,113 @@
+package psql
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/api/internal/domain"
+)
+
+func TestGetOrderUid(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newOrderStorage(mock)
+
+	const orderUid = "5d110e48-9e6b-4928-b436-14194b30d54f"
+
+	testCases := []struct {
+		name           string
+		key            domain.LookupKey
+		value          string
+		mock           func(value string)
+		expectedResult string
+		errMsg         string
+	}{
+		{
+			name:  "OK. By track number",
+			key:   domain.LookupTrackNumber,
+			value: "WBILMTESTTRACK3",
+			mock: func(value string) {
+				mock.ExpectQuery(`SELECT order_uid::text FROM orders WHERE track_number=\$1`).
+					WithArgs(value).
+					WillReturnRows(pgxmock.NewRows([]string{"order_uid"}).AddRow(orderUid))
+			},
+			expectedResult: orderUid,
+		},
+		{
+			name:  "OK. By request id",
+			key:   domain.LookupRequestID,
+			value: "9934930a-9e6b-4928-b436-14194b30d54f",
+			mock: func(value string) {
+				mock.ExpectQuery(`SELECT id::text FROM transaction WHERE request_id=\$1`).
+					WithArgs(value).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(orderUid))
+			},
+			expectedResult: orderUid,
+		},
+		{
+			name:  "OK. By rid",
+			key:   domain.LookupRid,
+			value: "ab4219087a764ae0btest",
+			mock: func(value string) {
+				mock.ExpectQuery(`SELECT DISTINCT o.order_uid::text FROM product p JOIN orders o ON o.track_number=p.track_number WHERE p.rid=\$1`).
+					WithArgs(value).
+					WillReturnRows(pgxmock.NewRows([]string{"order_uid"}).AddRow(orderUid))
+			},
+			expectedResult: orderUid,
+		},
+		{
+			name:  "Order does not exists",
+			key:   domain.LookupTrackNumber,
+			value: "WBILMTESTTRACK3",
+			mock: func(value string) {
+				mock.ExpectQuery(`SELECT order_uid::text FROM orders WHERE track_number=\$1`).
+					WithArgs(value).
+					WillReturnRows(pgxmock.NewRows([]string{"order_uid"}))
+			},
+			errMsg: domain.ErrOrderNotExists.Error(),
+		},
+		{
+			name:  "Several orders match the rid",
+			key:   domain.LookupRid,
+			value: "ab4219087a764ae0btest",
+			mock: func(value string) {
+				mock.ExpectQuery(`SELECT DISTINCT o.order_uid::text FROM product p`).
+					WithArgs(value).
+					WillReturnRows(pgxmock.NewRows([]string{"order_uid"}).AddRow(orderUid).AddRow("0f7b6b43-5f0c-4bc4-9d5a-8d5e3a4c2b10"))
+			},
+			errMsg: domain.ErrAmbiguousLookup.Error(),
+		},
+		{
+			name:  "Invalid request id",
+			key:   domain.LookupRequestID,
+			value: "invalid",
+			mock: func(value string) {
+				mock.ExpectQuery(`SELECT id::text FROM transaction WHERE request_id=\$1`).
+					WithArgs(value).
+					WillReturnError(&pgconn.PgError{Code: domain.CodeInvalidSyntax, Message: "invalid input syntax for type uuid"})
+			},
+			errMsg: domain.ErrInvalidSyntax.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.value)
+
+			result, err := storage.GetOrderUid(context.Background(), test.key, test.value)
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
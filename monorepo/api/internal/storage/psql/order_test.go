@@ -19,6 +19,8 @@ func TestGetByID(t *testing.T) {
 
 	storage := newOrderStorage(mock)
 
+	paidAt := time.Date(2021, 11, 26, 7, 0, 0, 0, time.UTC)
+
 	products := []*model.Product{
 		{
 			ChrtID:      9934930,
@@ -66,14 +68,19 @@ func TestGetByID(t *testing.T) {
 		DeliveryService:   "meest",
 		ShardKey:          "",
 		SmID:              99,
-		DateCreated:       "2021-11-26 06:22:19 +0000 UTC",
+		DateCreated:       "2021-11-26T06:22:19Z",
 		OofShard:          "1",
+		Status:            "paid",
+		Timeline: []*model.StatusChange{
+			{Status: "created", ChangedAt: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)},
+			{Status: "paid", ChangedAt: paidAt},
+		},
 	}
 
 	selectOrderByIDQuery := `
 	SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
        			o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.oof_shard,
-       			o.date_created, d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+       			o.date_created, o.status, d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
        			t.id, t.request_id, t.currency, t.provider, t.amount, t.payment_dt,
        			t.bank, t.delivery_cost, t.goods_total, t.custom_fee
 				FROM orders o
@@ -83,6 +90,7 @@ func TestGetByID(t *testing.T) {
     				ON o.order_uid=t.id
 				WHERE o.order_uid
 	`
+	selectStatusHistoryQuery := `SELECT status, COALESCE\(reason, ''\), changed_at FROM order_status_history`
 	selectItemsByTrackNumberQuery := `
 		
 					SELECT chrt_id, track_number, price, rid, name, sale,
@@ -95,10 +103,11 @@ func TestGetByID(t *testing.T) {
 	orderRows := []string{
 		"o.order_uid", "o.track_number", "o.entry", "o.locale", "o.internal_signature",
 		"o.customer_id", "o.delivery_service", "o.shardkey", "o.sm_id", "o.oof_shard",
-		"o.date_created", "d.name", "d.phone", "d.zip", "d.city", "d.address", "d.region", "d.email",
+		"o.date_created", "o.status", "d.name", "d.phone", "d.zip", "d.city", "d.address", "d.region", "d.email",
 		"t.id", "t.request_id", "t.currency", "t.provider", "t.amount", "t.payment_dt",
 		"t.bank", "t.delivery_cost", "t.goods_total", "t.custom_fee",
 	}
+	historyRows := []string{"status", "reason", "changed_at"}
 	itemRows := []string{"chrt_id", "track_number", "price", "rid", "name", "sale",
 		"size", "total_price", "nm_id", "brand", "status"}
 
@@ -124,7 +133,7 @@ func TestGetByID(t *testing.T) {
 				}
 				rows.AddRow(
 					"5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK3", "WBIL", "en", "", "test", "meest",
-					"", 99, "1", dateCreated, "Test Testov", "+9720000000", "2639809", "Kiryat Mozkin",
+					"", 99, "1", dateCreated, "paid", "Test Testov", "+9720000000", "2639809", "Kiryat Mozkin",
 					"Ploshad Mira 15", "Kraiot", "test@gmail.com", "5d110e48-9e6b-4928-b436-14194b30d54f", "5d110e48-9e6b-4928-b436-14194b30d54f",
 					"USD", "wbpay", float64(1817), int64(1637907727), "alpha", float64(1500), 317, 0,
 				)
@@ -135,6 +144,12 @@ func TestGetByID(t *testing.T) {
 				itemRows.AddRow(int64(9934930), "WBILMTESTTRACK3", float64(453), "ab4219087a764ae0btest", "Mascaras", 30,
 					"0", float64(317), int64(2389212), "Vivienne Sabo", 202)
 				mock.ExpectQuery(selectItemsByTrackNumberQuery).WithArgs(trackNumber).WillReturnRows(itemRows)
+
+				// select status history
+				historyRows := mock.NewRows(historyRows)
+				historyRows.AddRow("created", "", dateCreated)
+				historyRows.AddRow("paid", "", paidAt)
+				mock.ExpectQuery(selectStatusHistoryQuery).WithArgs(id).WillReturnRows(historyRows)
 			},
 			mockInput: struct {
 				id          string
@@ -142,6 +157,44 @@ func TestGetByID(t *testing.T) {
 			}{id: "5d110e48-9e6b-4928-b436-14194b30d54f", trackNumber: "WBILMTESTTRACK3"},
 			expectedResult: order,
 		},
+		{
+			name: "Status history read interrupted",
+			mock: func(ctx context.Context, id, trackNumber string) {
+				// select order, payment, delivery
+				rows := mock.NewRows(orderRows)
+				dateCreated, err := time.Parse("2006-01-02 15:04:05 -0700 MST", "2021-11-26 06:22:19 +0000 UTC")
+				if err != nil {
+					t.Error(err)
+				}
+				rows.AddRow(
+					"5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK3", "WBIL", "en", "", "test", "meest",
+					"", 99, "1", dateCreated, "paid", "Test Testov", "+9720000000", "2639809", "Kiryat Mozkin",
+					"Ploshad Mira 15", "Kraiot", "test@gmail.com", "5d110e48-9e6b-4928-b436-14194b30d54f", "5d110e48-9e6b-4928-b436-14194b30d54f",
+					"USD", "wbpay", float64(1817), int64(1637907727), "alpha", float64(1500), 317, 0,
+				)
+				mock.ExpectQuery(selectOrderByIDQuery).WithArgs(id).WillReturnRows(rows)
+
+				// select items
+				itemRows := mock.NewRows(itemRows)
+				itemRows.AddRow(int64(9934930), "WBILMTESTTRACK3", float64(453), "ab4219087a764ae0btest", "Mascaras", 30,
+					"0", float64(317), int64(2389212), "Vivienne Sabo", 202)
+				mock.ExpectQuery(selectItemsByTrackNumberQuery).WithArgs(trackNumber).WillReturnRows(itemRows)
+
+				// select status history, соединение обрывается после первой строки
+				historyRows := mock.NewRows(historyRows)
+				historyRows.AddRow("created", "", dateCreated)
+				historyRows.AddRow("paid", "", paidAt)
+				historyRows.RowError(1, errors.New("connection reset"))
+				mock.ExpectQuery(selectStatusHistoryQuery).WithArgs(id).WillReturnRows(historyRows)
+			},
+			mockInput: struct {
+				id          string
+				trackNumber string
+			}{id: "5d110e48-9e6b-4928-b436-14194b30d54f", trackNumber: "WBILMTESTTRACK3"},
+			wantErr:        true,
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			errMsg:         "connection reset",
+		},
 		{
 			name: "Order does not exists",
 			mock: func(ctx context.Context, id, trackNumber string) {
@@ -14,8 +14,9 @@ type pool interface {
 }
 
 type Storage struct {
-	conn         *pgxpool.Pool
-	OrderStorage *orderStorage
+	conn            *pgxpool.Pool
+	OrderStorage    *orderStorage
+	CustomerStorage *customerStorage
 }
 
 func New(ctx context.Context, cfg config.PostgresDatabase) (*Storage, error) {
@@ -36,8 +37,9 @@ func New(ctx context.Context, cfg config.PostgresDatabase) (*Storage, error) {
 	}
 
 	return &Storage{
-		conn:         conn,
-		OrderStorage: newOrderStorage(conn),
+		conn:            conn,
+		OrderStorage:    newOrderStorage(conn),
+		CustomerStorage: newCustomerStorage(conn),
 	}, nil
 }
 
@@ -7,9 +7,10 @@ import (
 )
 
 type Cache struct {
-	conn       *redis.Client
-	cfg        *config.RedisCache
-	OrderCache *orderCache
+	conn          *redis.Client
+	cfg           *config.RedisCache
+	OrderCache    *orderCache
+	CustomerCache *customerCache
 }
 
 func New(cfg config.RedisCache) (*Cache, error) {
@@ -23,9 +24,10 @@ func New(cfg config.RedisCache) (*Cache, error) {
 	}
 
 	return &Cache{
-		conn:       conn,
-		cfg:        &cfg,
-		OrderCache: newOrderCache(conn, cfg.TtlSecond),
+		conn:          conn,
+		cfg:           &cfg,
+		OrderCache:    newOrderCache(conn, cfg.TtlSecond),
+		CustomerCache: newCustomerCache(conn, cfg.CustomerSummaryTtlSecond),
 	}, nil
 }
 
@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dany-ykl/tracer"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/domain"
+)
+
+type customerCache struct {
+	conn      *redis.Client
+	ttlSecond int
+}
+
+const (
+	// customerSummaryPrefix префикс сводок покупателей, consumer удаляет сводку при записи заказа покупателя
+	customerSummaryPrefix = "customer_summary"
+	// customerSummaryVersionPrefix префикс версий сводок, consumer увеличивает версию вместе с удалением сводки
+	customerSummaryVersionPrefix = "customer_summary_version"
+	// customerSummaryNoVersion версия сводки, которую consumer еще не удалял
+	customerSummaryNoVersion = "0"
+)
+
+// setSummaryScript записать сводку, только если ее версия не изменилась с момента чтения
+var setSummaryScript = `if (redis.call('GET', KEYS[1]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[2], 'EX', ARGV[3])
+return 1`
+
+func newCustomerCache(conn *redis.Client, ttlSecond int) *customerCache {
+	return &customerCache{conn: conn, ttlSecond: ttlSecond}
+}
+
+// GetSummary вернуть сводку покупателя из redis cache
+func (c *customerCache) GetSummary(ctx context.Context, customerID string) (*domain.CustomerSummary, error) {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-customer-summary")
+	span.SetAttributes(attribute.String("customer-id", customerID))
+	defer span.End()
+
+	data, err := c.conn.Get(ctx, fmt.Sprintf("%s:%s", customerSummaryPrefix, customerID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return &domain.CustomerSummary{}, common.WrapError{Err: domain.ErrCustomerNotExists, Msg: domain.ErrCustomerNotExists.Error()}
+		}
+		return &domain.CustomerSummary{}, common.WrapError{Err: err, Msg: "fail to get customer summary from cache"}
+	}
+
+	var summary domain.CustomerSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return &domain.CustomerSummary{}, common.WrapError{Err: err, Msg: "fail to unmarshal customer summary"}
+	}
+
+	return &summary, nil
+}
+
+// GetSummaryVersion вернуть версию сводки покупателя, версию нужно прочитать до расчета сводки в базе
+func (c *customerCache) GetSummaryVersion(ctx context.Context, customerID string) (string, error) {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-customer-summary-version")
+	span.SetAttributes(attribute.String("customer-id", customerID))
+	defer span.End()
+
+	version, err := c.conn.Get(ctx, fmt.Sprintf("%s:%s", customerSummaryVersionPrefix, customerID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return customerSummaryNoVersion, nil
+		}
+		return "", common.WrapError{Err: err, Msg: "fail to get customer summary version from cache"}
+	}
+
+	return version, nil
+}
+
+// SetSummary вставить сводку покупателя в redis cache, если consumer не удалил ее после чтения версии.
+// Иначе сводка рассчитана до нового заказа и не записывается
+func (c *customerCache) SetSummary(ctx context.Context, summary *domain.CustomerSummary, version string) error {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-set-customer-summary")
+	span.SetAttributes(attribute.String("customer-id", summary.CustomerID))
+	defer span.End()
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return common.WrapError{Err: err, Msg: "fail to marshal customer summary"}
+	}
+
+	keys := []string{
+		fmt.Sprintf("%s:%s", customerSummaryVersionPrefix, summary.CustomerID),
+		fmt.Sprintf("%s:%s", customerSummaryPrefix, summary.CustomerID),
+	}
+	if err := c.conn.Eval(ctx, setSummaryScript, keys, version, data, c.ttlSecond).Err(); err != nil {
+		return common.WrapError{Err: err, Msg: "fail to set customer summary in cache"}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redismock/v9"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/api/internal/domain"
+)
+
+func TestGetSummary(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newCustomerCache(client, 100)
+
+	lastOrderAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		expectedResult *domain.CustomerSummary
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectGet("customer_summary:test").SetVal(`{"customer_id":"test","orders_count":1,` +
+					`"first_order_at":"2021-11-26T06:22:19Z","last_order_at":"2021-11-26T06:22:19Z","total_spent":{"USD":1817}}`)
+			},
+			expectedResult: &domain.CustomerSummary{
+				CustomerID:   "test",
+				OrdersCount:  1,
+				FirstOrderAt: &lastOrderAt,
+				LastOrderAt:  &lastOrderAt,
+				TotalSpent:   map[string]json.Number{"USD": "1817"},
+			},
+		},
+		{
+			name: "Summary does not exists",
+			mock: func() {
+				mock.ExpectGet("customer_summary:test").RedisNil()
+			},
+			expectedResult: &domain.CustomerSummary{},
+			errMsg:         domain.ErrCustomerNotExists.Error(),
+		},
+		{
+			name: "Broken summary",
+			mock: func() {
+				mock.ExpectGet("customer_summary:test").SetVal(`{"customer_id":`)
+			},
+			expectedResult: &domain.CustomerSummary{},
+			errMsg:         "unexpected end of JSON input",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			result, err := cache.GetSummary(context.Background(), "test")
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetSummaryVersion(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newCustomerCache(client, 100)
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		expectedResult string
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectGet("customer_summary_version:test").SetVal("3")
+			},
+			expectedResult: "3",
+		},
+		{
+			name: "OK. Summary was never invalidated",
+			mock: func() {
+				mock.ExpectGet("customer_summary_version:test").RedisNil()
+			},
+			expectedResult: customerSummaryNoVersion,
+		},
+		{
+			name: "Error from redis",
+			mock: func() {
+				mock.ExpectGet("customer_summary_version:test").SetErr(errors.New("redis: connection refused"))
+			},
+			errMsg: "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			result, err := cache.GetSummaryVersion(context.Background(), "test")
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetSummary(t *testing.T) {
+	ttl := 100
+	client, mock := redismock.NewClientMock()
+	cache := newCustomerCache(client, ttl)
+
+	keys := []string{"customer_summary_version:test", "customer_summary:test"}
+	data := []byte(`{"customer_id":"test","orders_count":0,"first_order_at":null,"last_order_at":null,"total_spent":{}}`)
+
+	testCases := []struct {
+		name   string
+		mock   func()
+		errMsg string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectEval(setSummaryScript, keys, "3", data, ttl).SetVal(int64(1))
+			},
+		},
+		{
+			name: "OK. Summary was invalidated after the version was read",
+			mock: func() {
+				mock.ExpectEval(setSummaryScript, keys, "3", data, ttl).SetVal(int64(0))
+			},
+		},
+		{
+			name: "Error from redis",
+			mock: func() {
+				mock.ExpectEval(setSummaryScript, keys, "3", data, ttl).SetErr(errors.New("redis: connection refused"))
+			},
+			errMsg: "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			err := cache.SetSummary(context.Background(), &domain.CustomerSummary{CustomerID: "test", TotalSpent: map[string]json.Number{}}, "3")
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
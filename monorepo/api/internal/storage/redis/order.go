@@ -7,7 +7,6 @@ import (
 	"github.com/dany-ykl/tracer"
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/attribute"
-	"time"
 	"wb_test_task/api/internal/common"
 	"wb_test_task/api/internal/domain"
 	"wb_test_task/libs/model"
@@ -18,43 +17,94 @@ type orderCache struct {
 	ttlSecond int
 }
 
-const orderObjectPrefix = "orders"
+const (
+	orderObjectPrefix = "orders"
+	// orderETagPrefix ETag заказа, пишется вместе с заказом и с тем же ttl
+	orderETagPrefix = "orders_etag"
+	// orderVersionPrefix версия заказа, consumer увеличивает ее вместе с удалением заказа из кэша
+	orderVersionPrefix = "orders_version"
+)
+
+// setOrderScript записать заказ и его ETag, только если версия заказа не изменилась с момента чтения
+var setOrderScript = `if (redis.call('GET', KEYS[1]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[2], 'EX', ARGV[4])
+redis.call('SET', KEYS[3], ARGV[3], 'EX', ARGV[4])
+return 1`
 
 func newOrderCache(conn *redis.Client, ttlSecond int) *orderCache {
 	return &orderCache{conn: conn, ttlSecond: ttlSecond}
 }
 
-// GetByID вернуть order по id
-func (o *orderCache) GetByID(ctx context.Context, id string) (*model.Order, error) {
-	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-order-by-id")
+// GetByIDWithETag вернуть order по id вместе с ETag одним запросом. Для заказов, записанных
+// в кэш без ETag, ETag считается от байтов заказа
+func (o *orderCache) GetByIDWithETag(ctx context.Context, id string) (*model.Order, string, error) {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-order-with-etag")
 	span.SetAttributes(attribute.String("order-id", id))
 	defer span.End()
 
-	cmd := o.conn.Get(ctx, fmt.Sprintf("%s:%s", orderObjectPrefix, id))
+	values, err := o.conn.MGet(ctx, fmt.Sprintf("%s:%s", orderObjectPrefix, id), fmt.Sprintf("%s:%s", orderETagPrefix, id)).Result()
+	if err != nil {
+		return &model.Order{Items: []*model.Product{}}, "", common.WrapError{Err: err, Msg: "fail to get order with etag from cache"}
+	}
 
-	if err := cmd.Err(); err != nil {
-		if err == redis.Nil {
-			return &model.Order{Items: []*model.Product{}}, common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()}
-		}
+	data, ok := values[0].(string)
+	if !ok {
+		return &model.Order{Items: []*model.Product{}}, "", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()}
+	}
 
-		return &model.Order{Items: []*model.Product{}}, common.WrapError{Err: err, Msg: "fail to get order by id from cache"}
+	var order model.Order
+	if err := json.Unmarshal([]byte(data), &order); err != nil {
+		return &model.Order{Items: []*model.Product{}}, "", common.WrapError{Err: err, Msg: "fail to unmarshal order"}
+	}
+
+	etag, ok := values[1].(string)
+	if !ok || len(etag) == 0 {
+		etag = model.ETag([]byte(data))
 	}
 
-	data, err := cmd.Bytes()
+	return &order, etag, nil
+}
+
+// GetETag вернуть ETag заказа без чтения самого заказа
+func (o *orderCache) GetETag(ctx context.Context, id string) (string, error) {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-order-etag")
+	span.SetAttributes(attribute.String("order-id", id))
+	defer span.End()
+
+	etag, err := o.conn.Get(ctx, fmt.Sprintf("%s:%s", orderETagPrefix, id)).Result()
 	if err != nil {
-		return &model.Order{Items: []*model.Product{}}, common.WrapError{Err: err, Msg: "fail to get bytes"}
+		if err == redis.Nil {
+			return "", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()}
+		}
+
+		return "", common.WrapError{Err: err, Msg: "fail to get order etag from cache"}
 	}
 
-	var order model.Order
-	if err := json.Unmarshal(data, &order); err != nil {
-		return &model.Order{Items: []*model.Product{}}, common.WrapError{Err: err, Msg: "fail to unmarshal order"}
+	return etag, nil
+}
+
+// GetVersion вернуть версию заказа в кэше, версию нужно прочитать до чтения заказа из базы
+func (o *orderCache) GetVersion(ctx context.Context, id string) (string, error) {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-order-version")
+	span.SetAttributes(attribute.String("order-id", id))
+	defer span.End()
+
+	version, err := o.conn.Get(ctx, fmt.Sprintf("%s:%s", orderVersionPrefix, id)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return model.CacheVersionNone, nil
+		}
+		return "", common.WrapError{Err: err, Msg: "fail to get order version from cache"}
 	}
 
-	return &order, nil
+	return version, nil
 }
 
-// Set вставить order в redis cache
-func (o *orderCache) Set(ctx context.Context, key string, order *model.Order) error {
+// Set вставить order в redis cache вместе с ETag, если версия заказа в кэше совпадает с version.
+// Иначе consumer изменил заказ после чтения из базы и устаревший заказ не записывается
+func (o *orderCache) Set(ctx context.Context, key string, order *model.Order, version string) error {
 	ctx, span := tracer.StartTrace(ctx, "redis-cache-set-order")
 	span.SetAttributes(attribute.String("key", key))
 	defer span.End()
@@ -64,8 +114,12 @@ func (o *orderCache) Set(ctx context.Context, key string, order *model.Order) er
 		return common.WrapError{Err: err, Msg: "fail to unmarshal order"}
 	}
 
-	cmd := o.conn.Set(ctx, fmt.Sprintf("%s:%s", orderObjectPrefix, key), data, time.Duration(o.ttlSecond)*time.Second)
-	if err := cmd.Err(); err != nil {
+	keys := []string{
+		fmt.Sprintf("%s:%s", orderVersionPrefix, key),
+		fmt.Sprintf("%s:%s", orderObjectPrefix, key),
+		fmt.Sprintf("%s:%s", orderETagPrefix, key),
+	}
+	if err := o.conn.Eval(ctx, setOrderScript, keys, version, data, model.ETag(data), o.ttlSecond).Err(); err != nil {
 		return common.WrapError{Err: err, Msg: "fail to set order in cache"}
 	}
 
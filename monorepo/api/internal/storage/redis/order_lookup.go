@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/tracer"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+	"wb_test_task/api/internal/common"
+	"wb_test_task/api/internal/domain"
+)
+
+// orderLookupPrefix префикс вторичных ключей, значение ключа - order_uid заказа
+const orderLookupPrefix = "order_lookup"
+
+func orderLookupKey(key domain.LookupKey, value string) string {
+	return fmt.Sprintf("%s:%s:%s", orderLookupPrefix, key, value)
+}
+
+// GetOrderUid вернуть order_uid заказа по альтернативному ключу
+func (o *orderCache) GetOrderUid(ctx context.Context, key domain.LookupKey, value string) (string, error) {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-order-uid-by-"+string(key))
+	span.SetAttributes(attribute.String(string(key), value))
+	defer span.End()
+
+	orderUid, err := o.conn.Get(ctx, orderLookupKey(key, value)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", common.WrapError{Err: domain.ErrOrderNotExists, Msg: domain.ErrOrderNotExists.Error()}
+		}
+		return "", common.WrapError{Err: err, Msg: "fail to get order uid from cache"}
+	}
+
+	return orderUid, nil
+}
+
+// SetOrderUid записать order_uid заказа по альтернативному ключу
+func (o *orderCache) SetOrderUid(ctx context.Context, key domain.LookupKey, value, orderUid string) error {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-set-order-uid")
+	span.SetAttributes(attribute.String(string(key), value))
+	defer span.End()
+
+	if err := o.conn.Set(ctx, orderLookupKey(key, value), orderUid, time.Duration(o.ttlSecond)*time.Second).Err(); err != nil {
+		return common.WrapError{Err: err, Msg: "fail to set order uid in cache"}
+	}
+
+	return nil
+}
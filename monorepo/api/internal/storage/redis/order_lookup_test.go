@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"github.com/go-redis/redismock/v9"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/api/internal/domain"
+)
+
+func TestGetOrderUid(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, 100)
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		expectedResult string
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectGet("order_lookup:track_number:WBILMTESTTRACK3").SetVal("5d110e48-9e6b-4928-b436-14194b30d54f")
+			},
+			expectedResult: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		},
+		{
+			name: "Key does not exists",
+			mock: func() {
+				mock.ExpectGet("order_lookup:track_number:WBILMTESTTRACK3").RedisNil()
+			},
+			errMsg: domain.ErrOrderNotExists.Error(),
+		},
+		{
+			name: "Unexpected error",
+			mock: func() {
+				mock.ExpectGet("order_lookup:track_number:WBILMTESTTRACK3").SetErr(errors.New("unexpected error"))
+			},
+			errMsg: "unexpected error",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			result, err := cache.GetOrderUid(context.Background(), domain.LookupTrackNumber, "WBILMTESTTRACK3")
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetOrderUid(t *testing.T) {
+	ttl := 100
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, ttl)
+
+	mock.ExpectSet("order_lookup:rid:ab4219087a764ae0btest", "5d110e48-9e6b-4928-b436-14194b30d54f", time.Duration(ttl)*time.Second).SetVal("OK")
+
+	err := cache.SetOrderUid(context.Background(), domain.LookupRid, "ab4219087a764ae0btest", "5d110e48-9e6b-4928-b436-14194b30d54f")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,121 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/go-redis/redismock/v9"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
-	"time"
 	"wb_test_task/api/internal/domain"
 	"wb_test_task/libs/model"
 )
 
-func TestGetByID(t *testing.T) {
-	client, mock := redismock.NewClientMock()
-	cache := newOrderCache(client, 100)
-
-	testCases := []struct {
-		name      string
-		mockInput struct {
-			id string
-		}
-		mock           func(id string)
-		expectedResult *model.Order
-		wantErr        bool
-		errMsg         string
-	}{
-		{
-			name:      "OK",
-			mockInput: struct{ id string }{id: "5d110e48-9e6b-4928-b436-14194b30d54f"},
-			mock: func(id string) {
-				mock.ExpectGet(fmt.Sprintf("%s:%s", orderObjectPrefix, id)).
-					SetVal(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","track_number":"WBILMTESTTRACK3","entry":"WBIL","delivery":{"name":"Test Testov","phone":"+9720000000","zip":"2639809","city":"Kiryat Mozkin","address":"Ploshad Mira 15","region":"Kraiot","email":"test@gmail.com"},"payment":{"transaction":"5d110e48-9e6b-4928-b436-14194b30d54f","request_id":"5d110e48-9e6b-4928-b436-14194b30d54f","currency":"USD","provider":"wbpay","amount":1817,"payment_dt":1637907727,"bank":"alpha","delivery_cost":1500,"goods_total":317,"custom_fee":0},"items":[{"chrt_id":9934930,"track_number":"WBILMTESTTRACK3","price":453,"rid":"ab4219087a764ae0btest","name":"Mascaras","sale":30,"size":"0","total_price":317,"nm_id":2389212,"brand":"Vivienne Sabo","status":202}],"locale":"en","internal_signature":"","customer_id":"test","delivery_service":"meest","shard_key":"","sm_id":99,"date_created":"2021-11-26 06:22:19 +0000 UTC","oof_shard":"1"}`)
-			},
-			expectedResult: &model.Order{
-				OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
-				TrackNumber: "WBILMTESTTRACK3",
-				Entry:       "WBIL",
-				Delivery: model.Delivery{
-					Name:    "Test Testov",
-					Phone:   "+9720000000",
-					Zip:     "2639809",
-					City:    "Kiryat Mozkin",
-					Address: "Ploshad Mira 15",
-					Region:  "Kraiot",
-					Email:   "test@gmail.com",
-				},
-				Payment: model.Payment{
-					Transaction:  "5d110e48-9e6b-4928-b436-14194b30d54f",
-					RequestID:    "5d110e48-9e6b-4928-b436-14194b30d54f",
-					Currency:     "USD",
-					Provider:     "wbpay",
-					Amount:       1817,
-					PaymentDt:    1637907727,
-					Bank:         "alpha",
-					DeliveryCost: 1500,
-					GoodsTotal:   317,
-					CustomFee:    0,
-				},
-				Items: []*model.Product{
-					{
-						ChrtID:      9934930,
-						TrackNumber: "WBILMTESTTRACK3",
-						Price:       453,
-						Rid:         "ab4219087a764ae0btest",
-						Name:        "Mascaras",
-						Sale:        30,
-						Size:        "0",
-						TotalPrice:  317,
-						NmID:        2389212,
-						Brand:       "Vivienne Sabo",
-						Status:      202,
-					},
-				},
-				Locale:            "en",
-				InternalSignature: "",
-				CustomerID:        "test",
-				DeliveryService:   "meest",
-				ShardKey:          "",
-				SmID:              99,
-				DateCreated:       "2021-11-26 06:22:19 +0000 UTC",
-				OofShard:          "1",
-			},
-		},
-		{
-			name: "Order does not exists",
-			mock: func(id string) {
-				mock.ExpectGet(fmt.Sprintf("%s:%s", orderObjectPrefix, id)).RedisNil()
-			},
-			expectedResult: &model.Order{Items: []*model.Product{}},
-			errMsg:         domain.ErrOrderNotExists.Error(),
-			wantErr:        true,
-		},
-		{
-			name: "Model and json fields mismatch",
-			mock: func(id string) {
-				mock.ExpectGet(fmt.Sprintf("%s:%s", orderObjectPrefix, id)).SetVal(``)
-			},
-			expectedResult: &model.Order{Items: []*model.Product{}},
-			errMsg:         "unexpected end of JSON input",
-			wantErr:        true,
-		},
-	}
-
-	for _, test := range testCases {
-		t.Run(test.name, func(t *testing.T) {
-			test.mock(test.mockInput.id)
-
-			order, err := cache.GetByID(context.Background(), test.mockInput.id)
-
-			if test.wantErr {
-				assert.EqualError(t, err, test.errMsg)
-				assert.Equal(t, test.expectedResult, order)
-			} else {
-				assert.Equal(t, test.expectedResult, order)
-				assert.NoError(t, err)
-			}
-		})
-	}
-}
-
 func TestSet(t *testing.T) {
 	client, mock := redismock.NewClientMock()
 	cache := newOrderCache(client, 100)
@@ -195,9 +87,41 @@ func TestSet(t *testing.T) {
 				ttl: 100,
 			},
 			mock: func(key string, order interface{}, ttl int) {
-				mock.ExpectSet(fmt.Sprintf("%s:%s", orderObjectPrefix, key), order, time.Duration(ttl)*time.Second).
-					SetVal(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","track_number":"WBILMTESTTRACK3","entry":"WBIL","delivery":{"name":"Test Testov","phone":"+9720000000","zip":"2639809","city":"Kiryat Mozkin","address":"Ploshad Mira 15","region":"Kraiot","email":"test@gmail.com"},"payment":{"transaction":"5d110e48-9e6b-4928-b436-14194b30d54f","request_id":"5d110e48-9e6b-4928-b436-14194b30d54f","currency":"USD","provider":"wbpay","amount":1817,"payment_dt":1637907727,"bank":"alpha","delivery_cost":1500,"goods_total":317,"custom_fee":0},"items":[{"chrt_id":9934930,"track_number":"WBILMTESTTRACK3","price":453,"rid":"ab4219087a764ae0btest","name":"Mascaras","sale":30,"size":"0","total_price":317,"nm_id":2389212,"brand":"Vivienne Sabo","status":202}],"locale":"en","internal_signature":"","customer_id":"test","delivery_service":"meest","shard_key":"","sm_id":99,"date_created":"2021-11-26 06:22:19 +0000 UTC","oof_shard":"1"}`)
+				mock.ExpectEval(setOrderScript, orderKeys(key), "2", order, model.ETag(order.([]byte)), ttl).SetVal(int64(1))
+			},
+		},
+		{
+			name: "OK. Order was invalidated after the version was read",
+			mockInput: struct {
+				key   string
+				order *model.Order
+				ttl   int
+			}{
+				key:   "5d110e48-9e6b-4928-b436-14194b30d54f",
+				order: &model.Order{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", Items: []*model.Product{}},
+				ttl:   100,
+			},
+			mock: func(key string, order interface{}, ttl int) {
+				mock.ExpectEval(setOrderScript, orderKeys(key), "2", order, model.ETag(order.([]byte)), ttl).SetVal(int64(0))
+			},
+		},
+		{
+			name: "Error from redis",
+			mockInput: struct {
+				key   string
+				order *model.Order
+				ttl   int
+			}{
+				key:   "5d110e48-9e6b-4928-b436-14194b30d54f",
+				order: &model.Order{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", Items: []*model.Product{}},
+				ttl:   100,
+			},
+			mock: func(key string, order interface{}, ttl int) {
+				mock.ExpectEval(setOrderScript, orderKeys(key), "2", order, model.ETag(order.([]byte)), ttl).
+					SetErr(errors.New("redis: connection refused"))
 			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
 		},
 	}
 
@@ -210,13 +134,205 @@ func TestSet(t *testing.T) {
 
 			test.mock(test.mockInput.key, data, test.mockInput.ttl)
 
-			err = cache.Set(context.Background(), test.mockInput.key, test.mockInput.order)
+			err = cache.Set(context.Background(), test.mockInput.key, test.mockInput.order, "2")
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// orderKeys ключи версии, заказа и ETag заказа для setOrderScript
+func orderKeys(key string) []string {
+	return []string{
+		fmt.Sprintf("%s:%s", orderVersionPrefix, key),
+		fmt.Sprintf("%s:%s", orderObjectPrefix, key),
+		fmt.Sprintf("%s:%s", orderETagPrefix, key),
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, 100)
+	id := "5d110e48-9e6b-4928-b436-14194b30d54f"
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		expectedResult string
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderVersionPrefix, id)).SetVal("2")
+			},
+			expectedResult: "2",
+		},
+		{
+			name: "OK. Order was never invalidated",
+			mock: func() {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderVersionPrefix, id)).RedisNil()
+			},
+			expectedResult: model.CacheVersionNone,
+		},
+		{
+			name: "Error from redis",
+			mock: func() {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderVersionPrefix, id)).SetErr(errors.New("redis: connection refused"))
+			},
+			errMsg: "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			result, err := cache.GetVersion(context.Background(), id)
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetByIDWithETag(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, 100)
+
+	const (
+		id       = "5d110e48-9e6b-4928-b436-14194b30d54f"
+		data     = `{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","track_number":"WBILMTESTTRACK3","items":[],"date_created":"2021-11-26 06:22:19 +0000 UTC"}`
+		etag     = `"0123456789abcdef"`
+		errRedis = "redis: connection refused"
+	)
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		expectedResult *model.Order
+		expectedETag   string
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectMGet(fmt.Sprintf("%s:%s", orderObjectPrefix, id), fmt.Sprintf("%s:%s", orderETagPrefix, id)).
+					SetVal([]interface{}{data, etag})
+			},
+			expectedResult: &model.Order{
+				OrderUid:    id,
+				TrackNumber: "WBILMTESTTRACK3",
+				Items:       []*model.Product{},
+				DateCreated: "2021-11-26 06:22:19 +0000 UTC",
+			},
+			expectedETag: etag,
+		},
+		{
+			name: "ETag is not cached",
+			mock: func() {
+				mock.ExpectMGet(fmt.Sprintf("%s:%s", orderObjectPrefix, id), fmt.Sprintf("%s:%s", orderETagPrefix, id)).
+					SetVal([]interface{}{data, nil})
+			},
+			expectedResult: &model.Order{
+				OrderUid:    id,
+				TrackNumber: "WBILMTESTTRACK3",
+				Items:       []*model.Product{},
+				DateCreated: "2021-11-26 06:22:19 +0000 UTC",
+			},
+			expectedETag: model.ETag([]byte(data)),
+		},
+		{
+			name: "Order does not exists",
+			mock: func() {
+				mock.ExpectMGet(fmt.Sprintf("%s:%s", orderObjectPrefix, id), fmt.Sprintf("%s:%s", orderETagPrefix, id)).
+					SetVal([]interface{}{nil, nil})
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         domain.ErrOrderNotExists.Error(),
+		},
+		{
+			name: "Redis error",
+			mock: func() {
+				mock.ExpectMGet(fmt.Sprintf("%s:%s", orderObjectPrefix, id), fmt.Sprintf("%s:%s", orderETagPrefix, id)).
+					SetErr(errors.New(errRedis))
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         errRedis,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			order, etag, err := cache.GetByIDWithETag(context.Background(), id)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, order)
+			assert.Equal(t, test.expectedETag, etag)
+		})
+	}
+}
+
+func TestGetETag(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, 100)
+
+	const id = "5d110e48-9e6b-4928-b436-14194b30d54f"
+
+	testCases := []struct {
+		name         string
+		mock         func()
+		expectedETag string
+		wantErr      bool
+		errMsg       string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderETagPrefix, id)).SetVal(`"0123456789abcdef"`)
+			},
+			expectedETag: `"0123456789abcdef"`,
+		},
+		{
+			name: "ETag does not exists",
+			mock: func() {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderETagPrefix, id)).RedisNil()
+			},
+			wantErr: true,
+			errMsg:  domain.ErrOrderNotExists.Error(),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			etag, err := cache.GetETag(context.Background(), id)
 
 			if test.wantErr {
 				assert.EqualError(t, err, test.errMsg)
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, test.expectedETag, etag)
 		})
 	}
 }
@@ -8,9 +8,11 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 	"wb_test_task/consumer/internal/application"
+	"wb_test_task/consumer/internal/consumer"
 )
 
 func main() {
@@ -23,13 +25,51 @@ func main() {
 	}
 
 	configFile := flag.String("config", "configs/config.yml", "Path to config file")
-	shutdownTimeout := flag.Int("shutdown-timeout", 1, "Time to shutdown second")
+	shutdownTimeout := flag.Int("shutdown-timeout", 30, "Time to shutdown second")
+	mode := flag.String("mode", "consume", "consume - process new messages, replay - reprocess messages of the stream")
+	replayFromSeq := flag.Uint64("replay-from-seq", 0, "Replay from stream sequence, 0 - from the beginning")
+	replayFromTime := flag.String("replay-from-time", "", "Replay messages stored since time, RFC3339")
+	replaySubjects := flag.String("replay-subjects", "", "Comma separated subjects to replay, empty - all subjects")
+	replayDatabaseUrl := flag.String("replay-database-url", "", "Target postgres url, empty - url from config")
+	replayRedisAddress := flag.String("replay-redis-address", "", "Target redis address, empty - address from config")
+	replayProgressInterval := flag.Int("replay-progress-interval", 10, "Replay progress log interval second")
 	flag.Parse()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	app, err := application.New(ctx, *configFile)
+	var (
+		app  *application.Application
+		err  error
+		opts consumer.ReplayOptions
+	)
+	switch *mode {
+	case "consume":
+		app, err = application.New(ctx, *configFile)
+	case "replay":
+		opts = consumer.ReplayOptions{
+			StartSeq:         *replayFromSeq,
+			ProgressInterval: time.Duration(*replayProgressInterval) * time.Second,
+		}
+		if len(*replayFromTime) != 0 {
+			if opts.StartTime, err = time.Parse(time.RFC3339, *replayFromTime); err != nil {
+				logger.Fatal("invalid replay-from-time", zap.Error(err))
+			}
+		}
+		if len(*replaySubjects) != 0 {
+			opts.Subjects = strings.Split(*replaySubjects, ",")
+		}
+		if err := opts.Validate(); err != nil {
+			logger.Fatal("invalid replay flags: use replay-from-seq or replay-from-time", zap.Error(err))
+		}
+
+		app, err = application.NewReplay(ctx, *configFile, application.ReplayTarget{
+			DatabaseUrl:  *replayDatabaseUrl,
+			RedisAddress: *replayRedisAddress,
+		})
+	default:
+		logger.Fatal("unknown mode", zap.String("mode", *mode))
+	}
 	if err != nil {
 		logger.Fatal("fail to init application", zap.Error(err))
 	}
@@ -40,16 +80,24 @@ func main() {
 		<-sig
 
 		logger.Info("--- shutdown application ---")
+		// отмена контекста останавливает получение новых сообщений
 		cancel()
-
-		time.Sleep(time.Duration(*shutdownTimeout))
-
-		if err := app.Shutdown(context.Background()); err != nil {
-			logger.Fatal("graceful shutdown is not complete: ", zap.String("error", err.Error()))
-		}
 	}()
 
-	if err := app.Start(ctx); err != nil {
+	if *mode == "replay" {
+		err = app.Replay(ctx, opts)
+	} else {
+		err = app.Start(ctx)
+	}
+	if err != nil {
 		log.Fatalln(err)
 	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(*shutdownTimeout)*time.Second)
+	defer cancelShutdown()
+
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		logger.Fatal("graceful shutdown is not complete: ", zap.String("error", err.Error()))
+	}
+	logger.Info("--- application stopped ---")
 }
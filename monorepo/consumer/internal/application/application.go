@@ -2,22 +2,43 @@ package application
 
 import (
 	"context"
+	"github.com/dany-ykl/logger"
 	"github.com/dany-ykl/tracer"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"time"
+	"wb_test_task/consumer/internal/cacherepair"
 	"wb_test_task/consumer/internal/config"
 	"wb_test_task/consumer/internal/consumer"
+	"wb_test_task/consumer/internal/delivery/http"
+	"wb_test_task/consumer/internal/delivery/http/health"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/consumer/internal/outbox"
 	"wb_test_task/consumer/internal/services"
 	"wb_test_task/consumer/internal/storage/psql"
 	"wb_test_task/consumer/internal/storage/redis"
 )
 
 type Application struct {
-	cfg          *config.Config
-	psqlStore    *psql.Storage
-	redisCache   *redis.Cache
-	service      *services.Service
-	natsConsumer *consumer.Consumer
-	cancelTracer func(ctx context.Context)
+	cfg           *config.Config
+	httpServer    *http.Server
+	psqlStore     *psql.Storage
+	redisCache    *redis.Cache
+	service       *services.Service
+	natsConsumer  *consumer.Consumer
+	outboxRelay   *outbox.Relay
+	cacheRepairer *cacherepair.Repairer
+	cancelTracer  func(ctx context.Context)
+}
+
+// ReplayTarget база данных и кэш, в которые записываются заказы при replay,
+// пустые значения - значения из конфига
+type ReplayTarget struct {
+	DatabaseUrl  string
+	RedisAddress string
 }
 
 func New(ctx context.Context, configFile string) (*Application, error) {
@@ -26,15 +47,46 @@ func New(ctx context.Context, configFile string) (*Application, error) {
 		return &Application{}, errors.Wrap(err, "fail to init config")
 	}
 
+	return newApplication(ctx, cfg, false)
+}
+
+// NewReplay приложение для повторной обработки сообщений стрима в target
+func NewReplay(ctx context.Context, configFile string, target ReplayTarget) (*Application, error) {
+	cfg, err := config.New(configFile)
+	if err != nil {
+		return &Application{}, errors.Wrap(err, "fail to init config")
+	}
+
+	if len(target.DatabaseUrl) != 0 {
+		cfg.Database.PostgresDatabase.Url = target.DatabaseUrl
+	}
+	if len(target.RedisAddress) != 0 {
+		cfg.Cache.RedisCache.Address = target.RedisAddress
+	}
+
+	return newApplication(ctx, cfg, true)
+}
+
+// newApplication инициализировать зависимости, replay - consumer без durable consumer для Replay
+func newApplication(ctx context.Context, cfg *config.Config, replay bool) (*Application, error) {
+
 	cache, err := redis.New(cfg.Cache.RedisCache)
 	if err != nil {
 		return &Application{}, errors.Wrap(err, "fail to init redis cache")
 	}
 
-	postgres, err := psql.New(ctx, cfg.Database.PostgresDatabase)
+	customerPolicy, err := domain.ParseCustomerPolicy(cfg.Consumer.CustomerPolicy)
+	if err != nil {
+		return &Application{}, errors.Wrap(err, "fail to init customer policy")
+	}
+	logger.Info("customer policy", zap.String("policy", string(customerPolicy)))
+	metrics.CustomerPolicy.WithLabelValues(string(customerPolicy)).Set(1)
+
+	postgres, err := psql.New(ctx, cfg.Database.PostgresDatabase, customerPolicy, replay)
 	if err != nil {
 		return &Application{}, errors.Wrap(err, "fail to init psql storage")
 	}
+	prometheus.MustRegister(metrics.NewPoolCollector(postgres.Stat))
 
 	// init tracer
 	cancelTracer, err := tracer.New(&tracer.Config{
@@ -50,35 +102,133 @@ func New(ctx context.Context, configFile string) (*Application, error) {
 	}
 
 	service := services.New(services.Depends{
-		OrderStorage: postgres.OrderStorage,
-		OrderCache:   cache.OrderCache,
+		OrderStorage:       postgres.OrderStorage,
+		OrderCache:         cache.OrderCache,
+		CacheRepairStorage: postgres.CacheRepairStorage,
 	})
 
-	natsConsumer, err := consumer.New(cfg.Consumer.NatsConsumer, service.OrderService)
+	newConsumer := consumer.New
+	if replay {
+		newConsumer = consumer.NewReplay
+	}
+
+	natsConsumer, err := newConsumer(cfg.Consumer.NatsConsumer, service.OrderService)
 	if err != nil {
 		return &Application{}, errors.Wrap(err, "fail to init natsConsumer")
 	}
 
+	// события outbox публикует и отложенные записи в кэш повторяет только приложение,
+	// обрабатывающее новые сообщения
+	var (
+		outboxRelay   *outbox.Relay
+		cacheRepairer *cacherepair.Repairer
+	)
+	if !replay {
+		if err := outbox.CreateStream(ctx, natsConsumer.JetStream(), cfg.Consumer.Outbox); err != nil {
+			return &Application{}, err
+		}
+		outboxRelay = outbox.NewRelay(cfg.Consumer.Outbox, postgres.OutboxStorage, natsConsumer.JetStream())
+		cacheRepairer = cacherepair.NewRepairer(cfg.Cache.Repair, postgres.CacheRepairStorage, service.OrderService)
+	}
+
 	return &Application{
-		cfg:          cfg,
-		psqlStore:    postgres,
-		service:      service,
-		natsConsumer: natsConsumer,
-		redisCache:   cache,
-		cancelTracer: cancelTracer,
+		cfg: cfg,
+		httpServer: http.New(cfg.Server.HttpServer, []health.Dependency{
+			{Name: "nats", Check: natsConsumer.CheckNats},
+			{Name: "jetstream_consumer", Check: natsConsumer.CheckConsumer},
+			{Name: "postgres", Check: postgres.Ping},
+			{Name: "redis", Check: cache.Ping},
+		}),
+		psqlStore:     postgres,
+		service:       service,
+		natsConsumer:  natsConsumer,
+		outboxRelay:   outboxRelay,
+		cacheRepairer: cacheRepairer,
+		redisCache:    cache,
+		cancelTracer:  cancelTracer,
 	}, nil
 }
 
 func (a *Application) Start(ctx context.Context) error {
-	if err := a.natsConsumer.Start(ctx); err != nil {
-		return errors.Wrap(err, "fail to start nats consumer")
+	// http сервер работает до Shutdown, чтобы метрики были доступны во время остановки
+	go func() {
+		if err := a.httpServer.Start(); err != nil {
+			logger.Error("fail to start http server", zap.Error(err))
+		}
+	}()
+
+	g, ctxG := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		if err := a.natsConsumer.Start(ctxG); err != nil {
+			return errors.Wrap(err, "fail to start nats consumer")
+		}
+		return nil
+	})
+	g.Go(func() error {
+		return a.outboxRelay.Start(ctxG)
+	})
+	g.Go(func() error {
+		return a.cacheRepairer.Start(ctxG)
+	})
+
+	return g.Wait()
+}
+
+// Replay повторно обработать сообщения стрима и вывести итог
+func (a *Application) Replay(ctx context.Context, opts consumer.ReplayOptions) error {
+	report, err := a.natsConsumer.Replay(ctx, opts)
+
+	fields := []zap.Field{
+		zap.Uint64("processed", report.Processed),
+		zap.Uint64("failed", report.Failed),
+		zap.Any("failures", report.Failures),
+		zap.Uint64("lastSeq", report.LastSeq),
+		zap.Duration("duration", report.Duration),
+		zap.Float64("messagesPerSecond", report.Throughput()),
 	}
+	if err != nil {
+		logger.Error("replay is not complete", append(fields, zap.Error(err))...)
+		return errors.Wrap(err, "fail to replay messages")
+	}
+
+	logger.Info("replay completed", fields...)
 	return nil
 }
 
-func (a *Application) Shutdown(_ context.Context) error {
-	if err := a.psqlStore.Shutdown(); err != nil {
-		return err
+// Shutdown остановить приложение: дождаться обработки сообщений, закрыть соединение nats,
+// остановить http сервер, отправить span в jaeger, закрыть redis и postgres. Шаги выполняются даже после ошибки
+// предыдущего шага, возвращается первая ошибка
+func (a *Application) Shutdown(ctx context.Context) error {
+	var shutdownErr error
+	step := func(name string, fn func() error) {
+		start := time.Now()
+		if err := fn(); err != nil {
+			logger.Error("shutdown step failed", zap.String("step", name),
+				zap.Duration("duration", time.Since(start)), zap.Error(err))
+			if shutdownErr == nil {
+				shutdownErr = errors.Wrapf(err, "fail to %s", name)
+			}
+			return
+		}
+		logger.Info("shutdown step completed", zap.String("step", name), zap.Duration("duration", time.Since(start)))
 	}
-	return nil
+
+	step("drain in-flight messages", func() error {
+		return a.natsConsumer.Drain(ctx)
+	})
+	step("drain nats connection", func() error {
+		return a.natsConsumer.Shutdown(ctx)
+	})
+	step("stop http server", func() error {
+		return a.httpServer.Shutdown(ctx)
+	})
+	step("flush tracer", func() error {
+		a.cancelTracer(ctx)
+		return nil
+	})
+	step("close redis", a.redisCache.Shutdown)
+	step("close postgres", a.psqlStore.Shutdown)
+
+	return shutdownErr
 }
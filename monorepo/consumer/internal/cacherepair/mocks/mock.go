@@ -0,0 +1,106 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repairer.go
+
+// Package mock_cacherepair is a generated GoMock package.
+package mock_cacherepair
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "wb_test_task/consumer/internal/domain"
+	model "wb_test_task/libs/model"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockrepairStorage is a mock of repairStorage interface.
+type MockrepairStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockrepairStorageMockRecorder
+}
+
+// MockrepairStorageMockRecorder is the mock recorder for MockrepairStorage.
+type MockrepairStorageMockRecorder struct {
+	mock *MockrepairStorage
+}
+
+// NewMockrepairStorage creates a new mock instance.
+func NewMockrepairStorage(ctrl *gomock.Controller) *MockrepairStorage {
+	mock := &MockrepairStorage{ctrl: ctrl}
+	mock.recorder = &MockrepairStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrepairStorage) EXPECT() *MockrepairStorageMockRecorder {
+	return m.recorder
+}
+
+// GetCacheLag mocks base method.
+func (m *MockrepairStorage) GetCacheLag(ctx context.Context) (*domain.CacheLag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCacheLag", ctx)
+	ret0, _ := ret[0].(*domain.CacheLag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCacheLag indicates an expected call of GetCacheLag.
+func (mr *MockrepairStorageMockRecorder) GetCacheLag(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCacheLag", reflect.TypeOf((*MockrepairStorage)(nil).GetCacheLag), ctx)
+}
+
+// ProcessCacheRepairs mocks base method.
+func (m *MockrepairStorage) ProcessCacheRepairs(ctx context.Context, limit int, repair func(context.Context, *model.Order) error, backoff func(int) time.Duration) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessCacheRepairs", ctx, limit, repair, backoff)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ProcessCacheRepairs indicates an expected call of ProcessCacheRepairs.
+func (mr *MockrepairStorageMockRecorder) ProcessCacheRepairs(ctx, limit, repair, backoff interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessCacheRepairs", reflect.TypeOf((*MockrepairStorage)(nil).ProcessCacheRepairs), ctx, limit, repair, backoff)
+}
+
+// MockorderService is a mock of orderService interface.
+type MockorderService struct {
+	ctrl     *gomock.Controller
+	recorder *MockorderServiceMockRecorder
+}
+
+// MockorderServiceMockRecorder is the mock recorder for MockorderService.
+type MockorderServiceMockRecorder struct {
+	mock *MockorderService
+}
+
+// NewMockorderService creates a new mock instance.
+func NewMockorderService(ctrl *gomock.Controller) *MockorderService {
+	mock := &MockorderService{ctrl: ctrl}
+	mock.recorder = &MockorderServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockorderService) EXPECT() *MockorderServiceMockRecorder {
+	return m.recorder
+}
+
+// RepairCache mocks base method.
+func (m *MockorderService) RepairCache(ctx context.Context, order *model.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairCache", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RepairCache indicates an expected call of RepairCache.
+func (mr *MockorderServiceMockRecorder) RepairCache(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairCache", reflect.TypeOf((*MockorderService)(nil).RepairCache), ctx, order)
+}
@@ -0,0 +1,121 @@
+package cacherepair
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/libs/model"
+)
+
+//go:generate mockgen -source=repairer.go -destination=mocks/mock.go
+type repairStorage interface {
+	ProcessCacheRepairs(ctx context.Context, limit int, repair func(ctx context.Context, order *model.Order) error,
+		backoff func(attempts int) time.Duration) (int, int, error)
+	GetCacheLag(ctx context.Context) (*domain.CacheLag, error)
+}
+
+type orderService interface {
+	RepairCache(ctx context.Context, order *model.Order) error
+}
+
+// Repairer повторяет в фоне записи в кэш, которые не удались после сохранения заказа в базе,
+// и публикует отставание кэша от базы
+type Repairer struct {
+	storage      repairStorage
+	service      orderService
+	pollInterval time.Duration
+	batchSize    int
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+}
+
+func NewRepairer(cfg config.CacheRepair, storage repairStorage, service orderService) *Repairer {
+	return &Repairer{
+		storage:      storage,
+		service:      service,
+		pollInterval: time.Duration(cfg.PollIntervalMs) * time.Millisecond,
+		batchSize:    cfg.BatchSize,
+		backoffBase:  time.Duration(cfg.BackoffBaseMs) * time.Millisecond,
+		backoffMax:   time.Duration(cfg.BackoffMaxMs) * time.Millisecond,
+	}
+}
+
+// Start повторять записи в кэш до отмены контекста
+func (r *Repairer) Start(ctx context.Context) error {
+	logger.Info("cache repairer is starting", zap.Duration("pollInterval", r.pollInterval),
+		zap.Int("batchSize", r.batchSize))
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.flush(ctx)
+		r.reportLag(ctx)
+
+		select {
+		case <-ctx.Done():
+			logger.Info("cache repairer stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush повторять записи пачками, пока есть заказы, время повтора которых наступило.
+// После неудачной записи следующая попытка выполняется на следующем тике
+func (r *Repairer) flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		repaired, failed, err := r.storage.ProcessCacheRepairs(ctx, r.batchSize, r.service.RepairCache, r.backoff)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("fail to process cache repairs", zap.Error(err))
+			}
+			return
+		}
+
+		metrics.CacheRepaired.Add(float64(repaired))
+		metrics.CacheRepairFailures.Add(float64(failed))
+
+		if failed != 0 || repaired < r.batchSize {
+			return
+		}
+	}
+}
+
+// reportLag обновить метрики отставания кэша от базы
+func (r *Repairer) reportLag(ctx context.Context) {
+	lag, err := r.storage.GetCacheLag(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("fail to get cache lag", zap.Error(err))
+		}
+		return
+	}
+
+	metrics.CacheLagOrders.Set(float64(lag.Pending))
+	if lag.Oldest.IsZero() {
+		metrics.CacheLagSeconds.Set(0)
+		return
+	}
+	metrics.CacheLagSeconds.Set(time.Since(lag.Oldest).Seconds())
+}
+
+// backoff задержка перед следующей записью: backoffBase, удваивается с каждой попыткой до backoffMax
+func (r *Repairer) backoff(attempts int) time.Duration {
+	delay := r.backoffBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.backoffMax {
+			return r.backoffMax
+		}
+	}
+
+	if delay > r.backoffMax {
+		return r.backoffMax
+	}
+	return delay
+}
@@ -0,0 +1,103 @@
+package cacherepair
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+	"time"
+	mock_cacherepair "wb_test_task/consumer/internal/cacherepair/mocks"
+	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/domain"
+)
+
+func init() {
+	if err := logger.InitLogger(logger.Config{
+		Namespace:   "test.cacherepair",
+		Development: true,
+		Level:       logger.InfoLevel,
+	}); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(storage *mock_cacherepair.MockrepairStorage)
+	}{
+		{
+			name: "Full batches are processed until nothing is due",
+			mock: func(storage *mock_cacherepair.MockrepairStorage) {
+				gomock.InOrder(
+					storage.EXPECT().ProcessCacheRepairs(gomock.Any(), 2, gomock.Any(), gomock.Any()).Return(2, 0, nil),
+					storage.EXPECT().ProcessCacheRepairs(gomock.Any(), 2, gomock.Any(), gomock.Any()).Return(0, 0, nil),
+				)
+			},
+		},
+		{
+			name: "Failed repair waits for the next tick",
+			mock: func(storage *mock_cacherepair.MockrepairStorage) {
+				storage.EXPECT().ProcessCacheRepairs(gomock.Any(), 2, gomock.Any(), gomock.Any()).Return(1, 1, nil)
+			},
+		},
+		{
+			name: "Error from storage",
+			mock: func(storage *mock_cacherepair.MockrepairStorage) {
+				storage.EXPECT().ProcessCacheRepairs(gomock.Any(), 2, gomock.Any(), gomock.Any()).
+					Return(0, 0, errors.New("error"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			storage := mock_cacherepair.NewMockrepairStorage(ct)
+			test.mock(storage)
+
+			repairer := NewRepairer(config.CacheRepair{BatchSize: 2, PollIntervalMs: 10}, storage,
+				mock_cacherepair.NewMockorderService(ct))
+			repairer.flush(context.Background())
+		})
+	}
+}
+
+func TestReportLag(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	storage := mock_cacherepair.NewMockrepairStorage(ct)
+	gomock.InOrder(
+		storage.EXPECT().GetCacheLag(gomock.Any()).Return(&domain.CacheLag{}, nil),
+		storage.EXPECT().GetCacheLag(gomock.Any()).Return(&domain.CacheLag{}, errors.New("error")),
+	)
+
+	repairer := NewRepairer(config.CacheRepair{}, storage, mock_cacherepair.NewMockorderService(ct))
+	repairer.reportLag(context.Background())
+	repairer.reportLag(context.Background())
+}
+
+func TestBackoff(t *testing.T) {
+	repairer := NewRepairer(config.CacheRepair{BackoffBaseMs: 1000, BackoffMaxMs: 5000}, nil, nil)
+
+	testCases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: time.Second},
+		{attempts: 2, expected: 2 * time.Second},
+		{attempts: 3, expected: 4 * time.Second},
+		{attempts: 4, expected: 5 * time.Second},
+		{attempts: 10, expected: 5 * time.Second},
+	}
+
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, repairer.backoff(test.attempts))
+	}
+}
@@ -5,15 +5,27 @@ import (
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 	"os"
+	"wb_test_task/libs/natsconn"
 )
 
 type Config struct {
+	Server   Server   `yaml:"server"`
 	Database Database `yaml:"database"`
 	Consumer Consumer `yaml:"consumer"`
 	Cache    Cache    `yaml:"cache"`
 	Jaeger   Jaeger   `yaml:"jaeger"`
 }
 
+type Server struct {
+	HttpServer HttpServer `yaml:"http"`
+}
+
+// HttpServer http сервер метрик и проверок состояния
+type HttpServer struct {
+	Port               string `yaml:"port" default:"9100"`
+	ReadinessTimeoutMs int    `yaml:"readiness_timeout_ms" default:"2000"`
+}
+
 type Database struct {
 	PostgresDatabase PostgresDatabase `yaml:"postgres"`
 }
@@ -25,7 +37,16 @@ type PostgresDatabase struct {
 }
 
 type Cache struct {
-	RedisCache RedisCache `yaml:"redis"`
+	RedisCache RedisCache  `yaml:"redis"`
+	Repair     CacheRepair `yaml:"repair"`
+}
+
+// CacheRepair настройки повторной записи в кэш заказов, сохраненных в базе
+type CacheRepair struct {
+	PollIntervalMs int `yaml:"poll_interval_ms" default:"1000"`
+	BatchSize      int `yaml:"batch_size" default:"100"`
+	BackoffBaseMs  int `yaml:"backoff_base_ms" default:"1000"`
+	BackoffMaxMs   int `yaml:"backoff_max_ms" default:"60000"`
 }
 
 type RedisCache struct {
@@ -35,15 +56,95 @@ type RedisCache struct {
 }
 
 type Consumer struct {
-	NatsConsumer NatsConsumer `yaml:"nats"`
+	NatsConsumer   NatsConsumer `yaml:"nats"`
+	CustomerPolicy string       `yaml:"customer_policy" default:"strict"`
+	Outbox         Outbox       `yaml:"outbox"`
+}
+
+// Outbox настройки публикации событий outbox
+type Outbox struct {
+	StreamName            string   `yaml:"stream_name" default:"order_events"`
+	Subjects              []string `yaml:"subjects" default:"[\"order.created\"]"`
+	DuplicateWindowSecond int      `yaml:"duplicate_window_second" default:"120"`
+	PollIntervalMs        int      `yaml:"poll_interval_ms" default:"1000"`
+	BatchSize             int      `yaml:"batch_size" default:"100"`
 }
 
 type NatsConsumer struct {
-	Url                  string   `yaml:"url"`
-	Subjects             []string `yaml:"subjects"`
-	RetryOfFailedConnect bool     `yaml:"retry_of_failed_connect"`
-	StreamName           string   `yaml:"stream_name"`
-	CountConsumers       int      `yaml:"count_consumers" default:"2"`
+	Url                  string          `yaml:"url"`
+	Subjects             []string        `yaml:"subjects"`
+	RetryOfFailedConnect bool            `yaml:"retry_of_failed_connect"`
+	Connection           natsconn.Config `yaml:"connection"`
+	StreamName           string          `yaml:"stream_name"`
+	CountConsumers       int             `yaml:"count_consumers" default:"2"`
+	Retry                Retry           `yaml:"retry"`
+	DeadLetter           DeadLetter      `yaml:"dead_letter"`
+	Batch                Batch           `yaml:"batch"`
+	Lanes                Lanes           `yaml:"lanes"`
+	Workers              Workers         `yaml:"workers"`
+	UnknownSubjectPolicy string          `yaml:"unknown_subject_policy" default:"dead_letter"`
+	HandlerTimeoutMs     int             `yaml:"handler_timeout_ms" default:"30000"`
+	DrainTimeoutMs       int             `yaml:"drain_timeout_ms" default:"10000"`
+	InfoPollIntervalMs   int             `yaml:"info_poll_interval_ms" default:"10000"`
+	Stream               Stream          `yaml:"stream"`
+	Durable              Durable         `yaml:"durable"`
+}
+
+// Stream настройки стрима jetstream
+type Stream struct {
+	// Bind использовать существующий стрим stream_name, настройки стрима не применяются
+	Bind                  bool   `yaml:"bind"`
+	Retention             string `yaml:"retention" default:"limits"`
+	MaxAgeSecond          int    `yaml:"max_age_second"`
+	MaxBytes              int64  `yaml:"max_bytes" default:"-1"`
+	Storage               string `yaml:"storage" default:"file"`
+	Replicas              int    `yaml:"replicas" default:"1"`
+	DuplicateWindowSecond int    `yaml:"duplicate_window_second" default:"120"`
+}
+
+// Durable настройки durable consumer jetstream
+type Durable struct {
+	Name           string   `yaml:"name"`
+	AckWaitSecond  int      `yaml:"ack_wait_second" default:"30"`
+	MaxDeliver     int      `yaml:"max_deliver" default:"-1"`
+	BackoffMs      []int    `yaml:"backoff_ms"`
+	MaxAckPending  int      `yaml:"max_ack_pending" default:"1000"`
+	DeliverPolicy  string   `yaml:"deliver_policy" default:"all"`
+	OptStartSeq    uint64   `yaml:"opt_start_seq"`
+	OptStartTime   string   `yaml:"opt_start_time"`
+	FilterSubjects []string `yaml:"filter_subjects"`
+}
+
+type Retry struct {
+	MaxDeliver    int `yaml:"max_deliver" default:"5"`
+	BackoffBaseMs int `yaml:"backoff_base_ms" default:"500"`
+	BackoffMaxMs  int `yaml:"backoff_max_ms" default:"30000"`
+}
+
+type DeadLetter struct {
+	StreamName string `yaml:"stream_name" default:"orders_dlq"`
+	Subject    string `yaml:"subject" default:"dlq.orders"`
+}
+
+type Batch struct {
+	Enabled   bool `yaml:"enabled"`
+	Size      int  `yaml:"size" default:"100"`
+	MaxWaitMs int  `yaml:"max_wait_ms" default:"500"`
+}
+
+// Lanes распределение сообщений по очередям по ключу, сообщения одного ключа обрабатываются по порядку
+type Lanes struct {
+	Enabled bool   `yaml:"enabled"`
+	Count   int    `yaml:"count" default:"8"`
+	Buffer  int    `yaml:"buffer" default:"16"`
+	Key     string `yaml:"key" default:"order_uid"`
+}
+
+// Workers пул обработчиков сообщений, размер пула не зависит от количества подписок.
+// Heartbeat применяется также к lanes и batch
+type Workers struct {
+	PoolSize            int `yaml:"pool_size" default:"16"`
+	HeartbeatIntervalMs int `yaml:"heartbeat_interval_ms" default:"10000"`
 }
 
 type Jaeger struct {
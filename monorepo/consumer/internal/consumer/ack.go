@@ -0,0 +1,47 @@
+package consumer
+
+import (
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+// received учесть сообщения, полученные из jetstream
+func received(msgs ...jetstream.Msg) {
+	for _, msg := range msgs {
+		metrics.MessagesReceived.WithLabelValues(msg.Subject()).Inc()
+	}
+}
+
+// ack подтвердить обработку сообщения
+func ack(msg jetstream.Msg) {
+	if err := msg.Ack(); err != nil {
+		logger.Warn("fail to ack message", zap.Error(err))
+		return
+	}
+	metrics.MessagesAcked.WithLabelValues(msg.Subject()).Inc()
+}
+
+// nak вернуть сообщение для повторной доставки через delay, 0 - без задержки
+func nak(msg jetstream.Msg, delay time.Duration) {
+	var err error
+	if delay > 0 {
+		err = msg.NakWithDelay(delay)
+	} else {
+		err = msg.Nak()
+	}
+	if err != nil {
+		logger.Warn("fail to nak message", zap.Error(err))
+		return
+	}
+	metrics.MessagesNaked.WithLabelValues(msg.Subject()).Inc()
+}
+
+// term прекратить доставку сообщения
+func term(msg jetstream.Msg) {
+	if err := msg.Term(); err != nil {
+		logger.Warn("fail to term message", zap.Error(err))
+	}
+}
@@ -0,0 +1,145 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/tracing"
+)
+
+// consumeBatches забирать сообщения пачками через Fetch до отмены контекста
+func (c *Consumer) consumeBatches(ctx context.Context) error {
+	maxWait := time.Duration(c.batchCfg.MaxWaitMs) * time.Millisecond
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		batch, err := c.consumer.Fetch(c.batchCfg.Size, jetstream.FetchMaxWait(maxWait))
+		if err != nil {
+			logger.Warn("fail to fetch messages", zap.Error(err))
+
+			// не повторять запрос сразу, если nats недоступен
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(maxWait):
+			}
+			continue
+		}
+
+		var msgs []jetstream.Msg
+		for msg := range batch.Messages() {
+			msgs = append(msgs, msg)
+		}
+		if err := batch.Error(); err != nil {
+			logger.Warn("fetch finished with error", zap.Int("received", len(msgs)), zap.Error(err))
+		}
+
+		if len(msgs) == 0 {
+			continue
+		}
+		received(msgs...)
+
+		if !c.acquire(len(msgs)) {
+			rejectDraining(msgs...)
+			continue
+		}
+		c.handleBatch(msgs)
+		c.release(len(msgs))
+	}
+}
+
+// handleBatch обработать пачку сообщений с сохранением порядка: подряд идущие order.create
+// записываются одной транзакцией, остальные сообщения обрабатываются по одному. Транзакция и каждое
+// отдельное сообщение ограничены messageContext, ack wait сообщений пачки продлевается до их подтверждения
+func (c *Consumer) handleBatch(msgs []jetstream.Msg) {
+	if c.workers.heartbeat > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		for _, msg := range msgs {
+			go heartbeat(msg, c.workers.heartbeat, done)
+		}
+	}
+
+	var (
+		pending  []jetstream.Msg
+		requests []*domain.OrderCreateRequest
+	)
+
+	flush := func() {
+		if len(pending) != 0 {
+			c.createOrders(pending, requests)
+		}
+		pending, requests = nil, nil
+	}
+
+	for _, msg := range msgs {
+		if msg.Subject() != SubjectOrderCreate {
+			flush()
+			c.handleMessageDeadline(msg)
+			continue
+		}
+
+		request, _, err := c.orderCreateDecoders.Decode(newMsg(msg))
+		if err != nil {
+			// сломанное сообщение или неподдерживаемая версия схемы уйдет в dead-letter через обычную обработку
+			flush()
+			c.handleMessageDeadline(msg)
+			continue
+		}
+
+		pending = append(pending, msg)
+		requests = append(requests, request)
+	}
+
+	flush()
+}
+
+// createOrders записать заказы одной транзакцией и подтвердить каждое сообщение,
+// при ошибке пачки сообщения обрабатываются по одному, чтобы изолировать сломанные заказы
+func (c *Consumer) createOrders(msgs []jetstream.Msg, requests []*domain.OrderCreateRequest) {
+	// у каждого сообщения своя трасса producer, span пачки связывается со всеми
+	links := make([]trace.Link, 0, len(msgs))
+	for _, msg := range msgs {
+		spanContext := trace.SpanContextFromContext(tracing.Extract(context.Background(), msg.Headers()))
+		if spanContext.IsValid() {
+			links = append(links, trace.Link{SpanContext: spanContext})
+		}
+	}
+
+	ctx, cancel := c.messageContext()
+	batchCtx, span := otel.Tracer("").Start(ctx, "nats-consumer-handle-batch", trace.WithLinks(links...))
+	span.SetAttributes(attribute.Int("size", len(msgs)))
+	_, err := c.orderService.CreateBatch(batchCtx, requests)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	cancel()
+
+	if err != nil {
+		logger.Warn("fail to create order batch, falling back to per-message handling",
+			zap.Int("size", len(msgs)), zap.Error(err))
+
+		for _, msg := range msgs {
+			c.handleMessageDeadline(msg)
+		}
+		return
+	}
+
+	for _, msg := range msgs {
+		ack(msg)
+	}
+}
@@ -0,0 +1,173 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	mock_consumer "wb_test_task/consumer/internal/consumer/mocks"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+// testMsg сообщение jetstream, запоминающее способ подтверждения
+type testMsg struct {
+	subject string
+	data    []byte
+	acked   bool
+	naked   bool
+	// inProgress количество отправленных InProgress
+	inProgress atomic.Int32
+}
+
+func (m *testMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: 1}, nil
+}
+func (m *testMsg) Data() []byte                           { return m.data }
+func (m *testMsg) Headers() nats.Header                   { return nats.Header{} }
+func (m *testMsg) Subject() string                        { return m.subject }
+func (m *testMsg) Reply() string                          { return "" }
+func (m *testMsg) Ack() error                             { m.acked = true; return nil }
+func (m *testMsg) DoubleAck(context.Context) error        { m.acked = true; return nil }
+func (m *testMsg) Nak() error                             { m.naked = true; return nil }
+func (m *testMsg) NakWithDelay(delay time.Duration) error { m.naked = true; return nil }
+func (m *testMsg) InProgress() error                      { m.inProgress.Add(1); return nil }
+func (m *testMsg) Term() error                            { return nil }
+
+func TestHandleBatch(t *testing.T) {
+	first := &domain.OrderCreateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"}
+	second := &domain.OrderCreateRequest{OrderUid: "9c1e0f0e-6f7a-4a7e-9a63-3e2a6c5b1d11"}
+	statusUpdate := &domain.OrderStatusUpdateRequest{OrderUid: first.OrderUid, Status: domain.StatusPaid}
+
+	firstData, _ := json.Marshal(first)
+	secondData, _ := json.Marshal(second)
+	statusUpdateData, _ := json.Marshal(statusUpdate)
+
+	testCases := []struct {
+		name string
+		msgs func() []*testMsg
+		mock func(service *mock_consumer.MockorderService, ctx gomock.Matcher)
+	}{
+		{
+			name: "OK. One transaction",
+			msgs: func() []*testMsg {
+				return []*testMsg{
+					{subject: SubjectOrderCreate, data: firstData},
+					{subject: SubjectOrderCreate, data: secondData},
+				}
+			},
+			mock: func(service *mock_consumer.MockorderService, ctx gomock.Matcher) {
+				service.EXPECT().CreateBatch(ctx, []*domain.OrderCreateRequest{first, second}).
+					Return([]*model.Order{{}, {}}, nil)
+			},
+		},
+		{
+			name: "Batch error. Per-message fallback",
+			msgs: func() []*testMsg {
+				return []*testMsg{
+					{subject: SubjectOrderCreate, data: firstData},
+					{subject: SubjectOrderCreate, data: secondData},
+				}
+			},
+			mock: func(service *mock_consumer.MockorderService, ctx gomock.Matcher) {
+				gomock.InOrder(
+					service.EXPECT().CreateBatch(ctx, []*domain.OrderCreateRequest{first, second}).
+						Return(nil, errors.New("error")),
+					service.EXPECT().Create(ctx, first).Return(&model.Order{}, nil),
+					service.EXPECT().Create(ctx, second).Return(&model.Order{}, nil),
+				)
+			},
+		},
+		{
+			name: "OK. Order of subjects preserved",
+			msgs: func() []*testMsg {
+				return []*testMsg{
+					{subject: SubjectOrderCreate, data: firstData},
+					{subject: SubjectOrderStatusUpdate, data: statusUpdateData},
+					{subject: SubjectOrderCreate, data: secondData},
+				}
+			},
+			mock: func(service *mock_consumer.MockorderService, ctx gomock.Matcher) {
+				gomock.InOrder(
+					service.EXPECT().CreateBatch(ctx, []*domain.OrderCreateRequest{first}).
+						Return([]*model.Order{{}}, nil),
+					service.EXPECT().UpdateStatus(ctx, statusUpdate).Return(nil),
+					service.EXPECT().CreateBatch(ctx, []*domain.OrderCreateRequest{second}).
+						Return([]*model.Order{{}}, nil),
+				)
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			orderService := mock_consumer.NewMockorderService(ct)
+			test.mock(orderService, gomock.Any())
+
+			consumer := newTestConsumer(orderService)
+
+			msgs := test.msgs()
+			batch := make([]jetstream.Msg, 0, len(msgs))
+			for _, msg := range msgs {
+				batch = append(batch, msg)
+			}
+
+			consumer.handleBatch(batch)
+
+			for _, msg := range msgs {
+				assert.True(t, msg.acked)
+				assert.False(t, msg.naked)
+			}
+		})
+	}
+}
+
+func TestHandleBatchDeadline(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	first := &domain.OrderCreateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"}
+	second := &domain.OrderCreateRequest{OrderUid: "9c1e0f0e-6f7a-4a7e-9a63-3e2a6c5b1d11"}
+	firstData, _ := json.Marshal(first)
+	secondData, _ := json.Marshal(second)
+
+	service := mock_consumer.NewMockorderService(ct)
+	// транзакция пачки не укладывается в deadline, каждое сообщение обрабатывается со своим deadline
+	gomock.InOrder(
+		service.EXPECT().CreateBatch(gomock.Any(), []*domain.OrderCreateRequest{first, second}).
+			DoAndReturn(func(ctx context.Context, requests []*domain.OrderCreateRequest) ([]*model.Order, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}),
+		service.EXPECT().Create(gomock.Any(), first).
+			DoAndReturn(func(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+				assert.NoError(t, ctx.Err())
+				return &model.Order{}, nil
+			}),
+		service.EXPECT().Create(gomock.Any(), second).Return(&model.Order{}, nil),
+	)
+
+	consumer := newTestConsumer(service)
+	consumer.workers = newWorkers(config.Workers{HeartbeatIntervalMs: 10}, 100*time.Millisecond)
+
+	msgs := []*testMsg{
+		{subject: SubjectOrderCreate, data: firstData},
+		{subject: SubjectOrderCreate, data: secondData},
+	}
+	consumer.handleBatch([]jetstream.Msg{msgs[0], msgs[1]})
+
+	for _, msg := range msgs {
+		assert.True(t, msg.acked)
+		assert.Greater(t, msg.inProgress.Load(), int32(0))
+	}
+}
@@ -2,22 +2,34 @@ package consumer
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"github.com/dany-ykl/logger"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"sync"
+	"sync/atomic"
+	"time"
 	"wb_test_task/consumer/internal/common"
 	"wb_test_task/consumer/internal/config"
 	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/consumer/internal/metrics"
 	"wb_test_task/libs/model"
+	"wb_test_task/libs/natsconn"
+)
+
+var ErrUnknownSubject = errors.New("unknown subject")
+
+const (
+	SubjectOrderCreate       = "order.create"
+	SubjectOrderStatusUpdate = "order.status.update"
+	SubjectOrderCancel       = "order.cancel"
 )
 
 type Msg struct {
 	Subject string
+	Header  nats.Header
 	Data    []byte
 }
 
@@ -29,119 +41,399 @@ type Consumer struct {
 	onMessage      func(ctx context.Context, msg *Msg)
 	orderService   orderService
 	countConsumers int
+	retryCfg       config.Retry
+	deadLetterCfg  config.DeadLetter
+	batchCfg       config.Batch
+	lanes          *lanes
+	workers        *workers
+	router         *Router
+
+	unknownSubjectPolicy UnknownSubjectPolicy
+
+	orderCreateDecoders       *DecoderRegistry[domain.OrderCreateRequest]
+	orderStatusUpdateDecoders *DecoderRegistry[domain.OrderStatusUpdateRequest]
+	orderCancelDecoders       *DecoderRegistry[domain.OrderCancelRequest]
+
+	// обработчики не зависят от контекста Start, чтобы остановка не прерывала начатую обработку
+	handlerCtx     context.Context
+	cancelHandlers context.CancelFunc
+	drainTimeout   time.Duration
+	// infoPollInterval период публикации состояния durable consumer в метрики, 0 - не публиковать
+	infoPollInterval time.Duration
+	drainMu          sync.Mutex
+	draining         bool
+	inFlight         sync.WaitGroup
+	inFlightCount    atomic.Int64
+	closed           chan struct{}
 }
 
 func New(cfg config.NatsConsumer, service orderService) (*Consumer, error) {
-	nc, err := nats.Connect(
-		cfg.Url,
-		nats.RetryOnFailedConnect(cfg.RetryOfFailedConnect),
-	)
+	unknownSubjectPolicy, err := ParseUnknownSubjectPolicy(cfg.UnknownSubjectPolicy)
 	if err != nil {
-		return &Consumer{}, errors.Wrap(err, "fail to connect to nats")
+		return &Consumer{}, err
 	}
 
-	js, err := jetstream.New(nc)
+	lanes, err := newLanes(cfg.Lanes)
 	if err != nil {
-		return &Consumer{}, errors.Wrap(err, "fail to create jetstream")
+		return &Consumer{}, errors.Wrap(err, "fail to init lanes")
+	}
+	if lanes != nil && cfg.Batch.Enabled {
+		return &Consumer{}, errors.New("lanes and batch can not be enabled together")
 	}
 
-	stream, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
-		Name:     cfg.StreamName,
-		Subjects: cfg.Subjects,
-	})
+	nc, js, closed, err := connect(cfg)
 	if err != nil {
-		return &Consumer{}, errors.Wrap(err, "fail to create stream")
+		return &Consumer{}, err
 	}
 
-	consumer, err := stream.CreateOrUpdateConsumer(context.Background(), jetstream.ConsumerConfig{
-		Durable:   fmt.Sprintf("%sconsumer", cfg.StreamName),
-		AckPolicy: jetstream.AckExplicitPolicy,
-	})
+	stream, streamSubjects, err := setupStream(context.Background(), js, cfg)
+	if err != nil {
+		return &Consumer{}, err
+	}
+
+	consumedSubjects := streamSubjects
+	if len(cfg.Durable.FilterSubjects) != 0 {
+		consumedSubjects = cfg.Durable.FilterSubjects
+	}
+	if lanes != nil {
+		if err := lanes.checkSubjects(consumedSubjects); err != nil {
+			return &Consumer{}, errors.Wrap(err, "fail to init lanes")
+		}
+	}
+
+	if len(cfg.DeadLetter.Subject) != 0 {
+		if _, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+			Name:     cfg.DeadLetter.StreamName,
+			Subjects: []string{cfg.DeadLetter.Subject},
+		}); err != nil {
+			return &Consumer{}, errors.Wrap(err, "fail to create dead-letter stream")
+		}
+	}
+
+	consumerCfg, err := consumerConfig(cfg)
+	if err != nil {
+		return &Consumer{}, errors.Wrap(err, "fail to init consumer config")
+	}
+
+	// сервер перестает доставлять сообщение после max_deliver попыток, и до dead-letter оно не дойдет
+	if consumerCfg.MaxDeliver > 0 && consumerCfg.MaxDeliver < cfg.Retry.MaxDeliver {
+		logger.Warn("durable max_deliver is less than retry max_deliver, messages may be dropped without dead-letter",
+			zap.Int("durableMaxDeliver", consumerCfg.MaxDeliver), zap.Int("retryMaxDeliver", cfg.Retry.MaxDeliver))
+	}
+
+	// сообщения в очередях lane уже доставлены и ждут подтверждения
+	if lanes != nil && consumerCfg.MaxAckPending > 0 && consumerCfg.MaxAckPending < cfg.Lanes.Count*(cfg.Lanes.Buffer+1) {
+		logger.Warn("durable max_ack_pending is less than lanes capacity, lanes will not be filled",
+			zap.Int("maxAckPending", consumerCfg.MaxAckPending), zap.Int("lanes", cfg.Lanes.Count),
+			zap.Int("buffer", cfg.Lanes.Buffer))
+	}
+
+	// InProgress продлевает ack wait, сообщение без heartbeat будет доставлено повторно до истечения handler_timeout_ms
+	heartbeatInterval := time.Duration(cfg.Workers.HeartbeatIntervalMs) * time.Millisecond
+	if heartbeatInterval >= consumerCfg.AckWait {
+		logger.Warn("workers heartbeat interval is not less than durable ack wait, long messages will be redelivered",
+			zap.Duration("heartbeatInterval", heartbeatInterval), zap.Duration("ackWait", consumerCfg.AckWait))
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(context.Background(), consumerCfg)
 	if err != nil {
 		return &Consumer{}, errors.Wrap(err, "fail to create consumer")
 	}
 
-	return &Consumer{
+	c := &Consumer{
 		conn:           nc,
 		js:             js,
 		stream:         stream,
 		consumer:       consumer,
 		orderService:   service,
 		countConsumers: cfg.CountConsumers,
-	}, nil
+		retryCfg:       cfg.Retry,
+		deadLetterCfg:  cfg.DeadLetter,
+		batchCfg:       cfg.Batch,
+		lanes:          lanes,
+		workers:        newWorkers(cfg.Workers, time.Duration(cfg.HandlerTimeoutMs)*time.Millisecond),
+		router:         NewRouter(),
+
+		unknownSubjectPolicy: unknownSubjectPolicy,
+
+		drainTimeout:     time.Duration(cfg.DrainTimeoutMs) * time.Millisecond,
+		infoPollInterval: time.Duration(cfg.InfoPollIntervalMs) * time.Millisecond,
+		closed:           closed,
+	}
+	c.handlerCtx, c.cancelHandlers = context.WithCancel(context.Background())
+	c.registerDecoders()
+	// время обработки ограничивает messageContext
+	c.registerHandlers(0)
+
+	// subject стрима и обработчики настраиваются отдельно, расхождение видно при старте
+	unhandled, unsubscribed := c.router.Drift(consumedSubjects)
+	if len(unhandled) != 0 {
+		logger.Warn("stream subjects without handler", zap.Strings("subjects", unhandled),
+			zap.String("unknownSubjectPolicy", string(unknownSubjectPolicy)))
+	}
+	if len(unsubscribed) != 0 {
+		logger.Warn("handlers for subjects outside of the stream", zap.Strings("subjects", unsubscribed))
+	}
+
+	return c, nil
+}
+
+// connect подключиться к nats, closed закрывается после закрытия соединения
+func connect(cfg config.NatsConsumer) (*nats.Conn, jetstream.JetStream, chan struct{}, error) {
+	opts, err := natsconn.Options(cfg.Connection)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "fail to init nats connection options")
+	}
+
+	closed := make(chan struct{})
+	opts = append(opts, natsconn.EventOptions(natsconn.ZapLogger{InfoFunc: logger.Info, WarnFunc: logger.Warn}, func() {
+		close(closed)
+	})...)
+	opts = append(opts,
+		nats.RetryOnFailedConnect(cfg.RetryOfFailedConnect),
+		nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+			fields := []zap.Field{zap.Error(err)}
+			if sub != nil {
+				fields = append(fields, zap.String("subject", sub.Subject))
+			}
+			logger.Warn("nats async error", fields...)
+		}),
+	)
+
+	nc, err := nats.Connect(cfg.Url, opts...)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "fail to connect to nats")
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "fail to create jetstream")
+	}
+
+	return nc, js, closed, nil
+}
+
+// JetStream вернуть jetstream соединения consumer
+func (c *Consumer) JetStream() jetstream.JetStream {
+	return c.js
 }
 
 func (c *Consumer) Start(ctx context.Context) error {
 	g, ctxG := errgroup.WithContext(ctx)
 
-	for i := 0; i < c.countConsumers; i++ {
-		id := i
+	g.Go(func() error {
+		return c.pollConsumerInfo(ctxG)
+	})
+
+	if c.lanes != nil {
+		logger.Info("nats lanes consumer is starting", zap.Int("lanes", len(c.lanes.queues)),
+			zap.String("key", string(c.lanes.key)))
 		g.Go(func() error {
-			logger.Info("nats consumer is starting", zap.Int("id", id))
-			cc, err := c.consumer.Consume(func(msg jetstream.Msg) {
-				if err := c.OnMessage(ctxG, &Msg{
-					Subject: msg.Subject(),
-					Data:    msg.Data(),
-				}); err != nil {
-					var wrapErr common.WrapError
-					if errors.As(err, &wrapErr) {
-						logger.Warn("fail to handle message", zap.String("errorType", wrapErr.Error()), zap.String("error", wrapErr.Message()))
-					} else {
-						logger.Warn("fail to handle message", zap.Error(err))
-					}
+			return c.consumeLanes(ctxG)
+		})
+	} else {
+		if !c.batchCfg.Enabled {
+			c.startWorkers()
+		}
+
+		for i := 0; i < c.countConsumers; i++ {
+			id := i
+			g.Go(func() error {
+				if c.batchCfg.Enabled {
+					logger.Info("nats batch consumer is starting", zap.Int("id", id),
+						zap.Int("batchSize", c.batchCfg.Size), zap.Int("maxWaitMs", c.batchCfg.MaxWaitMs))
+					return c.consumeBatches(ctxG)
 				}
 
-				if err := msg.Ack(); err != nil {
-					logger.Warn("fail to ack message", zap.Error(err))
+				logger.Info("nats consumer is starting", zap.Int("id", id))
+				cc, err := c.consumer.Consume(func(msg jetstream.Msg) {
+					received(msg)
+					c.submit(msg)
+				})
+				if err != nil {
+					return errors.Wrap(err, "fail to start consume")
 				}
-			})
-			if err != nil {
-				return errors.Wrap(err, "fail to start consume")
-			}
-			defer cc.Stop()
+				defer cc.Stop()
 
-			select {
-			case <-ctxG.Done():
-				return nil
-			}
-		})
+				select {
+				case <-ctxG.Done():
+					return nil
+				}
+			})
+		}
 	}
 
 	if err := g.Wait(); err != nil {
 		return errors.Wrap(err, "fail to wait")
 	}
 
+	logger.Info("nats consumer stopped pulling messages")
 	return nil
 }
 
+// handleMessage обработать сообщение и подтвердить его: ack при успехе,
+// nak с задержкой при временной ошибке, dead-letter при постоянной ошибке
+// или исчерпании попыток доставки
+func (c *Consumer) handleMessage(ctx context.Context, msg jetstream.Msg) {
+	c.settleMessage(ctx, msg, c.OnMessage(ctx, newMsg(msg)), deliveryCount(msg))
+}
+
+// settleMessage подтвердить сообщение по результату обработки err, numDelivered - номер попытки
+func (c *Consumer) settleMessage(ctx context.Context, msg jetstream.Msg, err error, numDelivered uint64) {
+	if err == nil {
+		ack(msg)
+		return
+	}
+
+	if errors.Is(err, ErrUnknownSubject) {
+		c.handleUnknownSubject(ctx, msg, err)
+		return
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if numDelivered < uint64(c.retryCfg.MaxDeliver) {
+			// время обработки истекло, сообщение сразу возвращается для повторной доставки
+			logger.Warn("message deadline exceeded", zap.String("subject", msg.Subject()),
+				zap.Uint64("numDelivered", numDelivered), zap.Error(err))
+			metrics.MessagesDeadlineExceeded.WithLabelValues(msg.Subject()).Inc()
+			nak(msg, 0)
+			return
+		}
+		// контекст обработки истек, dead-letter публикуется без него
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	kind := classifyError(msg.Subject(), err)
+	fields := []zap.Field{
+		zap.String("subject", msg.Subject()),
+		zap.String("kind", kind.String()),
+		zap.Uint64("numDelivered", numDelivered),
+	}
+	var wrapErr common.WrapError
+	if errors.As(err, &wrapErr) {
+		fields = append(fields, zap.String("errorType", wrapErr.Error()), zap.String("error", wrapErr.Message()))
+	} else {
+		fields = append(fields, zap.Error(err))
+	}
+	logger.Warn("fail to handle message", fields...)
+
+	delay := c.retryDelay(numDelivered)
+
+	reason := deadLetterReasonPermanent
+	if kind == failureTransient {
+		if numDelivered < uint64(c.retryCfg.MaxDeliver) {
+			nak(msg, delay)
+			return
+		}
+		reason = deadLetterReasonRetriesExhausted
+	}
+
+	if err := c.deadLetter(ctx, msg, reason, err, numDelivered); err != nil {
+		logger.Error("fail to dead-letter message", zap.String("subject", msg.Subject()), zap.Error(err))
+		nak(msg, delay)
+		return
+	}
+
+	term(msg)
+}
+
+// retryDelay задержка перед повтором обработки сообщения после numDelivered попыток
+func (c *Consumer) retryDelay(numDelivered uint64) time.Duration {
+	return backoff(numDelivered,
+		time.Duration(c.retryCfg.BackoffBaseMs)*time.Millisecond,
+		time.Duration(c.retryCfg.BackoffMaxMs)*time.Millisecond,
+	)
+}
+
+// newMsg сообщение для обработчиков router
+func newMsg(msg jetstream.Msg) *Msg {
+	return &Msg{
+		Subject: msg.Subject(),
+		Header:  msg.Headers(),
+		Data:    msg.Data(),
+	}
+}
+
+// deliveryCount вернуть номер доставки сообщения
+func deliveryCount(msg jetstream.Msg) uint64 {
+	if meta, err := msg.Metadata(); err == nil {
+		return meta.NumDelivered
+	}
+	return 1
+}
+
+// OnMessage передать сообщение обработчику subject из router
 func (c *Consumer) OnMessage(ctx context.Context, msg *Msg) error {
-	switch msg.Subject {
-	case "order.create":
-		return c.orderCreateHandler(ctx, msg)
-	default:
-		return errors.Wrap(errors.New("fail to onMessage msg"), msg.Subject)
+	handler, ok := c.router.Match(msg.Subject)
+	if !ok {
+		return errors.Wrap(ErrUnknownSubject, msg.Subject)
 	}
+
+	return handler(ctx, msg)
+}
+
+// registerHandlers зарегистрировать middleware и обработчики subject заказов. RecoverMiddleware
+// стоит внутри трассировки, логов и метрик, чтобы паника обработчика учитывалась как ошибка обработки
+func (c *Consumer) registerHandlers(handlerTimeout time.Duration) {
+	c.router.Use(
+		TracingMiddleware(),
+		LoggingMiddleware(),
+		MetricsMiddleware(),
+		RecoverMiddleware(),
+		TimeoutMiddleware(handlerTimeout),
+	)
+
+	c.router.Handle(SubjectOrderCreate, c.orderCreateHandler)
+	c.router.Handle(SubjectOrderStatusUpdate, c.orderStatusUpdateHandler)
+	c.router.Handle(SubjectOrderCancel, c.orderCancelHandler)
 }
 
 //go:generate mockgen -source=consumer.go -destination=mocks/mock.go
 type orderService interface {
 	Create(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error)
+	CreateBatch(ctx context.Context, requests []*domain.OrderCreateRequest) ([]*model.Order, error)
+	UpdateStatus(ctx context.Context, request *domain.OrderStatusUpdateRequest) error
+	Cancel(ctx context.Context, request *domain.OrderCancelRequest) error
 }
 
 func (c *Consumer) orderCreateHandler(ctx context.Context, msg *Msg) error {
-	var orderCrateRequest domain.OrderCreateRequest
-	if err := json.Unmarshal(msg.Data, &orderCrateRequest); err != nil {
-		return errors.Wrap(err, "fail to unmarshal msg")
+	orderCrateRequest, meta, err := c.orderCreateDecoders.Decode(msg)
+	if err != nil {
+		return err
 	}
+	annotateEnvelope(ctx, meta)
 
-	if _, err := c.orderService.Create(ctx, &orderCrateRequest); err != nil {
+	if _, err := c.orderService.Create(ctx, orderCrateRequest); err != nil {
 		return errors.Wrap(err, "fail to create order")
 	}
 
 	return nil
 }
 
-func (c *Consumer) Shutdown() error {
-	c.conn.Close()
+func (c *Consumer) orderStatusUpdateHandler(ctx context.Context, msg *Msg) error {
+	request, meta, err := c.orderStatusUpdateDecoders.Decode(msg)
+	if err != nil {
+		return err
+	}
+	annotateEnvelope(ctx, meta)
+
+	if err := c.orderService.UpdateStatus(ctx, request); err != nil {
+		return errors.Wrap(err, "fail to update order status")
+	}
+
+	return nil
+}
+
+func (c *Consumer) orderCancelHandler(ctx context.Context, msg *Msg) error {
+	request, meta, err := c.orderCancelDecoders.Decode(msg)
+	if err != nil {
+		return err
+	}
+	annotateEnvelope(ctx, meta)
+
+	if err := c.orderService.Cancel(ctx, request); err != nil {
+		return errors.Wrap(err, "fail to cancel order")
+	}
+
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"log"
 	"testing"
+	"time"
 	"wb_test_task/consumer/internal/config"
 	mock_consumer "wb_test_task/consumer/internal/consumer/mocks"
 	"wb_test_task/consumer/internal/domain"
@@ -25,6 +26,21 @@ func init() {
 	}
 }
 
+// newTestConsumer consumer с зарегистрированными обработчиками без подключения к nats
+func newTestConsumer(service orderService) *Consumer {
+	consumer := &Consumer{
+		orderService:         service,
+		retryCfg:             config.Retry{MaxDeliver: 5},
+		workers:              newWorkers(config.Workers{PoolSize: 2}, 0),
+		router:               NewRouter(),
+		unknownSubjectPolicy: UnknownSubjectDeadLetter,
+	}
+	consumer.handlerCtx, consumer.cancelHandlers = context.WithCancel(context.Background())
+	consumer.registerDecoders()
+	consumer.registerHandlers(time.Second)
+	return consumer
+}
+
 func TestOrderCreateHandler(t *testing.T) {
 	createOrderRequest := &domain.OrderCreateRequest{
 		OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
@@ -184,7 +200,7 @@ func TestOrderCreateHandler(t *testing.T) {
 			orderService := mock_consumer.NewMockorderService(ct)
 			test.mock(orderService, context.Background(), createOrderRequest)
 
-			consumer := Consumer{orderService: orderService}
+			consumer := newTestConsumer(orderService)
 			err := consumer.orderCreateHandler(context.Background(), test.mockInput.msg)
 
 			if test.wantErr {
@@ -196,6 +212,84 @@ func TestOrderCreateHandler(t *testing.T) {
 	}
 }
 
+func TestOrderStatusHandlers(t *testing.T) {
+	statusUpdateRequest := &domain.OrderStatusUpdateRequest{
+		OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		Status:   domain.StatusPaid,
+	}
+	cancelRequest := &domain.OrderCancelRequest{
+		OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		Reason:   "customer request",
+	}
+
+	statusUpdateData, err := json.Marshal(statusUpdateRequest)
+	if err != nil {
+		t.Error(err)
+	}
+	cancelData, err := json.Marshal(cancelRequest)
+	if err != nil {
+		t.Error(err)
+	}
+
+	testCases := []struct {
+		name    string
+		msg     *Msg
+		mock    func(service *mock_consumer.MockorderService, ctx gomock.Matcher)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "OK. Status update",
+			msg:  &Msg{Subject: SubjectOrderStatusUpdate, Data: statusUpdateData},
+			mock: func(service *mock_consumer.MockorderService, ctx gomock.Matcher) {
+				service.EXPECT().UpdateStatus(ctx, statusUpdateRequest).Return(nil)
+			},
+		},
+		{
+			name: "Status update service error",
+			msg:  &Msg{Subject: SubjectOrderStatusUpdate, Data: statusUpdateData},
+			mock: func(service *mock_consumer.MockorderService, ctx gomock.Matcher) {
+				service.EXPECT().UpdateStatus(ctx, statusUpdateRequest).Return(domain.ErrInvalidStatusTransition)
+			},
+			wantErr: true,
+			errMsg:  "fail to update order status: invalid order status transition",
+		},
+		{
+			name: "OK. Cancel",
+			msg:  &Msg{Subject: SubjectOrderCancel, Data: cancelData},
+			mock: func(service *mock_consumer.MockorderService, ctx gomock.Matcher) {
+				service.EXPECT().Cancel(ctx, cancelRequest).Return(nil)
+			},
+		},
+		{
+			name:    "Unknown subject",
+			msg:     &Msg{Subject: "order.unknown", Data: cancelData},
+			mock:    func(service *mock_consumer.MockorderService, ctx gomock.Matcher) {},
+			wantErr: true,
+			errMsg:  "order.unknown: unknown subject",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			orderService := mock_consumer.NewMockorderService(ct)
+			test.mock(orderService, gomock.Any())
+
+			consumer := newTestConsumer(orderService)
+			err := consumer.OnMessage(context.Background(), test.msg)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestNew(t *testing.T) {
 	ct := gomock.NewController(t)
 	defer ct.Finish()
@@ -0,0 +1,81 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"strconv"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+const (
+	HeaderDeadLetterSubject       = "Dlq-Original-Subject"
+	HeaderDeadLetterReason        = "Dlq-Reason"
+	HeaderDeadLetterErrorType     = "Dlq-Error-Type"
+	HeaderDeadLetterErrorMessage  = "Dlq-Error-Message"
+	HeaderDeadLetterDeliveryCount = "Dlq-Delivery-Count"
+)
+
+const (
+	deadLetterReasonPermanent        = "permanent"
+	deadLetterReasonRetriesExhausted = "retries_exhausted"
+	deadLetterReasonUnknownSubject   = "unknown_subject"
+)
+
+// deadLetter отправить сообщение в dead-letter subject. Без dead-letter subject сообщение
+// отбрасывается: содержимое остается только в логе и счетчике отброшенных сообщений
+func (c *Consumer) deadLetter(ctx context.Context, msg jetstream.Msg, reason string, cause error, numDelivered uint64) error {
+	if len(c.deadLetterCfg.Subject) == 0 {
+		metrics.MessagesDropped.WithLabelValues(msg.Subject(), reason).Inc()
+		logger.Error("dead-letter is not configured, message is dropped",
+			zap.String("subject", msg.Subject()), zap.String("reason", reason),
+			zap.Uint64("numDelivered", numDelivered), zap.ByteString("payload", msg.Data()), zap.Error(cause))
+		return nil
+	}
+
+	header := nats.Header{}
+	for key, values := range msg.Headers() {
+		header[key] = values
+	}
+
+	errorType, errorMessage := describeError(cause)
+	header.Set(HeaderDeadLetterSubject, msg.Subject())
+	header.Set(HeaderDeadLetterReason, reason)
+	header.Set(HeaderDeadLetterErrorType, errorType)
+	header.Set(HeaderDeadLetterErrorMessage, errorMessage)
+	header.Set(HeaderDeadLetterDeliveryCount, strconv.FormatUint(numDelivered, 10))
+
+	if _, err := c.js.PublishMsg(ctx, &nats.Msg{
+		Subject: c.deadLetterCfg.Subject,
+		Header:  header,
+		Data:    msg.Data(),
+	}); err != nil {
+		return errors.Wrap(err, "fail to publish message to dead-letter")
+	}
+	metrics.MessagesDeadLettered.WithLabelValues(msg.Subject(), reason).Inc()
+
+	return nil
+}
+
+// describeError вернуть тип ошибки и сообщение WrapError для заголовков dead-letter
+func describeError(err error) (string, string) {
+	var wrapErr common.WrapError
+	if errors.As(err, &wrapErr) {
+		return wrapErr.Error(), wrapErr.Message()
+	}
+
+	var (
+		syntaxErr    *json.SyntaxError
+		unmarshalErr *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalErr) {
+		return "unmarshal error", err.Error()
+	}
+
+	return errors.Cause(err).Error(), err.Error()
+}
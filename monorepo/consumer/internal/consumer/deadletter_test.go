@@ -0,0 +1,23 @@
+package consumer
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+func TestDeadLetterNotConfigured(t *testing.T) {
+	consumer := newTestConsumer(nil)
+	msg := &testMsg{subject: SubjectOrderCreate, data: []byte(`{"order_uid":`)}
+	dropped := metrics.MessagesDropped.WithLabelValues(SubjectOrderCreate, deadLetterReasonPermanent)
+	before := testutil.ToFloat64(dropped)
+
+	err := consumer.deadLetter(context.Background(), msg, deadLetterReasonPermanent, errors.New("unexpected end of JSON input"), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(dropped))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.MessagesDeadLettered.WithLabelValues(SubjectOrderCreate, deadLetterReasonPermanent)))
+}
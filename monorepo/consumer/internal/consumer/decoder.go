@@ -0,0 +1,114 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+)
+
+var ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+
+const (
+	// orderCreateSchemaV1 заказ без конверта, shard key передается в поле shardkey
+	orderCreateSchemaV1 = envelope.LegacyVersion
+	// orderCreateSchemaV2 текущая схема domain.OrderCreateRequest
+	orderCreateSchemaV2 = 2
+)
+
+// Decoder разобрать payload одной версии схемы и привести его к текущей модели домена.
+// Кодек и сжатие payload выбираются по заголовкам сообщения
+type Decoder[T any] func(header map[string][]string, payload []byte) (*T, error)
+
+// DecoderRegistry декодеры payload subject по версиям схемы
+type DecoderRegistry[T any] struct {
+	decoders map[int]Decoder[T]
+}
+
+func NewDecoderRegistry[T any]() *DecoderRegistry[T] {
+	return &DecoderRegistry[T]{decoders: make(map[int]Decoder[T])}
+}
+
+// Register зарегистрировать декодер версии схемы
+func (r *DecoderRegistry[T]) Register(version int, decoder Decoder[T]) {
+	r.decoders[version] = decoder
+}
+
+// Decode открыть конверт сообщения и разобрать payload декодером его версии схемы
+func (r *DecoderRegistry[T]) Decode(msg *Msg) (*T, envelope.Metadata, error) {
+	meta, payload, err := envelope.Open(msg.Header, msg.Data)
+	if err != nil {
+		return nil, envelope.Metadata{}, err
+	}
+
+	decoder, ok := r.decoders[meta.SchemaVersion]
+	if !ok {
+		return nil, meta, errors.Wrap(ErrUnsupportedSchemaVersion, fmt.Sprintf("%s v%d", msg.Subject, meta.SchemaVersion))
+	}
+
+	value, err := decoder(msg.Header, payload)
+	if err != nil {
+		return nil, meta, errors.Wrap(err, "fail to unmarshal msg")
+	}
+
+	return value, meta, nil
+}
+
+// decodePayload декодер версии схемы, совпадающей с моделью домена
+func decodePayload[T any](header map[string][]string, payload []byte) (*T, error) {
+	var value T
+	if err := codec.Decode(header, payload, &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// orderCreateRequestV1 заказ схемы v1
+type orderCreateRequestV1 struct {
+	domain.OrderCreateRequest
+	ShardKeyV1 string `json:"shardkey"`
+}
+
+// decodeOrderCreateV1 разобрать заказ схемы v1 и привести его к текущей схеме
+func decodeOrderCreateV1(header map[string][]string, payload []byte) (*domain.OrderCreateRequest, error) {
+	var request orderCreateRequestV1
+	if err := codec.Decode(header, payload, &request); err != nil {
+		return nil, err
+	}
+
+	if len(request.ShardKey) == 0 {
+		request.ShardKey = request.ShardKeyV1
+	}
+
+	return &request.OrderCreateRequest, nil
+}
+
+// registerDecoders зарегистрировать декодеры поддерживаемых версий схем subject
+func (c *Consumer) registerDecoders() {
+	c.orderCreateDecoders = NewDecoderRegistry[domain.OrderCreateRequest]()
+	c.orderCreateDecoders.Register(orderCreateSchemaV1, decodeOrderCreateV1)
+	c.orderCreateDecoders.Register(orderCreateSchemaV2, decodePayload[domain.OrderCreateRequest])
+
+	c.orderStatusUpdateDecoders = NewDecoderRegistry[domain.OrderStatusUpdateRequest]()
+	c.orderStatusUpdateDecoders.Register(envelope.LegacyVersion, decodePayload[domain.OrderStatusUpdateRequest])
+
+	c.orderCancelDecoders = NewDecoderRegistry[domain.OrderCancelRequest]()
+	c.orderCancelDecoders.Register(envelope.LegacyVersion, decodePayload[domain.OrderCancelRequest])
+}
+
+// annotateEnvelope записать описание сообщения в span обработчика
+func annotateEnvelope(ctx context.Context, meta envelope.Metadata) {
+	attributes := []attribute.KeyValue{attribute.Int("schema-version", meta.SchemaVersion)}
+	if len(meta.Source) != 0 {
+		attributes = append(attributes, attribute.String("source", meta.Source))
+	}
+	if !meta.ProducedAt.IsZero() {
+		attributes = append(attributes, attribute.String("produced-at", meta.ProducedAt.Format(time.RFC3339Nano)))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+}
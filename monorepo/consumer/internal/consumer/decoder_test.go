@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+)
+
+func TestOrderCreateDecoders(t *testing.T) {
+	consumer := newTestConsumer(nil)
+
+	v2Header := map[string][]string{}
+	envelope.Metadata{SchemaVersion: orderCreateSchemaV2, Source: "producer", ProducedAt: time.Now()}.SetHeader(v2Header)
+
+	wrapped, err := envelope.Wrap(envelope.Metadata{SchemaVersion: orderCreateSchemaV2},
+		[]byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","shard_key":"9"}`))
+	assert.NoError(t, err)
+
+	request := &domain.OrderCreateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", ShardKey: "9"}
+
+	protoHeader := map[string][]string{}
+	envelope.Metadata{SchemaVersion: orderCreateSchemaV2}.SetHeader(protoHeader)
+	protoData, err := codec.Encode(protoHeader, request, codec.ContentTypeProtobuf, codec.EncodingZstd)
+	assert.NoError(t, err)
+
+	msgpackHeader := map[string][]string{}
+	envelope.Metadata{SchemaVersion: orderCreateSchemaV2}.SetHeader(msgpackHeader)
+	msgpackData, err := codec.Encode(msgpackHeader, request, codec.ContentTypeMsgpack, codec.EncodingS2)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name             string
+		msg              *Msg
+		expectedVersion  int
+		expectedShardKey string
+		expectedErr      error
+	}{
+		{
+			name:             "V1 without envelope",
+			msg:              &Msg{Subject: SubjectOrderCreate, Data: []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","shardkey":"9"}`)},
+			expectedVersion:  orderCreateSchemaV1,
+			expectedShardKey: "9",
+		},
+		{
+			name: "V2 in header",
+			msg: &Msg{Subject: SubjectOrderCreate, Header: v2Header,
+				Data: []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","shard_key":"9"}`)},
+			expectedVersion:  orderCreateSchemaV2,
+			expectedShardKey: "9",
+		},
+		{
+			name:             "V2 in wrapper",
+			msg:              &Msg{Subject: SubjectOrderCreate, Data: wrapped},
+			expectedVersion:  orderCreateSchemaV2,
+			expectedShardKey: "9",
+		},
+		{
+			name:             "V2 protobuf with zstd",
+			msg:              &Msg{Subject: SubjectOrderCreate, Header: protoHeader, Data: protoData},
+			expectedVersion:  orderCreateSchemaV2,
+			expectedShardKey: "9",
+		},
+		{
+			name:             "V2 msgpack with s2",
+			msg:              &Msg{Subject: SubjectOrderCreate, Header: msgpackHeader, Data: msgpackData},
+			expectedVersion:  orderCreateSchemaV2,
+			expectedShardKey: "9",
+		},
+		{
+			name: "Unsupported content type",
+			msg: &Msg{Subject: SubjectOrderCreate, Header: map[string][]string{codec.HeaderContentType: {"text/xml"}},
+				Data: []byte(`{}`)},
+			expectedErr: codec.ErrUnsupportedContentType,
+		},
+		{
+			name: "Broken protobuf",
+			msg: &Msg{Subject: SubjectOrderCreate, Header: map[string][]string{codec.HeaderContentType: {codec.ContentTypeProtobuf}},
+				Data: []byte{0xff}},
+			expectedErr: codec.ErrMalformedPayload,
+		},
+		{
+			name: "Unsupported version",
+			msg: &Msg{Subject: SubjectOrderCreate, Header: map[string][]string{envelope.HeaderSchemaVersion: {"99"}},
+				Data: []byte(`{}`)},
+			expectedErr: ErrUnsupportedSchemaVersion,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			request, meta, err := consumer.orderCreateDecoders.Decode(test.msg)
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				assert.Equal(t, failurePermanent, classifyError(test.msg.Subject, err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedVersion, meta.SchemaVersion)
+			assert.Equal(t, "5d110e48-9e6b-4928-b436-14194b30d54f", request.OrderUid)
+			assert.Equal(t, test.expectedShardKey, request.ShardKey)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+var ErrDrainTimeout = errors.New("drain deadline exceeded")
+
+// acquire учесть начало обработки сообщений, false - consumer останавливается
+// и новые сообщения обрабатывать нельзя
+func (c *Consumer) acquire(n int) bool {
+	c.drainMu.Lock()
+	defer c.drainMu.Unlock()
+
+	if c.draining {
+		return false
+	}
+
+	c.inFlight.Add(1)
+	c.inFlightCount.Add(int64(n))
+	return true
+}
+
+// release учесть завершение обработки сообщений
+func (c *Consumer) release(n int) {
+	c.inFlightCount.Add(-int64(n))
+	c.inFlight.Done()
+}
+
+// rejectDraining вернуть сообщения, полученные во время остановки, для повторной доставки
+func rejectDraining(msgs ...jetstream.Msg) {
+	for _, msg := range msgs {
+		nak(msg, 0)
+	}
+}
+
+// Drain запретить обработку новых сообщений и дождаться завершения обработчиков,
+// после deadline обработчики отменяются, а неподтвержденные сообщения будут доставлены повторно
+func (c *Consumer) Drain(ctx context.Context) error {
+	c.drainMu.Lock()
+	c.draining = true
+	c.drainMu.Unlock()
+
+	if c.drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.drainTimeout)
+		defer cancel()
+	}
+
+	logger.Info("waiting for in-flight messages", zap.Int64("inFlight", c.inFlightCount.Load()))
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info("in-flight messages drained", zap.Duration("duration", time.Since(start)))
+		return nil
+	case <-ctx.Done():
+		inFlight := c.inFlightCount.Load()
+		c.cancelHandlers()
+		logger.Warn("drain deadline exceeded, in-flight handlers cancelled",
+			zap.Int64("inFlight", inFlight), zap.Duration("duration", time.Since(start)))
+		return errors.Wrapf(ErrDrainTimeout, "%d messages in flight", inFlight)
+	}
+}
+
+// Shutdown дождаться отправки и подтверждения буферизованных сообщений и закрыть соединение nats
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.cancelHandlers()
+
+	if err := c.conn.Drain(); err != nil {
+		return errors.Wrap(err, "fail to drain nats connection")
+	}
+
+	select {
+	case <-c.closed:
+		return nil
+	case <-ctx.Done():
+		c.conn.Close()
+		return errors.Wrap(ctx.Err(), "fail to wait nats connection drain")
+	}
+}
@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	testCases := []struct {
+		name        string
+		handleTime  time.Duration
+		expectedErr error
+	}{
+		{name: "In-flight handler finished", handleTime: 20 * time.Millisecond},
+		{name: "Drain deadline exceeded", handleTime: time.Second, expectedErr: ErrDrainTimeout},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			consumer := newTestConsumer(nil)
+			consumer.drainTimeout = 100 * time.Millisecond
+
+			assert.True(t, consumer.acquire(1))
+			go func() {
+				defer consumer.release(1)
+
+				select {
+				case <-time.After(test.handleTime):
+				case <-consumer.handlerCtx.Done():
+				}
+			}()
+
+			err := consumer.Drain(context.Background())
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				assert.Error(t, consumer.handlerCtx.Err())
+			} else {
+				assert.NoError(t, err)
+				assert.NoError(t, consumer.handlerCtx.Err())
+			}
+
+			// после начала остановки новые сообщения не обрабатываются
+			assert.False(t, consumer.acquire(1))
+
+			msg := &testMsg{subject: SubjectOrderCreate}
+			rejectDraining(msg)
+			assert.True(t, msg.naked)
+		})
+	}
+}
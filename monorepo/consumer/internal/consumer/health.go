@@ -0,0 +1,43 @@
+package consumer
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNatsNotConnected = errors.New("nats is not connected")
+	ErrConsumerNotBound = errors.New("jetstream consumer is not bound")
+	ErrConsumerDraining = errors.New("consumer is draining")
+)
+
+// CheckNats проверить состояние соединения с nats
+func (c *Consumer) CheckNats(_ context.Context) error {
+	if c.conn == nil || !c.conn.IsConnected() {
+		status := "closed"
+		if c.conn != nil {
+			status = c.conn.Status().String()
+		}
+		return errors.Wrap(ErrNatsNotConnected, status)
+	}
+	return nil
+}
+
+// CheckConsumer проверить, что durable consumer существует на сервере и consumer принимает сообщения
+func (c *Consumer) CheckConsumer(ctx context.Context) error {
+	c.drainMu.Lock()
+	draining := c.draining
+	c.drainMu.Unlock()
+	if draining {
+		return ErrConsumerDraining
+	}
+
+	if c.consumer == nil {
+		return ErrConsumerNotBound
+	}
+
+	if _, err := c.consumer.Info(ctx); err != nil {
+		return errors.Wrap(ErrConsumerNotBound, err.Error())
+	}
+	return nil
+}
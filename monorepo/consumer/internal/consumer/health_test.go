@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"context"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckConsumer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		consumer jetstream.Consumer
+		draining bool
+		errMsg   string
+	}{
+		{
+			name:     "OK",
+			consumer: &testInfoConsumer{info: &jetstream.ConsumerInfo{Name: "ordersconsumer"}},
+		},
+		{
+			name:     "Consumer deleted",
+			consumer: &testInfoConsumer{err: errors.New("nats: consumer not found")},
+			errMsg:   "nats: consumer not found: jetstream consumer is not bound",
+		},
+		{
+			name:   "Consumer not created",
+			errMsg: "jetstream consumer is not bound",
+		},
+		{
+			name:     "Draining",
+			consumer: &testInfoConsumer{info: &jetstream.ConsumerInfo{Name: "ordersconsumer"}},
+			draining: true,
+			errMsg:   "consumer is draining",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestConsumer(nil)
+			c.consumer = test.consumer
+			c.draining = test.draining
+
+			err := c.CheckConsumer(context.Background())
+			if test.errMsg != "" {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckNats(t *testing.T) {
+	c := newTestConsumer(nil)
+	assert.EqualError(t, c.CheckNats(context.Background()), "closed: nats is not connected")
+}
@@ -0,0 +1,45 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+// pollConsumerInfo публиковать в метрики состояние durable consumer до отмены контекста
+func (c *Consumer) pollConsumerInfo(ctx context.Context) error {
+	if c.infoPollInterval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(c.infoPollInterval)
+	defer ticker.Stop()
+
+	for {
+		c.reportConsumerInfo(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reportConsumerInfo запросить состояние durable consumer и обновить метрики
+func (c *Consumer) reportConsumerInfo(ctx context.Context) {
+	info, err := c.consumer.Info(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("fail to get consumer info", zap.Error(err))
+		}
+		return
+	}
+
+	metrics.JetStreamConsumerNumPending.WithLabelValues(info.Name).Set(float64(info.NumPending))
+	metrics.JetStreamConsumerNumAckPending.WithLabelValues(info.Name).Set(float64(info.NumAckPending))
+	metrics.JetStreamConsumerNumRedelivered.WithLabelValues(info.Name).Set(float64(info.NumRedelivered))
+	metrics.JetStreamConsumerNumWaiting.WithLabelValues(info.Name).Set(float64(info.NumWaiting))
+}
@@ -0,0 +1,45 @@
+package consumer
+
+import (
+	"context"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+// testInfoConsumer durable consumer, который возвращает заданное состояние
+type testInfoConsumer struct {
+	jetstream.Consumer
+	info *jetstream.ConsumerInfo
+	err  error
+}
+
+func (c *testInfoConsumer) Info(ctx context.Context) (*jetstream.ConsumerInfo, error) {
+	return c.info, c.err
+}
+
+func TestReportConsumerInfo(t *testing.T) {
+	c := newTestConsumer(nil)
+	c.consumer = &testInfoConsumer{info: &jetstream.ConsumerInfo{
+		Name:           "ordersconsumer",
+		NumPending:     10,
+		NumAckPending:  3,
+		NumRedelivered: 1,
+		NumWaiting:     2,
+	}}
+	c.reportConsumerInfo(context.Background())
+
+	assert.Equal(t, float64(10), testutil.ToFloat64(metrics.JetStreamConsumerNumPending.WithLabelValues("ordersconsumer")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.JetStreamConsumerNumAckPending.WithLabelValues("ordersconsumer")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.JetStreamConsumerNumRedelivered.WithLabelValues("ordersconsumer")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.JetStreamConsumerNumWaiting.WithLabelValues("ordersconsumer")))
+
+	// ошибка nats не сбрасывает последнее известное состояние
+	c.consumer = &testInfoConsumer{err: errors.New("nats: timeout")}
+	c.reportConsumerInfo(context.Background())
+
+	assert.Equal(t, float64(10), testutil.ToFloat64(metrics.JetStreamConsumerNumPending.WithLabelValues("ordersconsumer")))
+}
@@ -0,0 +1,135 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+	"wb_test_task/consumer/internal/config"
+)
+
+// jsonEnum перечисление jetstream, разбираемое из строкового значения
+type jsonEnum interface {
+	UnmarshalJSON(data []byte) error
+}
+
+// parseEnum разобрать значение перечисления jetstream из конфига, пустое значение -
+// значение по умолчанию сервера
+func parseEnum(name, value string, enum jsonEnum) error {
+	if len(value) == 0 {
+		return nil
+	}
+
+	if err := enum.UnmarshalJSON([]byte(strconv.Quote(value))); err != nil {
+		return errors.Errorf("invalid %s %q", name, value)
+	}
+	return nil
+}
+
+// streamConfig настройки стрима из конфига
+func streamConfig(cfg config.NatsConsumer) (jetstream.StreamConfig, error) {
+	streamCfg := jetstream.StreamConfig{
+		Name:       cfg.StreamName,
+		Subjects:   cfg.Subjects,
+		MaxAge:     time.Duration(cfg.Stream.MaxAgeSecond) * time.Second,
+		MaxBytes:   cfg.Stream.MaxBytes,
+		Replicas:   cfg.Stream.Replicas,
+		Duplicates: time.Duration(cfg.Stream.DuplicateWindowSecond) * time.Second,
+	}
+
+	if err := parseEnum("stream retention", cfg.Stream.Retention, &streamCfg.Retention); err != nil {
+		return jetstream.StreamConfig{}, err
+	}
+	if err := parseEnum("stream storage", cfg.Stream.Storage, &streamCfg.Storage); err != nil {
+		return jetstream.StreamConfig{}, err
+	}
+
+	return streamCfg, nil
+}
+
+// durableName имя durable consumer, по умолчанию <stream_name>consumer
+func durableName(cfg config.NatsConsumer) string {
+	if len(cfg.Durable.Name) != 0 {
+		return cfg.Durable.Name
+	}
+	return fmt.Sprintf("%sconsumer", cfg.StreamName)
+}
+
+// consumerConfig настройки durable consumer из конфига
+func consumerConfig(cfg config.NatsConsumer) (jetstream.ConsumerConfig, error) {
+	consumerCfg := jetstream.ConsumerConfig{
+		Durable:       durableName(cfg),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       time.Duration(cfg.Durable.AckWaitSecond) * time.Second,
+		MaxDeliver:    cfg.Durable.MaxDeliver,
+		MaxAckPending: cfg.Durable.MaxAckPending,
+	}
+
+	for _, delay := range cfg.Durable.BackoffMs {
+		consumerCfg.BackOff = append(consumerCfg.BackOff, time.Duration(delay)*time.Millisecond)
+	}
+	if len(consumerCfg.BackOff) != 0 && consumerCfg.MaxDeliver > 0 && consumerCfg.MaxDeliver <= len(consumerCfg.BackOff) {
+		return jetstream.ConsumerConfig{}, errors.Errorf("durable max_deliver %d must be greater than the number of backoff delays %d",
+			consumerCfg.MaxDeliver, len(consumerCfg.BackOff))
+	}
+
+	if err := parseEnum("durable deliver_policy", cfg.Durable.DeliverPolicy, &consumerCfg.DeliverPolicy); err != nil {
+		return jetstream.ConsumerConfig{}, err
+	}
+
+	switch consumerCfg.DeliverPolicy {
+	case jetstream.DeliverByStartSequencePolicy:
+		if cfg.Durable.OptStartSeq == 0 {
+			return jetstream.ConsumerConfig{}, errors.New("durable opt_start_seq is required for by_start_sequence deliver policy")
+		}
+		consumerCfg.OptStartSeq = cfg.Durable.OptStartSeq
+	case jetstream.DeliverByStartTimePolicy:
+		startTime, err := time.Parse(time.RFC3339, cfg.Durable.OptStartTime)
+		if err != nil {
+			return jetstream.ConsumerConfig{}, errors.Errorf("invalid durable opt_start_time %q", cfg.Durable.OptStartTime)
+		}
+		consumerCfg.OptStartTime = &startTime
+	}
+
+	// filter_subjects поддерживается с nats-server 2.10, один subject передается как filter_subject
+	switch len(cfg.Durable.FilterSubjects) {
+	case 0:
+	case 1:
+		consumerCfg.FilterSubject = cfg.Durable.FilterSubjects[0]
+	default:
+		consumerCfg.FilterSubjects = cfg.Durable.FilterSubjects
+	}
+
+	return consumerCfg, nil
+}
+
+// setupStream создать или обновить стрим, либо подключиться к существующему стриму
+// при bind: true. Вернуть стрим и его subject
+func setupStream(ctx context.Context, js jetstream.JetStream, cfg config.NatsConsumer) (jetstream.Stream, []string, error) {
+	if cfg.Stream.Bind {
+		stream, err := js.Stream(ctx, cfg.StreamName)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "fail to bind to stream %s", cfg.StreamName)
+		}
+
+		subjects := stream.CachedInfo().Config.Subjects
+		logger.Info("bound to existing stream", zap.String("stream", cfg.StreamName), zap.Strings("subjects", subjects))
+		return stream, subjects, nil
+	}
+
+	streamCfg, err := streamConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stream, err := js.CreateOrUpdateStream(ctx, streamCfg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fail to create stream")
+	}
+
+	return stream, cfg.Subjects, nil
+}
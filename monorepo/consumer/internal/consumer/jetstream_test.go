@@ -0,0 +1,130 @@
+package consumer
+
+import (
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/config"
+)
+
+func TestStreamConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		stream      config.Stream
+		expected    jetstream.StreamConfig
+		expectedErr bool
+	}{
+		{
+			name: "Full config",
+			stream: config.Stream{Retention: "workqueue", MaxAgeSecond: 3600, MaxBytes: 1024, Storage: "memory",
+				Replicas: 3, DuplicateWindowSecond: 60},
+			expected: jetstream.StreamConfig{Name: "orders", Subjects: []string{"order.create"},
+				Retention: jetstream.WorkQueuePolicy, MaxAge: time.Hour, MaxBytes: 1024,
+				Storage: jetstream.MemoryStorage, Replicas: 3, Duplicates: time.Minute},
+		},
+		{
+			name:     "Server defaults",
+			expected: jetstream.StreamConfig{Name: "orders", Subjects: []string{"order.create"}},
+		},
+		{
+			name:        "Invalid retention",
+			stream:      config.Stream{Retention: "forever"},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid storage",
+			stream:      config.Stream{Storage: "disk"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			streamCfg, err := streamConfig(config.NatsConsumer{
+				StreamName: "orders",
+				Subjects:   []string{"order.create"},
+				Stream:     test.stream,
+			})
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, streamCfg)
+		})
+	}
+}
+
+func TestConsumerConfig(t *testing.T) {
+	startTime := time.Date(2023, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		durable     config.Durable
+		expected    jetstream.ConsumerConfig
+		expectedErr bool
+	}{
+		{
+			name: "Full config",
+			durable: config.Durable{Name: "orders-worker", AckWaitSecond: 10, MaxDeliver: 5, BackoffMs: []int{1000, 5000},
+				MaxAckPending: 100, DeliverPolicy: "new", FilterSubjects: []string{"order.create", "order.cancel"}},
+			expected: jetstream.ConsumerConfig{Durable: "orders-worker", AckPolicy: jetstream.AckExplicitPolicy,
+				AckWait: 10 * time.Second, MaxDeliver: 5, BackOff: []time.Duration{time.Second, 5 * time.Second},
+				MaxAckPending: 100, DeliverPolicy: jetstream.DeliverNewPolicy,
+				FilterSubjects: []string{"order.create", "order.cancel"}},
+		},
+		{
+			name:    "Default durable name and single filter subject",
+			durable: config.Durable{FilterSubjects: []string{"order.create"}},
+			expected: jetstream.ConsumerConfig{Durable: "ordersconsumer", AckPolicy: jetstream.AckExplicitPolicy,
+				FilterSubject: "order.create"},
+		},
+		{
+			name:    "Deliver by start sequence",
+			durable: config.Durable{DeliverPolicy: "by_start_sequence", OptStartSeq: 42},
+			expected: jetstream.ConsumerConfig{Durable: "ordersconsumer", AckPolicy: jetstream.AckExplicitPolicy,
+				DeliverPolicy: jetstream.DeliverByStartSequencePolicy, OptStartSeq: 42},
+		},
+		{
+			name:    "Deliver by start time",
+			durable: config.Durable{DeliverPolicy: "by_start_time", OptStartTime: "2023-11-26T06:22:19Z"},
+			expected: jetstream.ConsumerConfig{Durable: "ordersconsumer", AckPolicy: jetstream.AckExplicitPolicy,
+				DeliverPolicy: jetstream.DeliverByStartTimePolicy, OptStartTime: &startTime},
+		},
+		{
+			name:        "Start sequence is missing",
+			durable:     config.Durable{DeliverPolicy: "by_start_sequence"},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid start time",
+			durable:     config.Durable{DeliverPolicy: "by_start_time", OptStartTime: "yesterday"},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid deliver policy",
+			durable:     config.Durable{DeliverPolicy: "oldest"},
+			expectedErr: true,
+		},
+		{
+			name:        "Max deliver does not cover backoff",
+			durable:     config.Durable{MaxDeliver: 2, BackoffMs: []int{1000, 5000}},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			consumerCfg, err := consumerConfig(config.NatsConsumer{StreamName: "orders", Durable: test.durable})
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, consumerCfg)
+		})
+	}
+}
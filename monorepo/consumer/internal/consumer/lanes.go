@@ -0,0 +1,233 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"hash/fnv"
+	"strings"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+	"wb_test_task/libs/model"
+)
+
+// LaneKey поле сообщения, по которому сообщения распределяются по lane
+type LaneKey string
+
+const (
+	// LaneKeyOrderUid события одного заказа обрабатываются по порядку
+	LaneKeyOrderUid LaneKey = "order_uid"
+	// LaneKeyCustomerID заказы одного покупателя создаются по порядку. customer_id передается только
+	// в order.create, поэтому ключ допустим, только если consumer не получает событий существующего заказа
+	LaneKeyCustomerID LaneKey = "customer_id"
+)
+
+// ParseLaneKey разобрать ключ lane из конфига, по умолчанию order_uid
+func ParseLaneKey(value string) (LaneKey, error) {
+	switch key := LaneKey(value); key {
+	case "", LaneKeyOrderUid:
+		return LaneKeyOrderUid, nil
+	case LaneKeyCustomerID:
+		return key, nil
+	default:
+		return "", errors.Errorf("unknown lane key %q", value)
+	}
+}
+
+// lanes очереди сообщений, каждую очередь обрабатывает один обработчик по порядку.
+// Сообщения с одним ключом всегда попадают в одну очередь
+type lanes struct {
+	key    LaneKey
+	queues []chan jetstream.Msg
+}
+
+func newLanes(cfg config.Lanes) (*lanes, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	key, err := ParseLaneKey(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Count <= 0 {
+		return nil, errors.Errorf("lanes count must be positive, got %d", cfg.Count)
+	}
+
+	queues := make([]chan jetstream.Msg, cfg.Count)
+	for i := range queues {
+		queues[i] = make(chan jetstream.Msg, cfg.Buffer)
+	}
+
+	return &lanes{key: key, queues: queues}, nil
+}
+
+// checkSubjects проверить, что ключ lane есть во всех получаемых subject: события существующего
+// заказа без customer_id попали бы в другую lane и обогнали бы создание заказа
+func (l *lanes) checkSubjects(subjects []string) error {
+	if l.key != LaneKeyCustomerID {
+		return nil
+	}
+
+	for _, subject := range subjects {
+		for _, lifecycle := range lifecycleSubjects {
+			if overlapTokens(strings.Split(subject, subjectSeparator), strings.Split(lifecycle, subjectSeparator)) {
+				return errors.Errorf("lane key %q is not supported for subject %s: %s does not carry it",
+					l.key, subject, lifecycle)
+			}
+		}
+	}
+
+	return nil
+}
+
+// index номер очереди сообщения
+func (l *lanes) index(msg *Msg) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(messageKey(msg, l.key)))
+	return int(hash.Sum32() % uint32(len(l.queues)))
+}
+
+// messageKey ключ сообщения: значение поля key, иначе order_uid. Сообщения без ключа
+// (сломанные или неизвестные) распределяются по subject
+func messageKey(msg *Msg, key LaneKey) string {
+	_, payload, err := envelope.Open(msg.Header, msg.Data)
+	if err != nil {
+		return msg.Subject
+	}
+
+	var fields laneFields
+	if err := codec.Decode(msg.Header, payload, &fields); err != nil {
+		return msg.Subject
+	}
+
+	if key == LaneKeyCustomerID && len(fields.CustomerID) != 0 {
+		return fields.CustomerID
+	}
+	if len(fields.OrderUid) != 0 {
+		return fields.OrderUid
+	}
+	return msg.Subject
+}
+
+// laneFields поля сообщения, по которым выбирается lane
+type laneFields struct {
+	OrderUid   string `json:"order_uid"`
+	CustomerID string `json:"customer_id"`
+}
+
+// UnmarshalProto разобрать поля lane из protobuf, в protobuf передается только order.create
+func (f *laneFields) UnmarshalProto(data []byte) error {
+	order, err := model.UnmarshalOrderCreateRequest(data)
+	if err != nil {
+		return err
+	}
+	f.OrderUid = order.OrderUid
+	f.CustomerID = order.CustomerID
+	return nil
+}
+
+// startLanes запустить обработчики очередей, обработчики завершаются после отмены handlerCtx
+func (c *Consumer) startLanes() {
+	for i, queue := range c.lanes.queues {
+		id, queue := i, queue
+		go func() {
+			logger.Info("nats consumer lane is starting", zap.Int("lane", id))
+			for {
+				select {
+				case <-c.handlerCtx.Done():
+					return
+				case msg := <-queue:
+					c.processLaneMessage(msg)
+					c.release(1)
+				}
+			}
+		}()
+	}
+}
+
+// processLaneMessage обработать сообщение lane. Временная ошибка повторяется внутри lane с backoff
+// вместо nak: повторная доставка пришла бы после следующих сообщений того же ключа, и они обогнали бы
+// повтор. Повторы считаются вместе с доставками jetstream до max_deliver, ack wait продлевается heartbeat
+func (c *Consumer) processLaneMessage(msg jetstream.Msg) {
+	if c.workers.heartbeat > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go heartbeat(msg, c.workers.heartbeat, done)
+	}
+
+	numDelivered := deliveryCount(msg)
+	for {
+		ctx, cancel := c.messageContext()
+		err := c.OnMessage(ctx, newMsg(msg))
+		if !c.retryInLane(ctx, msg, err, numDelivered) {
+			c.settleMessage(ctx, msg, err, numDelivered)
+			cancel()
+			return
+		}
+		cancel()
+
+		logger.Warn("fail to handle message, retry in lane", zap.String("subject", msg.Subject()),
+			zap.Uint64("numDelivered", numDelivered), zap.Error(err))
+		metrics.MessagesLaneRetried.WithLabelValues(msg.Subject()).Inc()
+
+		select {
+		case <-c.handlerCtx.Done():
+			// обработчики остановлены, сообщение будет доставлено повторно
+			nak(msg, 0)
+			return
+		case <-time.After(c.retryDelay(numDelivered)):
+		}
+		numDelivered++
+	}
+}
+
+// retryInLane повторить ли обработку в lane: временная ошибка или истекшее время обработки,
+// пока не исчерпаны попытки доставки
+func (c *Consumer) retryInLane(ctx context.Context, msg jetstream.Msg, err error, numDelivered uint64) bool {
+	if err == nil || errors.Is(err, ErrUnknownSubject) || numDelivered >= uint64(c.retryCfg.MaxDeliver) {
+		return false
+	}
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) || classifyError(msg.Subject(), err) == failureTransient
+}
+
+// dispatch передать сообщение в очередь его ключа. Если очередь заполнена, получение
+// новых сообщений ждет обработчика очереди
+func (c *Consumer) dispatch(msg jetstream.Msg) {
+	if !c.acquire(1) {
+		rejectDraining(msg)
+		return
+	}
+
+	queue := c.lanes.queues[c.lanes.index(newMsg(msg))]
+	select {
+	case queue <- msg:
+	case <-c.handlerCtx.Done():
+		// обработчики остановлены, сообщение будет доставлено повторно
+		c.release(1)
+		nak(msg, 0)
+	}
+}
+
+// consumeLanes получать сообщения одной подпиской до отмены контекста, чтобы порядок
+// доставки сообщений одного ключа сохранялся в очереди
+func (c *Consumer) consumeLanes(ctx context.Context) error {
+	c.startLanes()
+
+	cc, err := c.consumer.Consume(func(msg jetstream.Msg) {
+		received(msg)
+		c.dispatch(msg)
+	})
+	if err != nil {
+		return errors.Wrap(err, "fail to start consume")
+	}
+	defer cc.Stop()
+
+	<-ctx.Done()
+	return nil
+}
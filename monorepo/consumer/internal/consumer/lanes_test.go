@@ -0,0 +1,251 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	mock_consumer "wb_test_task/consumer/internal/consumer/mocks"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+	"wb_test_task/libs/model"
+)
+
+func TestMessageKey(t *testing.T) {
+	wrapped, _ := envelope.Wrap(envelope.Metadata{SchemaVersion: 2},
+		[]byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","customer_id":"test"}`))
+	protoData, _ := model.MarshalOrderCreateRequest(
+		&model.Order{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", CustomerID: "test"})
+
+	testCases := []struct {
+		name           string
+		msg            *Msg
+		key            LaneKey
+		expectedResult string
+	}{
+		{
+			name:           "Order uid",
+			msg:            &Msg{Subject: SubjectOrderCreate, Data: wrapped},
+			key:            LaneKeyOrderUid,
+			expectedResult: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		},
+		{
+			name:           "Customer id",
+			msg:            &Msg{Subject: SubjectOrderCreate, Data: wrapped},
+			key:            LaneKeyCustomerID,
+			expectedResult: "test",
+		},
+		{
+			name: "Status update",
+			msg: &Msg{Subject: SubjectOrderStatusUpdate,
+				Data: []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","status":"paid"}`)},
+			key:            LaneKeyOrderUid,
+			expectedResult: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		},
+		{
+			name: "Protobuf message",
+			msg: &Msg{Subject: SubjectOrderCreate,
+				Header: map[string][]string{codec.HeaderContentType: {codec.ContentTypeProtobuf}}, Data: protoData},
+			key:            LaneKeyCustomerID,
+			expectedResult: "test",
+		},
+		{
+			name:           "Broken message",
+			msg:            &Msg{Subject: SubjectOrderCancel, Data: []byte(`error`)},
+			key:            LaneKeyOrderUid,
+			expectedResult: SubjectOrderCancel,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedResult, messageKey(test.msg, test.key))
+		})
+	}
+}
+
+func TestLaneIndexOrderEvents(t *testing.T) {
+	l, err := newLanes(config.Lanes{Enabled: true, Count: 16, Buffer: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create, _ := envelope.Wrap(envelope.Metadata{SchemaVersion: 2},
+		[]byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","customer_id":"test"}`))
+	events := []*Msg{
+		{Subject: SubjectOrderStatusUpdate, Data: []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","status":"paid"}`)},
+		{Subject: SubjectOrderCancel, Data: []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","reason":"test"}`)},
+	}
+
+	// создание, смена статуса и отмена одного заказа обрабатываются одной lane
+	lane := l.index(&Msg{Subject: SubjectOrderCreate, Data: create})
+	for _, event := range events {
+		assert.Equal(t, lane, l.index(event), event.Subject)
+	}
+}
+
+func TestNewLanes(t *testing.T) {
+	l, err := newLanes(config.Lanes{})
+	assert.NoError(t, err)
+	assert.Nil(t, l)
+
+	l, err = newLanes(config.Lanes{Enabled: true, Count: 4, Buffer: 2})
+	assert.NoError(t, err)
+	assert.Len(t, l.queues, 4)
+	assert.Equal(t, LaneKeyOrderUid, l.key)
+
+	l, err = newLanes(config.Lanes{Enabled: true, Count: 4, Key: "customer_id"})
+	assert.NoError(t, err)
+	assert.Equal(t, LaneKeyCustomerID, l.key)
+
+	_, err = newLanes(config.Lanes{Enabled: true, Count: 4, Key: "track_number"})
+	assert.EqualError(t, err, `unknown lane key "track_number"`)
+
+	_, err = newLanes(config.Lanes{Enabled: true, Count: 0})
+	assert.EqualError(t, err, "lanes count must be positive, got 0")
+}
+
+func TestLanesCheckSubjects(t *testing.T) {
+	testCases := []struct {
+		name          string
+		key           LaneKey
+		subjects      []string
+		expectedError string
+	}{
+		{
+			name:     "OK. Order uid",
+			key:      LaneKeyOrderUid,
+			subjects: []string{"order.>"},
+		},
+		{
+			name:     "OK. Customer id, only order create",
+			key:      LaneKeyCustomerID,
+			subjects: []string{SubjectOrderCreate},
+		},
+		{
+			name:          "Error. Customer id with status update",
+			key:           LaneKeyCustomerID,
+			subjects:      []string{SubjectOrderCreate, SubjectOrderStatusUpdate},
+			expectedError: `lane key "customer_id" is not supported for subject order.status.update: order.status.update does not carry it`,
+		},
+		{
+			name:          "Error. Customer id with wildcard",
+			key:           LaneKeyCustomerID,
+			subjects:      []string{"order.>"},
+			expectedError: `lane key "customer_id" is not supported for subject order.>: order.status.update does not carry it`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			l := &lanes{key: test.key}
+			err := l.checkSubjects(test.subjects)
+			if len(test.expectedError) != 0 {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	first := &domain.OrderCreateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"}
+	second := &domain.OrderCreateRequest{OrderUid: "9c1e0f0e-6f7a-4a7e-9a63-3e2a6c5b1d11"}
+	statusUpdate := &domain.OrderStatusUpdateRequest{OrderUid: first.OrderUid, Status: domain.StatusPaid}
+
+	firstData, _ := json.Marshal(first)
+	secondData, _ := json.Marshal(second)
+	statusUpdateData, _ := json.Marshal(statusUpdate)
+
+	var created atomic.Bool
+	service := mock_consumer.NewMockorderService(ct)
+	// статус заказа обновляется только после долгой записи заказа, заказ другого ключа не ждет
+	service.EXPECT().Create(gomock.Any(), first).
+		DoAndReturn(func(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+			time.Sleep(50 * time.Millisecond)
+			created.Store(true)
+			return &model.Order{}, nil
+		})
+	service.EXPECT().UpdateStatus(gomock.Any(), statusUpdate).
+		DoAndReturn(func(ctx context.Context, request *domain.OrderStatusUpdateRequest) error {
+			assert.True(t, created.Load(), "status updated before order was created")
+			return nil
+		})
+	service.EXPECT().Create(gomock.Any(), second).Return(&model.Order{}, nil)
+
+	consumer := newTestConsumer(service)
+	consumer.lanes, _ = newLanes(config.Lanes{Enabled: true, Count: 4, Buffer: 1})
+	consumer.startLanes()
+
+	msgs := []*testMsg{
+		{subject: SubjectOrderCreate, data: firstData},
+		{subject: SubjectOrderCreate, data: secondData},
+		{subject: SubjectOrderStatusUpdate, data: statusUpdateData},
+	}
+	for _, msg := range msgs {
+		consumer.dispatch(msg)
+	}
+
+	assert.NoError(t, consumer.Drain(context.Background()))
+	for _, msg := range msgs {
+		assert.True(t, msg.acked)
+	}
+	consumer.cancelHandlers()
+}
+
+func TestDispatchTransientFailure(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	create := &domain.OrderCreateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"}
+	statusUpdate := &domain.OrderStatusUpdateRequest{OrderUid: create.OrderUid, Status: domain.StatusPaid}
+
+	createData, _ := json.Marshal(create)
+	statusUpdateData, _ := json.Marshal(statusUpdate)
+
+	var created atomic.Bool
+	service := mock_consumer.NewMockorderService(ct)
+	// временная ошибка повторяется в lane, смена статуса того же заказа ждет повтора
+	gomock.InOrder(
+		service.EXPECT().Create(gomock.Any(), create).Return(&model.Order{}, errors.New("connection refused")),
+		service.EXPECT().Create(gomock.Any(), create).
+			DoAndReturn(func(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+				created.Store(true)
+				return &model.Order{}, nil
+			}),
+	)
+	service.EXPECT().UpdateStatus(gomock.Any(), statusUpdate).
+		DoAndReturn(func(ctx context.Context, request *domain.OrderStatusUpdateRequest) error {
+			assert.True(t, created.Load(), "status updated before order was created")
+			return nil
+		})
+
+	consumer := newTestConsumer(service)
+	consumer.lanes, _ = newLanes(config.Lanes{Enabled: true, Count: 4, Buffer: 1})
+	consumer.startLanes()
+
+	msgs := []*testMsg{
+		{subject: SubjectOrderCreate, data: createData},
+		{subject: SubjectOrderStatusUpdate, data: statusUpdateData},
+	}
+	for _, msg := range msgs {
+		consumer.dispatch(msg)
+	}
+
+	assert.NoError(t, consumer.Drain(context.Background()))
+	for _, msg := range msgs {
+		assert.True(t, msg.acked)
+		assert.False(t, msg.naked)
+	}
+	consumer.cancelHandlers()
+}
@@ -0,0 +1,101 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/libs/tracing"
+)
+
+var ErrHandlerPanic = errors.New("handler panic")
+
+// RecoverMiddleware перехватить панику обработчика и вернуть ее как ошибку
+func RecoverMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *Msg) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("panic in message handler", zap.String("subject", msg.Subject),
+						zap.Any("panic", r), zap.Stack("stack"))
+					err = errors.Wrapf(ErrHandlerPanic, "%v", r)
+				}
+			}()
+
+			return next(ctx, msg)
+		}
+	}
+}
+
+// LoggingMiddleware логировать обработку сообщения
+func LoggingMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *Msg) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			logger.Debug("message handled", zap.String("subject", msg.Subject),
+				zap.Duration("duration", time.Since(start)), zap.Bool("success", err == nil))
+			return err
+		}
+	}
+}
+
+// TracingMiddleware создать span обработки сообщения, дочерний к span producer из заголовков
+func TracingMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *Msg) error {
+			ctx = tracing.Extract(ctx, msg.Header)
+			ctx, span := tracer.StartTrace(ctx, "nats-consumer-handle-"+msg.Subject)
+			span.SetAttributes(attribute.String("subject", msg.Subject))
+			defer span.End()
+
+			err := next(ctx, msg)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// MetricsMiddleware учитывать количество и длительность обработки сообщений
+func MetricsMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *Msg) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			result := "ok"
+			if err != nil {
+				result = classifyError(msg.Subject, err).String()
+				metrics.MessageFailures.WithLabelValues(msg.Subject, failureType(err)).Inc()
+			}
+			metrics.MessagesHandled.WithLabelValues(msg.Subject, result).Inc()
+			metrics.MessageHandleDuration.WithLabelValues(msg.Subject).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// TimeoutMiddleware ограничить время обработки сообщения, 0 - без ограничения
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		if timeout <= 0 {
+			return next
+		}
+
+		return func(ctx context.Context, msg *Msg) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			return next(ctx, msg)
+		}
+	}
+}
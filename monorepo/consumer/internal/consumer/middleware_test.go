@@ -0,0 +1,50 @@
+package consumer
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	handler := RecoverMiddleware()(func(ctx context.Context, msg *Msg) error {
+		panic("boom")
+	})
+
+	err := handler(context.Background(), &Msg{Subject: "order.create"})
+
+	assert.True(t, errors.Is(err, ErrHandlerPanic))
+	assert.Equal(t, failurePermanent, classifyError(SubjectOrderCreate, err))
+}
+
+func TestHandlerPanicMetrics(t *testing.T) {
+	consumer := newTestConsumer(nil)
+	consumer.router.Handle(SubjectOrderCreate, func(ctx context.Context, msg *Msg) error {
+		panic("boom")
+	})
+	failures := metrics.MessageFailures.WithLabelValues(SubjectOrderCreate, ErrHandlerPanic.Error())
+	handled := metrics.MessagesHandled.WithLabelValues(SubjectOrderCreate, failurePermanent.String())
+	failuresBefore, handledBefore := testutil.ToFloat64(failures), testutil.ToFloat64(handled)
+
+	err := consumer.OnMessage(context.Background(), &Msg{Subject: SubjectOrderCreate})
+
+	assert.True(t, errors.Is(err, ErrHandlerPanic))
+	assert.Equal(t, failuresBefore+1, testutil.ToFloat64(failures))
+	assert.Equal(t, handledBefore+1, testutil.ToFloat64(handled))
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	handler := TimeoutMiddleware(10 * time.Millisecond)(func(ctx context.Context, msg *Msg) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := handler(context.Background(), &Msg{Subject: "order.create"})
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, failureTransient, classifyError(SubjectOrderCreate, err))
+}
@@ -36,6 +36,20 @@ func (m *MockorderService) EXPECT() *MockorderServiceMockRecorder {
 	return m.recorder
 }
 
+// Cancel mocks base method.
+func (m *MockorderService) Cancel(ctx context.Context, request *domain.OrderCancelRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockorderServiceMockRecorder) Cancel(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockorderService)(nil).Cancel), ctx, request)
+}
+
 // Create mocks base method.
 func (m *MockorderService) Create(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -50,3 +64,32 @@ func (mr *MockorderServiceMockRecorder) Create(ctx, request interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockorderService)(nil).Create), ctx, request)
 }
+
+// CreateBatch mocks base method.
+func (m *MockorderService) CreateBatch(ctx context.Context, requests []*domain.OrderCreateRequest) ([]*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, requests)
+	ret0, _ := ret[0].([]*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockorderServiceMockRecorder) CreateBatch(ctx, requests interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockorderService)(nil).CreateBatch), ctx, requests)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockorderService) UpdateStatus(ctx context.Context, request *domain.OrderStatusUpdateRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockorderServiceMockRecorder) UpdateStatus(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockorderService)(nil).UpdateStatus), ctx, request)
+}
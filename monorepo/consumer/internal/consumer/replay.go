@@ -0,0 +1,202 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/config"
+)
+
+const (
+	defaultReplayBatchSize        = 100
+	defaultReplayProgressInterval = 10 * time.Second
+	replayFetchMaxWait            = time.Second
+)
+
+// ReplayOptions параметры повторной обработки сообщений стрима
+type ReplayOptions struct {
+	// StartSeq начать с сообщения с номером StartSeq, 0 - с начала стрима
+	StartSeq uint64
+	// StartTime начать с сообщений, записанных в стрим не раньше StartTime
+	StartTime time.Time
+	// Subjects обработать только сообщения этих subject, пусто - все subject стрима
+	Subjects         []string
+	BatchSize        int
+	ProgressInterval time.Duration
+}
+
+// Validate проверить параметры replay: начало задается номером сообщения или временем, но не обоими
+func (o ReplayOptions) Validate() error {
+	if o.StartSeq != 0 && !o.StartTime.IsZero() {
+		return errors.New("replay start sequence and start time can not be set together")
+	}
+	return nil
+}
+
+// ReplayReport итог повторной обработки сообщений
+type ReplayReport struct {
+	Processed uint64
+	Failed    uint64
+	// Failures количество ошибок по типу ошибки
+	Failures map[string]uint64
+	LastSeq  uint64
+	Duration time.Duration
+}
+
+// Throughput количество обработанных сообщений в секунду
+func (r ReplayReport) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Processed) / r.Duration.Seconds()
+}
+
+// NewReplay consumer для повторной обработки сообщений существующего стрима.
+// Durable consumer и dead-letter стрим не создаются
+func NewReplay(cfg config.NatsConsumer, service orderService) (*Consumer, error) {
+	nc, js, closed, err := connect(cfg)
+	if err != nil {
+		return &Consumer{}, err
+	}
+
+	stream, err := js.Stream(context.Background(), cfg.StreamName)
+	if err != nil {
+		return &Consumer{}, errors.Wrapf(err, "fail to get stream %s", cfg.StreamName)
+	}
+
+	c := &Consumer{
+		conn:         nc,
+		js:           js,
+		stream:       stream,
+		orderService: service,
+		router:       NewRouter(),
+		closed:       closed,
+	}
+	c.handlerCtx, c.cancelHandlers = context.WithCancel(context.Background())
+	c.registerDecoders()
+	c.registerHandlers(time.Duration(cfg.HandlerTimeoutMs) * time.Millisecond)
+
+	return c, nil
+}
+
+// replayConsumerConfig настройки ordered consumer для replay
+func replayConsumerConfig(opts ReplayOptions) jetstream.OrderedConsumerConfig {
+	consumerCfg := jetstream.OrderedConsumerConfig{
+		FilterSubjects: opts.Subjects,
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	}
+
+	switch {
+	case opts.StartSeq != 0:
+		consumerCfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		consumerCfg.OptStartSeq = opts.StartSeq
+	case !opts.StartTime.IsZero():
+		startTime := opts.StartTime
+		consumerCfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		consumerCfg.OptStartTime = &startTime
+	}
+
+	return consumerCfg
+}
+
+// Replay повторно обработать сообщения стрима теми же обработчиками, что и при чтении
+// durable consumer. Сообщения читает ephemeral ordered consumer до последнего сообщения,
+// которое было в стриме на момент запуска. Ошибки обработки учитываются в отчете
+// и не останавливают replay, dead-letter не используется
+func (c *Consumer) Replay(ctx context.Context, opts ReplayOptions) (ReplayReport, error) {
+	if err := opts.Validate(); err != nil {
+		return ReplayReport{}, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultReplayBatchSize
+	}
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = defaultReplayProgressInterval
+	}
+
+	consumerCfg := replayConsumerConfig(opts)
+	oc, err := c.stream.OrderedConsumer(ctx, consumerCfg)
+	if err != nil {
+		return ReplayReport{}, errors.Wrap(err, "fail to create ordered consumer")
+	}
+
+	logger.Info("replay is starting", zap.String("deliverPolicy", consumerCfg.DeliverPolicy.String()),
+		zap.Uint64("startSeq", opts.StartSeq), zap.Time("startTime", opts.StartTime), zap.Strings("subjects", opts.Subjects))
+
+	report := ReplayReport{Failures: map[string]uint64{}}
+	start := time.Now()
+	lastProgress := start
+	var total uint64
+
+	for {
+		select {
+		case <-ctx.Done():
+			report.Duration = time.Since(start)
+			return report, errors.Wrap(ctx.Err(), "replay interrupted")
+		default:
+		}
+
+		batch, err := oc.Fetch(opts.BatchSize, jetstream.FetchMaxWait(replayFetchMaxWait))
+		if err != nil {
+			report.Duration = time.Since(start)
+			return report, errors.Wrap(err, "fail to fetch messages")
+		}
+
+		received := 0
+		caughtUp := false
+		for msg := range batch.Messages() {
+			received++
+
+			meta, err := msg.Metadata()
+			if err == nil {
+				if total == 0 {
+					total = meta.NumPending + 1
+				}
+				report.LastSeq = meta.Sequence.Stream
+				caughtUp = meta.NumPending == 0
+			}
+
+			c.replayMessage(ctx, msg, meta, &report)
+		}
+		if err := batch.Error(); err != nil {
+			logger.Warn("fetch finished with error", zap.Int("received", received), zap.Error(err))
+		}
+
+		if received == 0 || caughtUp {
+			report.Duration = time.Since(start)
+			return report, nil
+		}
+
+		if time.Since(lastProgress) >= opts.ProgressInterval {
+			lastProgress = time.Now()
+			report.Duration = lastProgress.Sub(start)
+			logger.Info("replay progress", zap.Uint64("processed", report.Processed), zap.Uint64("total", total),
+				zap.Uint64("failed", report.Failed), zap.Uint64("lastSeq", report.LastSeq),
+				zap.Float64("messagesPerSecond", report.Throughput()))
+		}
+	}
+}
+
+// replayMessage обработать сообщение replay и учесть результат в отчете
+func (c *Consumer) replayMessage(ctx context.Context, msg jetstream.Msg, meta *jetstream.MsgMetadata, report *ReplayReport) {
+	report.Processed++
+
+	err := c.OnMessage(ctx, newMsg(msg))
+	if err == nil {
+		return
+	}
+
+	report.Failed++
+	errorType, errorMessage := describeError(err)
+	report.Failures[errorType]++
+
+	var seq uint64
+	if meta != nil {
+		seq = meta.Sequence.Stream
+	}
+	logger.Warn("fail to replay message", zap.Uint64("seq", seq), zap.String("subject", msg.Subject()),
+		zap.String("errorType", errorType), zap.String("error", errorMessage))
+}
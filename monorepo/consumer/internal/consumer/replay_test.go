@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	mock_consumer "wb_test_task/consumer/internal/consumer/mocks"
+	"wb_test_task/consumer/internal/domain"
+)
+
+func TestReplayConsumerConfig(t *testing.T) {
+	startTime := time.Date(2023, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		opts     ReplayOptions
+		expected jetstream.OrderedConsumerConfig
+	}{
+		{
+			name:     "From the beginning",
+			opts:     ReplayOptions{Subjects: []string{SubjectOrderCreate}},
+			expected: jetstream.OrderedConsumerConfig{FilterSubjects: []string{SubjectOrderCreate}},
+		},
+		{
+			name: "From sequence",
+			opts: ReplayOptions{StartSeq: 42},
+			expected: jetstream.OrderedConsumerConfig{DeliverPolicy: jetstream.DeliverByStartSequencePolicy,
+				OptStartSeq: 42},
+		},
+		{
+			name: "From time",
+			opts: ReplayOptions{StartTime: startTime},
+			expected: jetstream.OrderedConsumerConfig{DeliverPolicy: jetstream.DeliverByStartTimePolicy,
+				OptStartTime: &startTime},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, replayConsumerConfig(test.opts))
+		})
+	}
+}
+
+func TestReplayOptionsValidate(t *testing.T) {
+	startTime := time.Date(2023, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	assert.NoError(t, ReplayOptions{}.Validate())
+	assert.NoError(t, ReplayOptions{StartSeq: 42}.Validate())
+	assert.NoError(t, ReplayOptions{StartTime: startTime}.Validate())
+	assert.EqualError(t, ReplayOptions{StartSeq: 42, StartTime: startTime}.Validate(),
+		"replay start sequence and start time can not be set together")
+}
+
+func TestReplayMessage(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	service := mock_consumer.NewMockorderService(ct)
+	consumer := newTestConsumer(service)
+
+	data := []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f"}`)
+	service.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, nil)
+	service.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, errors.Wrap(domain.ErrOrderConflict, "fail"))
+
+	report := ReplayReport{Failures: map[string]uint64{}}
+	meta := &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Stream: 1}}
+
+	consumer.replayMessage(context.Background(), &testMsg{subject: SubjectOrderCreate, data: data}, meta, &report)
+	consumer.replayMessage(context.Background(), &testMsg{subject: SubjectOrderCreate, data: data}, meta, &report)
+	consumer.replayMessage(context.Background(), &testMsg{subject: "order.unknown", data: data}, meta, &report)
+
+	assert.Equal(t, uint64(3), report.Processed)
+	assert.Equal(t, uint64(2), report.Failed)
+	assert.Equal(t, uint64(1), report.Failures[domain.ErrOrderConflict.Error()])
+	assert.Equal(t, uint64(1), report.Failures[ErrUnknownSubject.Error()])
+
+	report.Duration = 2 * time.Second
+	assert.Equal(t, 1.5, report.Throughput())
+}
@@ -0,0 +1,130 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"time"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+)
+
+type failureKind int
+
+const (
+	// failureTransient временная ошибка (соединение, таймаут), сообщение можно повторить
+	failureTransient failureKind = iota
+	// failurePermanent постоянная ошибка, повтор не поможет
+	failurePermanent
+)
+
+func (k failureKind) String() string {
+	if k == failurePermanent {
+		return "permanent"
+	}
+	return "transient"
+}
+
+// permanentErrors ошибки, при которых сообщение сразу уходит в dead-letter
+var permanentErrors = []error{
+	ErrUnknownSubject,
+	ErrHandlerPanic,
+	ErrUnsupportedSchemaVersion,
+	envelope.ErrInvalidEnvelope,
+	codec.ErrUnsupportedContentType,
+	codec.ErrUnsupportedContentEncoding,
+	codec.ErrUnsupportedType,
+	codec.ErrMalformedPayload,
+	domain.ErrInvalidValue,
+	domain.ErrInvalidOrderValue,
+	domain.ErrOrderValidation,
+	domain.ErrOrderAlreadyExists,
+	domain.ErrOrderConflict,
+	domain.ErrInvalidOrderStatus,
+	domain.ErrInvalidStatusTransition,
+	// для событий существующего заказа временная ошибка, см. lifecycleSubjects
+	domain.ErrOrderDoesNotExists,
+	domain.ErrUserDoesNotExists,
+	domain.ErrInvalidUserID,
+}
+
+// lifecycleSubjects subject событий существующего заказа. Событие может прийти раньше order.create:
+// при нескольких подписках или fallback batch создание заказа обрабатывается параллельно
+var lifecycleSubjects = []string{SubjectOrderStatusUpdate, SubjectOrderCancel}
+
+// classifyError определить тип ошибки обработки сообщения subject. Для событий существующего
+// заказа отсутствие заказа временная ошибка: сообщение повторяется, пока заказ не будет создан
+func classifyError(subject string, err error) failureKind {
+	if errors.Is(err, domain.ErrOrderDoesNotExists) {
+		for _, lifecycle := range lifecycleSubjects {
+			if subject == lifecycle {
+				return failureTransient
+			}
+		}
+	}
+
+	for _, target := range permanentErrors {
+		if errors.Is(err, target) {
+			return failurePermanent
+		}
+	}
+
+	var (
+		syntaxErr    *json.SyntaxError
+		unmarshalErr *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalErr) {
+		return failurePermanent
+	}
+
+	// ошибки соединения, таймауты и все неизвестные ошибки считаются временными,
+	// после исчерпания попыток сообщение все равно попадет в dead-letter
+	return failureTransient
+}
+
+// knownErrors ошибки, по которым считаются метрики ошибок обработки, остальные учитываются как unknown
+var knownErrors = append([]error{
+	domain.ErrUserAlreadyExists,
+	context.DeadlineExceeded,
+	context.Canceled,
+}, permanentErrors...)
+
+// failureType тип ошибки обработки сообщения для метрик
+func failureType(err error) string {
+	for _, target := range knownErrors {
+		if errors.Is(err, target) {
+			return target.Error()
+		}
+	}
+
+	var (
+		syntaxErr    *json.SyntaxError
+		unmarshalErr *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &unmarshalErr) {
+		return "unmarshal error"
+	}
+
+	return "unknown"
+}
+
+// backoff вернуть задержку перед повторной доставкой сообщения
+func backoff(numDelivered uint64, base, max time.Duration) time.Duration {
+	if numDelivered == 0 {
+		numDelivered = 1
+	}
+
+	delay := base
+	for i := uint64(1); i < numDelivered; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
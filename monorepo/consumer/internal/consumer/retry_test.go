@@ -0,0 +1,170 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/common"
+	mock_consumer "wb_test_task/consumer/internal/consumer/mocks"
+	"wb_test_task/consumer/internal/domain"
+)
+
+func TestClassifyError(t *testing.T) {
+	var data map[string]any
+	unmarshalErr := json.Unmarshal([]byte(`error`), &data)
+
+	testCases := []struct {
+		name           string
+		subject        string
+		err            error
+		expectedResult failureKind
+	}{
+		{
+			name:           "Order already exists",
+			err:            errors.Wrap(common.WrapError{Err: domain.ErrOrderAlreadyExists, Msg: "hint"}, "fail to create order"),
+			expectedResult: failurePermanent,
+		},
+		{
+			name:           "Invalid value",
+			err:            common.WrapError{Err: domain.ErrInvalidValue, Msg: "invalid input syntax"},
+			expectedResult: failurePermanent,
+		},
+		{
+			name:           "Unmarshal error",
+			err:            errors.Wrap(unmarshalErr, "fail to unmarshal msg"),
+			expectedResult: failurePermanent,
+		},
+		{
+			name:           "Unknown subject",
+			err:            errors.Wrap(ErrUnknownSubject, "order.unknown"),
+			expectedResult: failurePermanent,
+		},
+		{
+			name:           "Connection error",
+			err:            common.WrapError{Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, Msg: "fail to create transaction"},
+			expectedResult: failureTransient,
+		},
+		{
+			name:           "Timeout",
+			err:            errors.Wrap(context.DeadlineExceeded, "fail to create order"),
+			expectedResult: failureTransient,
+		},
+		{
+			name:           "Order does not exists. Status update",
+			subject:        SubjectOrderStatusUpdate,
+			err:            errors.Wrap(common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: "hint"}, "fail to update order status"),
+			expectedResult: failureTransient,
+		},
+		{
+			name:           "Order does not exists. Cancel",
+			subject:        SubjectOrderCancel,
+			err:            errors.Wrap(common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: "hint"}, "fail to cancel order"),
+			expectedResult: failureTransient,
+		},
+		{
+			name:           "Order does not exists. Create",
+			subject:        SubjectOrderCreate,
+			err:            common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: "hint"},
+			expectedResult: failurePermanent,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedResult, classifyError(test.subject, test.err))
+		})
+	}
+}
+
+func TestHandleMessageOrderDoesNotExists(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	statusUpdate := &domain.OrderStatusUpdateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", Status: domain.StatusPaid}
+	data, _ := json.Marshal(statusUpdate)
+
+	// смена статуса пришла раньше создания заказа: сообщение возвращается для повтора, а не в dead-letter
+	service := mock_consumer.NewMockorderService(ct)
+	service.EXPECT().UpdateStatus(gomock.Any(), statusUpdate).
+		Return(common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: domain.ErrOrderDoesNotExists.Error()})
+
+	consumer := newTestConsumer(service)
+	msg := &testMsg{subject: SubjectOrderStatusUpdate, data: data}
+	consumer.handleMessage(context.Background(), msg)
+
+	assert.True(t, msg.naked)
+	assert.False(t, msg.acked)
+}
+
+func TestFailureType(t *testing.T) {
+	var data map[string]any
+	unmarshalErr := json.Unmarshal([]byte(`error`), &data)
+
+	testCases := []struct {
+		name           string
+		err            error
+		expectedResult string
+	}{
+		{
+			name:           "Domain error",
+			err:            errors.Wrap(common.WrapError{Err: domain.ErrUserDoesNotExists, Msg: "hint"}, "fail to create order"),
+			expectedResult: "user does not exists",
+		},
+		{
+			name:           "Timeout",
+			err:            errors.Wrap(context.DeadlineExceeded, "fail to create order"),
+			expectedResult: "context deadline exceeded",
+		},
+		{
+			name:           "Unmarshal error",
+			err:            errors.Wrap(unmarshalErr, "fail to unmarshal msg"),
+			expectedResult: "unmarshal error",
+		},
+		{
+			name:           "Unknown error",
+			err:            common.WrapError{Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, Msg: "fail to create transaction"},
+			expectedResult: "unknown",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedResult, failureType(test.err))
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		name           string
+		numDelivered   uint64
+		expectedResult time.Duration
+	}{
+		{name: "First delivery", numDelivered: 1, expectedResult: 500 * time.Millisecond},
+		{name: "Unknown delivery", numDelivered: 0, expectedResult: 500 * time.Millisecond},
+		{name: "Third delivery", numDelivered: 3, expectedResult: 2 * time.Second},
+		{name: "Capped", numDelivered: 10, expectedResult: 30 * time.Second},
+		{name: "Overflow", numDelivered: 200, expectedResult: 30 * time.Second},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedResult, backoff(test.numDelivered, 500*time.Millisecond, 30*time.Second))
+		})
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	errorType, errorMessage := describeError(errors.Wrap(
+		common.WrapError{Err: domain.ErrOrderAlreadyExists, Msg: "hint: Key (order_uid) already exists"},
+		"fail to create order",
+	))
+
+	assert.Equal(t, "order already exists", errorType)
+	assert.Equal(t, "hint: Key (order_uid) already exists", errorMessage)
+}
@@ -0,0 +1,184 @@
+package consumer
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	subjectSeparator      = "."
+	subjectWildcardToken  = "*"
+	subjectWildcardTail   = ">"
+	routeLiteralTokenRank = 2
+	routeWildcardRank     = 1
+)
+
+// HandlerFunc обработчик сообщений subject
+type HandlerFunc func(ctx context.Context, msg *Msg) error
+
+// Middleware обертка над обработчиком сообщений
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type route struct {
+	pattern string
+	tokens  []string
+	handler HandlerFunc
+}
+
+// Router сопоставляет subject сообщения с зарегистрированным обработчиком.
+// Поддерживаются wildcard nats: * - один токен, > - один и более токенов в конце
+type Router struct {
+	routes      []*route
+	middlewares []Middleware
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle зарегистрировать обработчик для subject или шаблона subject
+func (r *Router) Handle(pattern string, handler HandlerFunc) {
+	for _, rt := range r.routes {
+		if rt.pattern == pattern {
+			rt.handler = handler
+			return
+		}
+	}
+
+	r.routes = append(r.routes, &route{
+		pattern: pattern,
+		tokens:  strings.Split(pattern, subjectSeparator),
+		handler: handler,
+	})
+}
+
+// Use добавить middleware, первый добавленный middleware выполняется первым
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Match вернуть обработчик subject, обернутый в middleware. Если subject подходит
+// под несколько шаблонов, выбирается самый конкретный
+func (r *Router) Match(subject string) (HandlerFunc, bool) {
+	tokens := strings.Split(subject, subjectSeparator)
+
+	var (
+		best     *route
+		bestRank int
+	)
+	for _, rt := range r.routes {
+		if !matchTokens(rt.tokens, tokens) {
+			continue
+		}
+
+		if rank := routeRank(rt.tokens); best == nil || rank > bestRank {
+			best, bestRank = rt, rank
+		}
+	}
+
+	if best == nil {
+		return nil, false
+	}
+
+	handler := best.handler
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+
+	return handler, true
+}
+
+// Patterns вернуть зарегистрированные шаблоны subject
+func (r *Router) Patterns() []string {
+	patterns := make([]string, 0, len(r.routes))
+	for _, rt := range r.routes {
+		patterns = append(patterns, rt.pattern)
+	}
+	return patterns
+}
+
+// Drift сравнить subject стрима с зарегистрированными обработчиками: вернуть subject,
+// для которых нет обработчика, и обработчики, ни одно сообщение которых не попадает в стрим
+func (r *Router) Drift(subjects []string) (unhandled []string, unsubscribed []string) {
+	for _, subject := range subjects {
+		tokens := strings.Split(subject, subjectSeparator)
+
+		handled := false
+		for _, rt := range r.routes {
+			if matchTokens(rt.tokens, tokens) {
+				handled = true
+				break
+			}
+		}
+		if !handled {
+			unhandled = append(unhandled, subject)
+		}
+	}
+
+	for _, rt := range r.routes {
+		subscribed := false
+		for _, subject := range subjects {
+			if overlapTokens(strings.Split(subject, subjectSeparator), rt.tokens) {
+				subscribed = true
+				break
+			}
+		}
+		if !subscribed {
+			unsubscribed = append(unsubscribed, rt.pattern)
+		}
+	}
+
+	return unhandled, unsubscribed
+}
+
+// matchTokens проверить, что subject подходит под шаблон. Wildcard в subject
+// совпадает только с таким же или более широким wildcard шаблона
+func matchTokens(pattern, subject []string) bool {
+	for i, token := range pattern {
+		if token == subjectWildcardTail {
+			return len(subject) > i
+		}
+
+		if i >= len(subject) {
+			return false
+		}
+
+		switch {
+		case subject[i] == subjectWildcardTail:
+			return false
+		case token == subjectWildcardToken:
+			continue
+		case token != subject[i]:
+			return false
+		}
+	}
+
+	return len(pattern) == len(subject)
+}
+
+// overlapTokens проверить, что существует subject, подходящий под оба шаблона
+func overlapTokens(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == subjectWildcardTail || b[i] == subjectWildcardTail {
+			return true
+		}
+		if a[i] != b[i] && a[i] != subjectWildcardToken && b[i] != subjectWildcardToken {
+			return false
+		}
+	}
+
+	return len(a) == len(b)
+}
+
+// routeRank вес шаблона: литеральные токены важнее wildcard
+func routeRank(tokens []string) int {
+	rank := 0
+	for _, token := range tokens {
+		if token == subjectWildcardToken || token == subjectWildcardTail {
+			rank += routeWildcardRank
+			continue
+		}
+		rank += routeLiteralTokenRank
+	}
+	return rank
+}
@@ -0,0 +1,81 @@
+package consumer
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRouterMatch(t *testing.T) {
+	router := NewRouter()
+	for _, pattern := range []string{"order.create", "order.*", "order.>", "order.*.update"} {
+		pattern := pattern
+		router.Handle(pattern, func(ctx context.Context, msg *Msg) error {
+			msg.Data = []byte(pattern)
+			return nil
+		})
+	}
+
+	testCases := []struct {
+		subject         string
+		expectedPattern string
+		expectedOk      bool
+	}{
+		{subject: "order.create", expectedPattern: "order.create", expectedOk: true},
+		{subject: "order.cancel", expectedPattern: "order.*", expectedOk: true},
+		{subject: "order.status.update", expectedPattern: "order.*.update", expectedOk: true},
+		{subject: "order.status.delete", expectedPattern: "order.>", expectedOk: true},
+		{subject: "order", expectedOk: false},
+		{subject: "payment.create", expectedOk: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.subject, func(t *testing.T) {
+			handler, ok := router.Match(test.subject)
+			assert.Equal(t, test.expectedOk, ok)
+			if !ok {
+				return
+			}
+
+			msg := &Msg{Subject: test.subject}
+			assert.NoError(t, handler(context.Background(), msg))
+			assert.Equal(t, test.expectedPattern, string(msg.Data))
+		})
+	}
+}
+
+func TestRouterMiddlewareOrder(t *testing.T) {
+	var calls []string
+	middleware := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, msg *Msg) error {
+				calls = append(calls, name)
+				return next(ctx, msg)
+			}
+		}
+	}
+
+	router := NewRouter()
+	router.Use(middleware("first"), middleware("second"))
+	router.Handle("order.create", func(ctx context.Context, msg *Msg) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	handler, ok := router.Match("order.create")
+	assert.True(t, ok)
+	assert.NoError(t, handler(context.Background(), &Msg{Subject: "order.create"}))
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestRouterDrift(t *testing.T) {
+	router := NewRouter()
+	router.Handle("order.create", func(ctx context.Context, msg *Msg) error { return nil })
+	router.Handle("order.status.*", func(ctx context.Context, msg *Msg) error { return nil })
+	router.Handle("payment.>", func(ctx context.Context, msg *Msg) error { return nil })
+
+	unhandled, unsubscribed := router.Drift([]string{"order.create", "order.status.update", "order.cancel", "order.>"})
+
+	assert.Equal(t, []string{"order.cancel", "order.>"}, unhandled)
+	assert.Equal(t, []string{"payment.>"}, unsubscribed)
+}
@@ -0,0 +1,64 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+// UnknownSubjectPolicy обработка сообщений, для subject которых нет обработчика
+type UnknownSubjectPolicy string
+
+const (
+	// UnknownSubjectAck сообщение подтверждается и отбрасывается
+	UnknownSubjectAck UnknownSubjectPolicy = "ack"
+	// UnknownSubjectNak сообщение возвращается в стрим для повторной доставки
+	UnknownSubjectNak UnknownSubjectPolicy = "nak"
+	// UnknownSubjectDeadLetter сообщение отправляется в dead-letter
+	UnknownSubjectDeadLetter UnknownSubjectPolicy = "dead_letter"
+)
+
+// ParseUnknownSubjectPolicy разобрать политику из конфига, по умолчанию dead_letter
+func ParseUnknownSubjectPolicy(value string) (UnknownSubjectPolicy, error) {
+	if len(value) == 0 {
+		return UnknownSubjectDeadLetter, nil
+	}
+
+	switch policy := UnknownSubjectPolicy(value); policy {
+	case UnknownSubjectAck, UnknownSubjectNak, UnknownSubjectDeadLetter:
+		return policy, nil
+	default:
+		return "", errors.Errorf("unknown subject policy %q", value)
+	}
+}
+
+// handleUnknownSubject применить политику к сообщению без обработчика
+func (c *Consumer) handleUnknownSubject(ctx context.Context, msg jetstream.Msg, cause error) {
+	numDelivered := deliveryCount(msg)
+
+	metrics.MessagesUnknownSubject.WithLabelValues(string(c.unknownSubjectPolicy)).Inc()
+	logger.Warn("no handler for subject", zap.String("subject", msg.Subject()),
+		zap.String("policy", string(c.unknownSubjectPolicy)), zap.Uint64("numDelivered", numDelivered))
+
+	switch c.unknownSubjectPolicy {
+	case UnknownSubjectAck:
+		ack(msg)
+	case UnknownSubjectNak:
+		delay := backoff(numDelivered,
+			time.Duration(c.retryCfg.BackoffBaseMs)*time.Millisecond,
+			time.Duration(c.retryCfg.BackoffMaxMs)*time.Millisecond,
+		)
+		nak(msg, delay)
+	default:
+		if err := c.deadLetter(ctx, msg, deadLetterReasonUnknownSubject, cause, numDelivered); err != nil {
+			logger.Error("fail to dead-letter message", zap.String("subject", msg.Subject()), zap.Error(err))
+			nak(msg, 0)
+			return
+		}
+		term(msg)
+	}
+}
@@ -0,0 +1,32 @@
+package consumer
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHandleUnknownSubject(t *testing.T) {
+	testCases := []struct {
+		name          string
+		policy        UnknownSubjectPolicy
+		expectedAcked bool
+		expectedNaked bool
+	}{
+		{name: "Ack", policy: UnknownSubjectAck, expectedAcked: true},
+		{name: "Nak", policy: UnknownSubjectNak, expectedNaked: true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			consumer := newTestConsumer(nil)
+			consumer.unknownSubjectPolicy = test.policy
+
+			msg := &testMsg{subject: "payment.create"}
+			consumer.handleMessage(context.Background(), msg)
+
+			assert.Equal(t, test.expectedAcked, msg.acked)
+			assert.Equal(t, test.expectedNaked, msg.naked)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package consumer
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+// workers пул обработчиков сообщений. Подписки передают сообщения в пул, количество
+// одновременно обрабатываемых сообщений не зависит от количества подписок
+type workers struct {
+	size  int
+	queue chan jetstream.Msg
+	// deadline максимальное время обработки сообщения, 0 - без ограничения
+	deadline time.Duration
+	// heartbeat период продления ack wait обрабатываемого сообщения, 0 - не продлевать
+	heartbeat time.Duration
+}
+
+// newWorkers пул обработчиков, deadline - handler_timeout_ms
+func newWorkers(cfg config.Workers, deadline time.Duration) *workers {
+	size := cfg.PoolSize
+	if size <= 0 {
+		size = 1
+	}
+
+	return &workers{
+		size:      size,
+		queue:     make(chan jetstream.Msg),
+		deadline:  deadline,
+		heartbeat: time.Duration(cfg.HeartbeatIntervalMs) * time.Millisecond,
+	}
+}
+
+// startWorkers запустить обработчики пула, обработчики завершаются после отмены handlerCtx
+func (c *Consumer) startWorkers() {
+	logger.Info("nats consumer worker pool is starting", zap.Int("size", c.workers.size),
+		zap.Duration("deadline", c.workers.deadline), zap.Duration("heartbeat", c.workers.heartbeat))
+
+	for i := 0; i < c.workers.size; i++ {
+		go func() {
+			for {
+				select {
+				case <-c.handlerCtx.Done():
+					return
+				case msg := <-c.workers.queue:
+					c.processMessage(msg)
+					c.release(1)
+				}
+			}
+		}()
+	}
+}
+
+// submit передать сообщение свободному обработчику пула. Пока все обработчики заняты,
+// подписка не получает новые сообщения
+func (c *Consumer) submit(msg jetstream.Msg) {
+	if !c.acquire(1) {
+		rejectDraining(msg)
+		return
+	}
+
+	select {
+	case c.workers.queue <- msg:
+	case <-c.handlerCtx.Done():
+		// обработчики остановлены, сообщение будет доставлено повторно
+		c.release(1)
+		nak(msg, 0)
+	}
+}
+
+// processMessage обработать сообщение с ограничением времени обработки. Пока сообщение
+// обрабатывается, ack wait продлевается, чтобы сервер не доставил его повторно
+func (c *Consumer) processMessage(msg jetstream.Msg) {
+	if c.workers.heartbeat > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go heartbeat(msg, c.workers.heartbeat, done)
+	}
+
+	c.handleMessageDeadline(msg)
+}
+
+// handleMessageDeadline обработать сообщение в контексте messageContext
+func (c *Consumer) handleMessageDeadline(msg jetstream.Msg) {
+	ctx, cancel := c.messageContext()
+	defer cancel()
+
+	c.handleMessage(ctx, msg)
+}
+
+// messageContext контекст обработки одного сообщения с ограничением времени обработки
+func (c *Consumer) messageContext() (context.Context, context.CancelFunc) {
+	if c.workers.deadline > 0 {
+		return context.WithTimeout(c.handlerCtx, c.workers.deadline)
+	}
+	return context.WithCancel(c.handlerCtx)
+}
+
+// heartbeat отправлять InProgress с периодом interval до закрытия done или подтверждения сообщения
+func heartbeat(msg jetstream.Msg, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := msg.InProgress(); err != nil {
+				if errors.Is(err, jetstream.ErrMsgAlreadyAckd) {
+					return
+				}
+				logger.Warn("fail to send message in progress", zap.String("subject", msg.Subject()), zap.Error(err))
+				continue
+			}
+			metrics.MessagesInProgress.WithLabelValues(msg.Subject()).Inc()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	mock_consumer "wb_test_task/consumer/internal/consumer/mocks"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+func TestProcessMessage(t *testing.T) {
+	request := &domain.OrderCreateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"}
+	data, _ := json.Marshal(request)
+
+	testCases := []struct {
+		name               string
+		mock               func(service *mock_consumer.MockorderService)
+		expectedAcked      bool
+		expectedNaked      bool
+		expectedInProgress bool
+	}{
+		{
+			name: "Long handler sends heartbeats",
+			mock: func(service *mock_consumer.MockorderService) {
+				service.EXPECT().Create(gomock.Any(), request).
+					DoAndReturn(func(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+						time.Sleep(50 * time.Millisecond)
+						return &model.Order{}, nil
+					})
+			},
+			expectedAcked:      true,
+			expectedInProgress: true,
+		},
+		{
+			name: "Deadline exceeded",
+			mock: func(service *mock_consumer.MockorderService) {
+				service.EXPECT().Create(gomock.Any(), request).
+					DoAndReturn(func(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+						<-ctx.Done()
+						return &model.Order{}, ctx.Err()
+					})
+			},
+			expectedNaked:      true,
+			expectedInProgress: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			service := mock_consumer.NewMockorderService(ct)
+			test.mock(service)
+
+			consumer := newTestConsumer(service)
+			consumer.workers = newWorkers(config.Workers{PoolSize: 1, HeartbeatIntervalMs: 10}, 100*time.Millisecond)
+
+			msg := &testMsg{subject: SubjectOrderCreate, data: data}
+			consumer.processMessage(msg)
+
+			assert.Equal(t, test.expectedAcked, msg.acked)
+			assert.Equal(t, test.expectedNaked, msg.naked)
+			assert.Equal(t, test.expectedInProgress, msg.inProgress.Load() > 0)
+		})
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	var running, maxRunning atomic.Int32
+	service := mock_consumer.NewMockorderService(ct)
+	service.EXPECT().Create(gomock.Any(), gomock.Any()).Times(4).
+		DoAndReturn(func(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+			current := running.Add(1)
+			for {
+				max := maxRunning.Load()
+				if current <= max || maxRunning.CompareAndSwap(max, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			running.Add(-1)
+			return &model.Order{}, nil
+		})
+
+	consumer := newTestConsumer(service)
+	consumer.workers = newWorkers(config.Workers{PoolSize: 2}, 0)
+	consumer.startWorkers()
+
+	msgs := make([]*testMsg, 0, 4)
+	for i := 0; i < 4; i++ {
+		data, _ := json.Marshal(&domain.OrderCreateRequest{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"})
+		msg := &testMsg{subject: SubjectOrderCreate, data: data}
+		msgs = append(msgs, msg)
+		consumer.submit(msg)
+	}
+
+	assert.NoError(t, consumer.Drain(context.Background()))
+	for _, msg := range msgs {
+		assert.True(t, msg.acked)
+	}
+	assert.Equal(t, int32(2), maxRunning.Load())
+	consumer.cancelHandlers()
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/dany-ykl/logger"
+	"go.uber.org/zap"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOk   = "ok"
+	StatusFail = "fail"
+)
+
+// Dependency зависимость, которую проверяет readiness
+type Dependency struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// DependencyStatus результат проверки зависимости
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Response struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
+// HealthController liveness отвечает, пока процесс обрабатывает http запросы, readiness
+// проверяет зависимости параллельно и отвечает 503, если хотя бы одна проверка не прошла за timeout
+func HealthController(mux *http.ServeMux, prefix string, dependencies []Dependency, timeout time.Duration) {
+	mux.HandleFunc(prefix+"/live", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Response{Status: StatusOk})
+	})
+
+	mux.HandleFunc(prefix+"/readiness", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		response := check(ctx, dependencies)
+		code := http.StatusOK
+		if response.Status != StatusOk {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, response)
+	})
+}
+
+// check проверить зависимости параллельно
+func check(ctx context.Context, dependencies []Dependency) Response {
+	response := Response{Status: StatusOk, Dependencies: make(map[string]DependencyStatus, len(dependencies))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, dependency := range dependencies {
+		dependency := dependency
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := dependency.Check(ctx)
+			status := DependencyStatus{Status: StatusOk, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				status.Status = StatusFail
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			response.Dependencies[dependency.Name] = status
+			if err != nil {
+				response.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return response
+}
+
+func writeJSON(w http.ResponseWriter, code int, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Warn("fail to write health response", zap.Error(err))
+	}
+}
@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("connection refused") }
+	stuck := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	testCases := []struct {
+		name           string
+		dependencies   []Dependency
+		expectedCode   int
+		expectedStatus map[string]string
+	}{
+		{
+			name:           "OK",
+			dependencies:   []Dependency{{Name: "nats", Check: ok}, {Name: "postgres", Check: ok}},
+			expectedCode:   http.StatusOK,
+			expectedStatus: map[string]string{"nats": StatusOk, "postgres": StatusOk},
+		},
+		{
+			name:           "Dependency failed",
+			dependencies:   []Dependency{{Name: "nats", Check: ok}, {Name: "redis", Check: fail}},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedStatus: map[string]string{"nats": StatusOk, "redis": StatusFail},
+		},
+		{
+			name:           "Dependency timeout",
+			dependencies:   []Dependency{{Name: "postgres", Check: stuck}},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedStatus: map[string]string{"postgres": StatusFail},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			HealthController(mux, "/api/health", test.dependencies, 50*time.Millisecond)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health/readiness", nil))
+
+			assert.Equal(t, test.expectedCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var response Response
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Len(t, response.Dependencies, len(test.expectedStatus))
+			for name, status := range test.expectedStatus {
+				assert.Equal(t, status, response.Dependencies[name].Status, name)
+				if status == StatusFail {
+					assert.NotEmpty(t, response.Dependencies[name].Error, name)
+				}
+			}
+		})
+	}
+}
+
+func TestLive(t *testing.T) {
+	mux := http.NewServeMux()
+	HealthController(mux, "/api/health", []Dependency{{Name: "redis", Check: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}}}, time.Second)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health/live", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/delivery/http/health"
+)
+
+const readHeaderTimeout = 5 * time.Second
+
+// Server http сервер служебных эндпоинтов consumer: метрики prometheus, liveness и readiness
+type Server struct {
+	server *http.Server
+	cfg    config.HttpServer
+}
+
+func New(cfg config.HttpServer, dependencies []health.Dependency) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	// init health
+	health.HealthController(mux, "/api/health", dependencies, time.Duration(cfg.ReadinessTimeoutMs)*time.Millisecond)
+
+	return &Server{
+		server: &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           mux,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+		cfg: cfg,
+	}
+}
+
+func (s *Server) Start() error {
+	logger.Info("http server started", zap.String("port", s.cfg.Port))
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
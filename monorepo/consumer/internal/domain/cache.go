@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"time"
+	"wb_test_task/libs/model"
+)
+
+// CacheRepair заказ, сохраненный в базе, но не записанный в кэш
+type CacheRepair struct {
+	OrderUid  string
+	Order     *model.Order
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// CacheLag отставание кэша от базы
+type CacheLag struct {
+	// Pending количество заказов, ожидающих записи в кэш
+	Pending int
+	// Oldest дата первой неудачной записи самого старого заказа, нулевая - кэш не отстает
+	Oldest time.Time
+}
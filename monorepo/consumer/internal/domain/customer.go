@@ -0,0 +1,23 @@
+package domain
+
+import "github.com/pkg/errors"
+
+// CustomerPolicy политика обработки заказов от покупателей, которых нет в users
+type CustomerPolicy string
+
+const (
+	// CustomerPolicyStrict заказ отклоняется с ErrUserDoesNotExists
+	CustomerPolicyStrict CustomerPolicy = "strict"
+	// CustomerPolicyAutoCreate покупатель создается в транзакции заказа
+	CustomerPolicyAutoCreate CustomerPolicy = "auto_create"
+)
+
+// ParseCustomerPolicy разобрать политику из конфига
+func ParseCustomerPolicy(value string) (CustomerPolicy, error) {
+	switch policy := CustomerPolicy(value); policy {
+	case CustomerPolicyStrict, CustomerPolicyAutoCreate:
+		return policy, nil
+	default:
+		return "", errors.Errorf("unknown customer policy %q", value)
+	}
+}
@@ -12,6 +12,12 @@ var (
 	ErrOrderAlreadyExists = errors.New("order already exists")
 	ErrInvalidOrderValue  = errors.New("invalid order value")
 	ErrOrderDoesNotExists = errors.New("order does not exists")
+	ErrOrderValidation    = errors.New("order validation failed")
+	ErrOrderConflict      = errors.New("order conflicts with already accepted order")
+
+	// order status errors
+	ErrInvalidOrderStatus      = errors.New("invalid order status")
+	ErrInvalidStatusTransition = errors.New("invalid order status transition")
 
 	// common errors
 	ErrInvalidValue = errors.New("invalid value")
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// OrderFingerprint отпечаток содержимого принятого запроса на создание заказа
+type OrderFingerprint struct {
+	OrderUid string
+	Hash     string
+	Payload  []byte
+}
+
+// FieldChange изменение поля между принятым и повторно полученным заказом
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// NewOrderFingerprint вычислить отпечаток запроса на создание заказа
+func NewOrderFingerprint(request *OrderCreateRequest) (*OrderFingerprint, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return &OrderFingerprint{}, err
+	}
+
+	hash := sha256.Sum256(payload)
+
+	return &OrderFingerprint{
+		OrderUid: request.OrderUid,
+		Hash:     hex.EncodeToString(hash[:]),
+		Payload:  payload,
+	}, nil
+}
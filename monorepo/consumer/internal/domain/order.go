@@ -18,3 +18,54 @@ type OrderCreateRequest struct {
 	DateCreated       string           `json:"date_created"`
 	OofShard          string           `json:"oof_shard"`
 }
+
+// ToOrder преобразовать запрос на создание в заказ
+func (r *OrderCreateRequest) ToOrder() *model.Order {
+	return &model.Order{
+		OrderUid:          r.OrderUid,
+		TrackNumber:       r.TrackNumber,
+		Entry:             r.Entry,
+		Delivery:          r.Delivery,
+		Payment:           r.Payment,
+		Items:             r.Items,
+		Locale:            r.Locale,
+		InternalSignature: r.InternalSignature,
+		CustomerID:        r.CustomerID,
+		DeliveryService:   r.DeliveryService,
+		ShardKey:          r.ShardKey,
+		SmID:              r.SmID,
+		DateCreated:       model.NormalizeDateCreated(r.DateCreated),
+		OofShard:          r.OofShard,
+	}
+}
+
+// MarshalProto закодировать запрос в protobuf сообщение order.create
+func (r *OrderCreateRequest) MarshalProto() ([]byte, error) {
+	return model.MarshalOrderCreateRequest(r.ToOrder())
+}
+
+// UnmarshalProto разобрать запрос из protobuf сообщения order.create
+func (r *OrderCreateRequest) UnmarshalProto(data []byte) error {
+	order, err := model.UnmarshalOrderCreateRequest(data)
+	if err != nil {
+		return err
+	}
+
+	*r = OrderCreateRequest{
+		OrderUid:          order.OrderUid,
+		TrackNumber:       order.TrackNumber,
+		Entry:             order.Entry,
+		Delivery:          order.Delivery,
+		Payment:           order.Payment,
+		Items:             order.Items,
+		Locale:            order.Locale,
+		InternalSignature: order.InternalSignature,
+		CustomerID:        order.CustomerID,
+		DeliveryService:   order.DeliveryService,
+		ShardKey:          order.ShardKey,
+		SmID:              order.SmID,
+		DateCreated:       order.DateCreated,
+		OofShard:          order.OofShard,
+	}
+	return nil
+}
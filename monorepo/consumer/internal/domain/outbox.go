@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"wb_test_task/libs/model"
+)
+
+const (
+	// SubjectOrderCreated событие о сохраненном заказе для других сервисов
+	SubjectOrderCreated = "order.created"
+
+	// OrderCreatedSchemaVersion версия схемы payload события order.created
+	OrderCreatedSchemaVersion = 1
+)
+
+// OutboxEvent событие, записанное в одной транзакции с заказом и ожидающее публикации
+type OutboxEvent struct {
+	ID       int64
+	MsgID    string
+	Subject  string
+	OrderUid string
+	Header   map[string][]string
+	Payload  []byte
+	Attempts int
+}
+
+// NewOrderCreatedEvent событие order.created с сохраненным заказом. Id сообщения
+// зависит только от заказа, поэтому повторная публикация отбрасывается jetstream
+func NewOrderCreatedEvent(order *model.Order, header map[string][]string) (*OutboxEvent, error) {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return &OutboxEvent{}, err
+	}
+
+	return &OutboxEvent{
+		MsgID:    fmt.Sprintf("%s:%s", SubjectOrderCreated, order.OrderUid),
+		Subject:  SubjectOrderCreated,
+		OrderUid: order.OrderUid,
+		Header:   header,
+		Payload:  payload,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// OrderStatus статус жизненного цикла заказа
+type OrderStatus string
+
+const (
+	StatusCreated   OrderStatus = "created"
+	StatusPaid      OrderStatus = "paid"
+	StatusAssembled OrderStatus = "assembled"
+	StatusShipped   OrderStatus = "shipped"
+	StatusDelivered OrderStatus = "delivered"
+	StatusCancelled OrderStatus = "cancelled"
+)
+
+// statusTransitions допустимые переходы между статусами заказа
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:   {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusAssembled, StatusCancelled},
+	StatusAssembled: {StatusShipped, StatusCancelled},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {},
+	StatusCancelled: {},
+}
+
+// Valid проверить, что статус известен
+func (s OrderStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo проверить, допустим ли переход в статус next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(statusTransitions[s], next)
+}
+
+type OrderStatusUpdateRequest struct {
+	OrderUid  string      `json:"order_uid"`
+	Status    OrderStatus `json:"status"`
+	Reason    string      `json:"reason"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+type OrderCancelRequest struct {
+	OrderUid  string    `json:"order_uid"`
+	Reason    string    `json:"reason"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+import "wb_test_task/libs/model"
+
+// Violation нарушение бизнес-правила в поле запроса, правила общие с api
+type Violation = model.Violation
+
+// Violations список нарушений бизнес-правил
+type Violations = model.Violations
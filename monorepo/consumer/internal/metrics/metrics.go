@@ -0,0 +1,263 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	namespace = "wb_test_task"
+	subsystem = "consumer"
+)
+
+var (
+	// CustomerPolicy активная политика создания покупателей
+	CustomerPolicy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "customer_policy",
+		Help:      "Active customer provisioning policy, 1 for the policy in use.",
+	}, []string{"policy"})
+
+	// CustomersAutoCreated количество покупателей, созданных вместе с заказом
+	CustomersAutoCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "customers_auto_created_total",
+		Help:      "Number of customers inserted in the order creation transaction.",
+	})
+
+	// CustomersMissing количество заказов, отклоненных из-за отсутствующего покупателя
+	CustomersMissing = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "customers_missing_total",
+		Help:      "Number of orders rejected because the customer does not exist.",
+	}, []string{"policy"})
+
+	// OrdersDuplicate количество повторно полученных идентичных заказов
+	OrdersDuplicate = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "orders_duplicate_total",
+		Help:      "Number of redelivered orders identical to the accepted ones.",
+	})
+
+	// OrdersConflict количество заказов с уже принятым order_uid и другим содержимым
+	OrdersConflict = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "orders_conflict_total",
+		Help:      "Number of orders whose payload differs from the accepted order with the same order_uid.",
+	})
+
+	// OrderConflictFields количество изменений по полям в конфликтующих заказах
+	OrderConflictFields = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "order_conflict_fields_total",
+		Help:      "Number of changed fields in conflicting orders.",
+	}, []string{"field"})
+
+	// MessagesReceived количество полученных из jetstream сообщений по subject
+	MessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_received_total",
+		Help:      "Number of messages received from jetstream by subject.",
+	}, []string{"subject"})
+
+	// MessagesAcked количество подтвержденных сообщений по subject
+	MessagesAcked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_acked_total",
+		Help:      "Number of acknowledged messages by subject.",
+	}, []string{"subject"})
+
+	// MessagesNaked количество сообщений, возвращенных для повторной доставки, по subject
+	MessagesNaked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_naked_total",
+		Help:      "Number of negatively acknowledged messages returned for redelivery by subject.",
+	}, []string{"subject"})
+
+	// MessagesDeadLettered количество сообщений, отправленных в dead-letter, по subject и причине
+	MessagesDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_dead_lettered_total",
+		Help:      "Number of messages published to the dead-letter subject by original subject and reason.",
+	}, []string{"subject", "reason"})
+
+	// MessagesDropped количество сообщений, отброшенных без dead-letter subject, по subject и причине
+	MessagesDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_dropped_total",
+		Help:      "Number of failed messages terminated without a dead-letter subject configured by original subject and reason.",
+	}, []string{"subject", "reason"})
+
+	// MessagesDeadlineExceeded количество сообщений, возвращенных после истечения времени обработки, по subject
+	MessagesDeadlineExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_deadline_exceeded_total",
+		Help:      "Number of messages returned for redelivery after the processing deadline exceeded by subject.",
+	}, []string{"subject"})
+
+	// MessagesLaneRetried количество повторов обработки сообщений внутри lane по subject
+	MessagesLaneRetried = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_lane_retried_total",
+		Help:      "Number of handling retries inside a lane after transient failures by subject.",
+	}, []string{"subject"})
+
+	// MessagesInProgress количество продлений ack wait обрабатываемых сообщений по subject
+	MessagesInProgress = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_in_progress_total",
+		Help:      "Number of in-progress heartbeats sent for long-running messages by subject.",
+	}, []string{"subject"})
+
+	// MessageFailures количество ошибок обработки сообщений по subject и типу ошибки
+	MessageFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "message_failures_total",
+		Help:      "Number of message handling failures by subject and error type.",
+	}, []string{"subject", "error"})
+
+	// MessagesHandled количество обработанных сообщений по subject и результату
+	MessagesHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_handled_total",
+		Help:      "Number of messages passed to subject handlers by result: ok, transient or permanent.",
+	}, []string{"subject", "result"})
+
+	// MessageHandleDuration длительность обработки сообщения
+	MessageHandleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "message_handle_duration_seconds",
+		Help:      "Duration of message handling by subject.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"subject"})
+
+	// MessagesUnknownSubject количество сообщений без обработчика
+	MessagesUnknownSubject = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "messages_unknown_subject_total",
+		Help:      "Number of messages without a registered subject handler by applied policy.",
+	}, []string{"policy"})
+
+	// OutboxEventsPublished количество опубликованных событий outbox
+	OutboxEventsPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "outbox_events_published_total",
+		Help:      "Number of outbox events published to nats.",
+	})
+
+	// OutboxPublishFailures количество неудачных попыток публикации событий outbox
+	OutboxPublishFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "outbox_publish_failures_total",
+		Help:      "Number of failed outbox event publish attempts.",
+	})
+
+	// CacheWriteFailures количество заказов, сохраненных в базе и не записанных в кэш
+	CacheWriteFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "cache_write_failures_total",
+		Help:      "Number of orders saved to the database whose cache write failed and was deferred.",
+	})
+
+	// CustomerSummaryInvalidationFailures количество неудачных удалений сводки покупателя из кэша
+	CustomerSummaryInvalidationFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "customer_summary_invalidation_failures_total",
+		Help:      "Number of failed customer summary invalidations, the message is redelivered to retry the invalidation.",
+	})
+
+	// CacheRepaired количество заказов, записанных в кэш повторно
+	CacheRepaired = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "cache_repaired_total",
+		Help:      "Number of deferred cache writes completed in the background.",
+	})
+
+	// CacheRepairFailures количество неудачных повторных записей в кэш
+	CacheRepairFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "cache_repair_failures_total",
+		Help:      "Number of failed background cache write attempts.",
+	})
+
+	// CacheLagOrders количество заказов, которые есть в базе и ожидают записи в кэш
+	CacheLagOrders = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "cache_lag_orders",
+		Help:      "Number of orders saved to the database and still waiting for a cache write.",
+	})
+
+	// CacheLagSeconds время с первой неудачной записи самого старого заказа, ожидающего записи в кэш
+	CacheLagSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "cache_lag_seconds",
+		Help:      "Age of the oldest deferred cache write, 0 when the cache is up to date with the database.",
+	})
+
+	// RedisCommandDuration длительность команд redis
+	RedisCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Duration of redis commands by command and result.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "result"})
+
+	// JetStreamConsumerNumPending количество сообщений стрима, еще не доставленных consumer
+	JetStreamConsumerNumPending = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "jetstream_consumer_num_pending",
+		Help:      "Number of stream messages not yet delivered to the durable consumer.",
+	}, []string{"consumer"})
+
+	// JetStreamConsumerNumAckPending количество доставленных и еще не подтвержденных сообщений
+	JetStreamConsumerNumAckPending = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "jetstream_consumer_num_ack_pending",
+		Help:      "Number of delivered messages waiting for acknowledgement.",
+	}, []string{"consumer"})
+
+	// JetStreamConsumerNumRedelivered количество сообщений, доставленных повторно и еще не подтвержденных
+	JetStreamConsumerNumRedelivered = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "jetstream_consumer_num_redelivered",
+		Help:      "Number of redelivered messages waiting for acknowledgement.",
+	}, []string{"consumer"})
+
+	// JetStreamConsumerNumWaiting количество ожидающих pull запросов consumer
+	JetStreamConsumerNumWaiting = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "jetstream_consumer_num_waiting",
+		Help:      "Number of pull requests waiting for messages.",
+	}, []string{"consumer"})
+)
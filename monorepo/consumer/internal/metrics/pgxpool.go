@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector публикует статистику пула соединений pgx на момент сбора метрик
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+}
+
+// NewPoolCollector collector статистики пула соединений pgx
+func NewPoolCollector(stat func() *pgxpool.Stat) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "pgxpool_"+name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		stat:                 stat,
+		acquiredConns:        desc("acquired_conns", "Number of currently acquired connections in the pool."),
+		idleConns:            desc("idle_conns", "Number of currently idle connections in the pool."),
+		constructingConns:    desc("constructing_conns", "Number of connections with construction in progress."),
+		totalConns:           desc("total_conns", "Total number of connections currently in the pool."),
+		maxConns:             desc("max_conns", "Maximum size of the pool."),
+		acquireCount:         desc("acquire_total", "Number of successful acquires from the pool."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total duration of successful acquires from the pool."),
+		emptyAcquireCount:    desc("empty_acquire_total", "Number of successful acquires that waited for a connection."),
+		canceledAcquireCount: desc("canceled_acquire_total", "Number of acquires canceled by a context."),
+		newConnsCount:        desc("new_conns_total", "Number of new connections opened."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquireCount
+	ch <- c.newConnsCount
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.newConnsCount, prometheus.CounterValue, float64(stat.NewConnsCount()))
+}
@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: relay.go
+
+// Package mock_outbox is a generated GoMock package.
+package mock_outbox
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "wb_test_task/consumer/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+	nats "github.com/nats-io/nats.go"
+	jetstream "github.com/nats-io/nats.go/jetstream"
+)
+
+// MockoutboxStorage is a mock of outboxStorage interface.
+type MockoutboxStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockoutboxStorageMockRecorder
+}
+
+// MockoutboxStorageMockRecorder is the mock recorder for MockoutboxStorage.
+type MockoutboxStorageMockRecorder struct {
+	mock *MockoutboxStorage
+}
+
+// NewMockoutboxStorage creates a new mock instance.
+func NewMockoutboxStorage(ctrl *gomock.Controller) *MockoutboxStorage {
+	mock := &MockoutboxStorage{ctrl: ctrl}
+	mock.recorder = &MockoutboxStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockoutboxStorage) EXPECT() *MockoutboxStorageMockRecorder {
+	return m.recorder
+}
+
+// ProcessOutbox mocks base method.
+func (m *MockoutboxStorage) ProcessOutbox(ctx context.Context, limit int, publish func(context.Context, *domain.OutboxEvent) error) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessOutbox", ctx, limit, publish)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ProcessOutbox indicates an expected call of ProcessOutbox.
+func (mr *MockoutboxStorageMockRecorder) ProcessOutbox(ctx, limit, publish interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOutbox", reflect.TypeOf((*MockoutboxStorage)(nil).ProcessOutbox), ctx, limit, publish)
+}
+
+// Mockpublisher is a mock of publisher interface.
+type Mockpublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockpublisherMockRecorder
+}
+
+// MockpublisherMockRecorder is the mock recorder for Mockpublisher.
+type MockpublisherMockRecorder struct {
+	mock *Mockpublisher
+}
+
+// NewMockpublisher creates a new mock instance.
+func NewMockpublisher(ctrl *gomock.Controller) *Mockpublisher {
+	mock := &Mockpublisher{ctrl: ctrl}
+	mock.recorder = &MockpublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockpublisher) EXPECT() *MockpublisherMockRecorder {
+	return m.recorder
+}
+
+// PublishMsg mocks base method.
+func (m *Mockpublisher) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, msg}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishMsg", varargs...)
+	ret0, _ := ret[0].(*jetstream.PubAck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishMsg indicates an expected call of PublishMsg.
+func (mr *MockpublisherMockRecorder) PublishMsg(ctx, msg interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, msg}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishMsg", reflect.TypeOf((*Mockpublisher)(nil).PublishMsg), varargs...)
+}
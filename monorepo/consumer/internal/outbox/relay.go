@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/libs/tracing"
+)
+
+//go:generate mockgen -source=relay.go -destination=mocks/mock.go
+type outboxStorage interface {
+	ProcessOutbox(ctx context.Context, limit int, publish func(ctx context.Context, event *domain.OutboxEvent) error) (int, int, error)
+}
+
+type publisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// Relay публикует события outbox в nats. Событие отмечается опубликованным после
+// подтверждения jetstream, поэтому доставка at-least-once, а повторы отбрасываются
+// jetstream по Nats-Msg-Id в пределах duplicate window стрима
+type Relay struct {
+	storage      outboxStorage
+	publisher    publisher
+	pollInterval time.Duration
+	batchSize    int
+}
+
+func NewRelay(cfg config.Outbox, storage outboxStorage, publisher publisher) *Relay {
+	return &Relay{
+		storage:      storage,
+		publisher:    publisher,
+		pollInterval: time.Duration(cfg.PollIntervalMs) * time.Millisecond,
+		batchSize:    cfg.BatchSize,
+	}
+}
+
+// CreateStream создать или обновить стрим событий outbox
+func CreateStream(ctx context.Context, js jetstream.JetStream, cfg config.Outbox) error {
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       cfg.StreamName,
+		Subjects:   cfg.Subjects,
+		Duplicates: time.Duration(cfg.DuplicateWindowSecond) * time.Second,
+	}); err != nil {
+		return errors.Wrap(err, "fail to create outbox stream")
+	}
+	return nil
+}
+
+// Start публиковать события outbox до отмены контекста
+func (r *Relay) Start(ctx context.Context) error {
+	logger.Info("outbox relay is starting", zap.Duration("pollInterval", r.pollInterval),
+		zap.Int("batchSize", r.batchSize))
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			logger.Info("outbox relay stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// flush публиковать события пачками, пока в outbox есть неопубликованные события.
+// После неудачной публикации следующая попытка выполняется на следующем тике
+func (r *Relay) flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, failed, err := r.storage.ProcessOutbox(ctx, r.batchSize, r.publish)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("fail to process outbox", zap.Error(err))
+			}
+			return
+		}
+
+		metrics.OutboxEventsPublished.Add(float64(sent))
+		metrics.OutboxPublishFailures.Add(float64(failed))
+
+		if failed != 0 || sent < r.batchSize {
+			return
+		}
+	}
+}
+
+// publish опубликовать событие с id сообщения для дедупликации
+func (r *Relay) publish(ctx context.Context, event *domain.OutboxEvent) error {
+	// span публикации продолжает трассу транзакции, в которой записано событие
+	ctx, span := tracer.StartTrace(tracing.Extract(ctx, event.Header), "outbox-relay-publish-"+event.Subject)
+	span.SetAttributes(attribute.String("order-id", event.OrderUid), attribute.String("msg-id", event.MsgID))
+	defer span.End()
+
+	header := nats.Header{}
+	for key, values := range event.Header {
+		header[key] = values
+	}
+	tracing.Inject(ctx, header)
+
+	if _, err := r.publisher.PublishMsg(ctx, &nats.Msg{
+		Subject: event.Subject,
+		Header:  header,
+		Data:    event.Payload,
+	}, jetstream.WithMsgID(event.MsgID)); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return errors.Wrap(err, "fail to publish outbox event")
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package outbox
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/domain"
+	mock_outbox "wb_test_task/consumer/internal/outbox/mocks"
+	"wb_test_task/libs/envelope"
+)
+
+func init() {
+	if err := logger.InitLogger(logger.Config{
+		Namespace:   "test.outbox",
+		Development: true,
+		Level:       logger.InfoLevel,
+	}); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(storage *mock_outbox.MockoutboxStorage)
+	}{
+		{
+			name: "Full batches are processed until outbox is empty",
+			mock: func(storage *mock_outbox.MockoutboxStorage) {
+				gomock.InOrder(
+					storage.EXPECT().ProcessOutbox(gomock.Any(), 2, gomock.Any()).Return(2, 0, nil),
+					storage.EXPECT().ProcessOutbox(gomock.Any(), 2, gomock.Any()).Return(1, 0, nil),
+				)
+			},
+		},
+		{
+			name: "Failed publish waits for the next tick",
+			mock: func(storage *mock_outbox.MockoutboxStorage) {
+				storage.EXPECT().ProcessOutbox(gomock.Any(), 2, gomock.Any()).Return(1, 1, nil)
+			},
+		},
+		{
+			name: "Error from storage",
+			mock: func(storage *mock_outbox.MockoutboxStorage) {
+				storage.EXPECT().ProcessOutbox(gomock.Any(), 2, gomock.Any()).Return(0, 0, errors.New("error"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			storage := mock_outbox.NewMockoutboxStorage(ct)
+			test.mock(storage)
+
+			relay := NewRelay(config.Outbox{BatchSize: 2, PollIntervalMs: 10}, storage, mock_outbox.NewMockpublisher(ct))
+			relay.flush(context.Background())
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	header := map[string][]string{envelope.HeaderSchemaVersion: {"1"}}
+	event := &domain.OutboxEvent{
+		ID:       1,
+		MsgID:    "order.created:5d110e48-9e6b-4928-b436-14194b30d54f",
+		Subject:  domain.SubjectOrderCreated,
+		OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		Header:   header,
+		Payload:  []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f"}`),
+	}
+
+	publisher := mock_outbox.NewMockpublisher(ct)
+	publisher.EXPECT().PublishMsg(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+			assert.Equal(t, event.Subject, msg.Subject)
+			assert.Equal(t, event.Payload, msg.Data)
+			assert.Equal(t, "1", msg.Header.Get(envelope.HeaderSchemaVersion))
+			assert.Len(t, opts, 1)
+			return &jetstream.PubAck{}, nil
+		})
+	publisher.EXPECT().PublishMsg(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("nats: timeout"))
+
+	relay := NewRelay(config.Outbox{BatchSize: 1}, mock_outbox.NewMockoutboxStorage(ct), publisher)
+
+	assert.NoError(t, relay.publish(context.Background(), event))
+	assert.EqualError(t, relay.publish(context.Background(), event), "fail to publish outbox event: nats: timeout")
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/tracer"
+	"go.opentelemetry.io/otel/attribute"
+	"strings"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+// CreateBatch создание пачки заказов одной транзакцией, при любой ошибке пачка не записывается
+// и вызывающий должен обработать заказы по одному
+func (o *orderService) CreateBatch(ctx context.Context, requests []*domain.OrderCreateRequest) ([]*model.Order, error) {
+	ctx, span := tracer.StartTrace(ctx, "order-service-create-batch")
+	span.SetAttributes(attribute.Int("size", len(requests)))
+	defer span.End()
+
+	var invalid []string
+	for _, request := range requests {
+		if violations := validateOrderCreateRequest(request); len(violations) != 0 {
+			invalid = append(invalid, fmt.Sprintf("%s: %s", request.OrderUid, violations.Error()))
+		}
+	}
+	if len(invalid) != 0 {
+		return nil, common.WrapError{Err: domain.ErrOrderValidation, Msg: strings.Join(invalid, "; ")}
+	}
+
+	orders, err := o.store.CreateBatch(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	customers := make(map[string]struct{}, len(orders))
+	for _, order := range orders {
+		if _, ok := customers[order.CustomerID]; !ok {
+			customers[order.CustomerID] = struct{}{}
+			if err := o.invalidateCustomerSummary(ctx, order.CustomerID); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := o.cache.Set(ctx, order.OrderUid, order, model.CacheVersionNone); err != nil {
+			if err := o.deferCacheWrite(ctx, order, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/consumer/internal/domain"
+	mock_services "wb_test_task/consumer/internal/services/mocks"
+	"wb_test_task/libs/model"
+)
+
+func TestCreateBatch(t *testing.T) {
+	valid := newValidOrderCreateRequest()
+	invalid := newValidOrderCreateRequest()
+	invalid.Locale = "ru"
+	orders := []*model.Order{valid.ToOrder()}
+
+	testCases := []struct {
+		name           string
+		requests       []*domain.OrderCreateRequest
+		mock           func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher)
+		expectedResult []*model.Order
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name:     "OK",
+			requests: []*domain.OrderCreateRequest{valid},
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher) {
+				storage.EXPECT().CreateBatch(ctx, []*domain.OrderCreateRequest{valid}).Return(orders, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, orders[0].CustomerID).Return(nil)
+				cache.EXPECT().Set(ctx, valid.OrderUid, orders[0], model.CacheVersionNone).Return(nil)
+			},
+			expectedResult: orders,
+		},
+		{
+			name:     "Validation error",
+			requests: []*domain.OrderCreateRequest{valid, invalid},
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher) {
+			},
+			wantErr: true,
+			errMsg:  "order validation failed",
+		},
+		{
+			name:     "Error from storage",
+			requests: []*domain.OrderCreateRequest{valid},
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher) {
+				storage.EXPECT().CreateBatch(ctx, []*domain.OrderCreateRequest{valid}).Return(nil, errors.New("error"))
+			},
+			wantErr: true,
+			errMsg:  "error",
+		},
+		{
+			name:     "Error from customer summary invalidation",
+			requests: []*domain.OrderCreateRequest{valid},
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher) {
+				storage.EXPECT().CreateBatch(ctx, []*domain.OrderCreateRequest{valid}).Return(orders, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, orders[0].CustomerID).Return(errors.New("redis: connection refused"))
+			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			storage := mock_services.NewMockorderStorage(ct)
+			cache := mock_services.NewMockorderCache(ct)
+
+			test.mock(storage, cache, gomock.Any())
+
+			service := newOrderService(storage, cache, mock_services.NewMockcacheRepairStorage(ct))
+			result, err := service.CreateBatch(context.Background(), test.requests)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
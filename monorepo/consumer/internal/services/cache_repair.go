@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/libs/model"
+)
+
+// deferCacheWrite записать заказ, сохраненный в базе, для повторной записи в кэш в фоне.
+// Если заказ не удалось записать и для повтора, вернуть ошибку, чтобы сообщение было доставлено повторно
+func (o *orderService) deferCacheWrite(ctx context.Context, order *model.Order, cause error) error {
+	metrics.CacheWriteFailures.Inc()
+	logger.Warn("fail to write order to cache, write is deferred", zap.String("orderUID", order.OrderUid),
+		zap.Error(cause))
+
+	if err := o.repairs.CreateCacheRepair(ctx, order, cause.Error()); err != nil {
+		logger.Error("fail to defer cache write", zap.String("orderUID", order.OrderUid), zap.Error(err))
+		return cause
+	}
+
+	return nil
+}
+
+// RepairCache записать в кэш заказ, запись которого была отложена. Статус заказа мог
+// измениться после неудачной записи, поэтому статус и история статусов читаются из базы
+func (o *orderService) RepairCache(ctx context.Context, order *model.Order) error {
+	ctx, span := tracer.StartTrace(ctx, "order-service-repair-cache")
+	span.SetAttributes(attribute.String("order-id", order.OrderUid))
+	defer span.End()
+
+	version, err := o.cache.GetVersion(ctx, order.OrderUid)
+	if err != nil {
+		return err
+	}
+
+	if err := o.loadStatus(ctx, order); err != nil {
+		return err
+	}
+
+	return o.cache.Set(ctx, order.OrderUid, order, version)
+}
+
+// loadStatus заполнить статус и историю статусов заказа из базы
+func (o *orderService) loadStatus(ctx context.Context, order *model.Order) error {
+	status, timeline, err := o.store.GetStatusHistory(ctx, order.OrderUid)
+	if err != nil {
+		return err
+	}
+
+	order.Status = status
+	order.Timeline = timeline
+	return nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	mock_services "wb_test_task/consumer/internal/services/mocks"
+	"wb_test_task/libs/model"
+)
+
+func TestRepairCache(t *testing.T) {
+	orderUid := "5d110e48-9e6b-4928-b436-14194b30d54f"
+	timeline := []*model.StatusChange{
+		{Status: "created", ChangedAt: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)},
+		{Status: "paid", ChangedAt: time.Date(2021, 11, 26, 7, 0, 0, 0, time.UTC)},
+	}
+
+	testCases := []struct {
+		name    string
+		mock    func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "OK",
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher) {
+				gomock.InOrder(
+					cache.EXPECT().GetVersion(ctx, orderUid).Return("3", nil),
+					storage.EXPECT().GetStatusHistory(ctx, orderUid).Return("paid", timeline, nil),
+					cache.EXPECT().Set(ctx, orderUid, &model.Order{OrderUid: orderUid, Status: "paid", Timeline: timeline}, "3").
+						Return(nil),
+				)
+			},
+		},
+		{
+			name: "Error from cache version",
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher) {
+				cache.EXPECT().GetVersion(ctx, orderUid).Return("", errors.New("redis: connection refused"))
+			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
+		},
+		{
+			name: "Error from cache",
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache, ctx gomock.Matcher) {
+				cache.EXPECT().GetVersion(ctx, orderUid).Return("3", nil)
+				storage.EXPECT().GetStatusHistory(ctx, orderUid).Return("paid", timeline, nil)
+				cache.EXPECT().Set(ctx, orderUid, &model.Order{OrderUid: orderUid, Status: "paid", Timeline: timeline}, "3").
+					Return(errors.New("redis: connection refused"))
+			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			storage := mock_services.NewMockorderStorage(ct)
+			cache := mock_services.NewMockorderCache(ct)
+			test.mock(storage, cache, gomock.Any())
+
+			service := newOrderService(storage, cache, mock_services.NewMockcacheRepairStorage(ct))
+			err := service.RepairCache(context.Background(), &model.Order{OrderUid: orderUid})
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
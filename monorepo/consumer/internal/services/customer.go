@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+	"github.com/dany-ykl/logger"
+	"go.uber.org/zap"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+// invalidateCustomerSummary удалить сводку покупателя после записи нового заказа. Ошибка возвращается,
+// чтобы сообщение было доставлено повторно: повтор проходит через refreshCachedOrder и удаляет сводку снова
+func (o *orderService) invalidateCustomerSummary(ctx context.Context, customerID string) error {
+	if err := o.cache.DeleteCustomerSummary(ctx, customerID); err != nil {
+		metrics.CustomerSummaryInvalidationFailures.Inc()
+		logger.Warn("fail to invalidate customer summary", zap.String("customerID", customerID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dany-ykl/logger"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/libs/model"
+)
+
+var fieldIndexRegexp = regexp.MustCompile(`\[\d+\]`)
+
+// resolveDuplicate сравнить повторно полученный заказ с уже принятым: идентичный заказ
+// считается успешной обработкой, заказ с тем же order_uid и другим содержимым - конфликтом
+func (o *orderService) resolveDuplicate(ctx context.Context, request *domain.OrderCreateRequest, cause error) (*model.Order, error) {
+	stored, err := o.store.GetFingerprint(ctx, request.OrderUid)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderDoesNotExists) {
+			// дубликат по другому ключу (например, track_number) или заказ принят до появления отпечатков
+			return &model.Order{Items: []*model.Product{}}, cause
+		}
+		return &model.Order{Items: []*model.Product{}}, err
+	}
+
+	fingerprint, err := domain.NewOrderFingerprint(request)
+	if err != nil {
+		return &model.Order{Items: []*model.Product{}}, common.WrapError{Err: err, Msg: "fail to calculate order fingerprint"}
+	}
+
+	if fingerprint.Hash == stored.Hash {
+		metrics.OrdersDuplicate.Inc()
+		logger.Info("duplicate order ignored", zap.String("orderUID", request.OrderUid))
+		return o.refreshCachedOrder(ctx, request)
+	}
+
+	changes, err := diffPayload(stored.Payload, fingerprint.Payload)
+	if err != nil {
+		return &model.Order{Items: []*model.Product{}}, common.WrapError{Err: err, Msg: "fail to diff order payload"}
+	}
+
+	metrics.OrdersConflict.Inc()
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+		metrics.OrderConflictFields.WithLabelValues(fieldIndexRegexp.ReplaceAllString(change.Field, "[]")).Inc()
+	}
+	logger.Warn("order conflicts with already accepted order",
+		zap.String("orderUID", request.OrderUid), zap.Any("diff", changes))
+
+	return &model.Order{Items: []*model.Product{}}, common.WrapError{
+		Err:  domain.ErrOrderConflict,
+		Msg:  fmt.Sprintf("changed fields: %s", strings.Join(fields, ", ")),
+		Body: changes,
+	}
+}
+
+// refreshCachedOrder записать в кэш уже принятый заказ с текущей историей статусов и удалить сводку покупателя,
+// чтобы повторная доставка или replay восстанавливали потерянный кэш и неудавшуюся инвалидацию
+func (o *orderService) refreshCachedOrder(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+	if err := o.invalidateCustomerSummary(ctx, request.CustomerID); err != nil {
+		return &model.Order{Items: []*model.Product{}}, err
+	}
+
+	order := request.ToOrder()
+	version, err := o.cache.GetVersion(ctx, request.OrderUid)
+	if err != nil {
+		if err := o.deferCacheWrite(ctx, order, err); err != nil {
+			return &model.Order{Items: []*model.Product{}}, err
+		}
+		return order, nil
+	}
+
+	if err := o.loadStatus(ctx, order); err != nil {
+		return &model.Order{Items: []*model.Product{}}, err
+	}
+
+	if err := o.cache.Set(ctx, request.OrderUid, order, version); err != nil {
+		if err := o.deferCacheWrite(ctx, order, err); err != nil {
+			return &model.Order{Items: []*model.Product{}}, err
+		}
+	}
+
+	return order, nil
+}
+
+// diffPayload вернуть список измененных полей между двумя json документами
+func diffPayload(old, new []byte) ([]domain.FieldChange, error) {
+	var oldDoc, newDoc any
+	if err := json.Unmarshal(old, &oldDoc); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(new, &newDoc); err != nil {
+		return nil, err
+	}
+
+	oldFields, newFields := map[string]any{}, map[string]any{}
+	flatten("", oldDoc, oldFields)
+	flatten("", newDoc, newFields)
+
+	keys := make([]string, 0, len(oldFields)+len(newFields))
+	for key := range oldFields {
+		keys = append(keys, key)
+	}
+	for key := range newFields {
+		if _, ok := oldFields[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []domain.FieldChange
+	for _, key := range keys {
+		if !reflect.DeepEqual(oldFields[key], newFields[key]) {
+			changes = append(changes, domain.FieldChange{Field: key, Old: oldFields[key], New: newFields[key]})
+		}
+	}
+
+	return changes, nil
+}
+
+// flatten разложить json документ в набор путь -> значение
+func flatten(prefix string, value any, out map[string]any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flatten(path, item, out)
+		}
+	case []any:
+		for i, item := range v {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), item, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/consumer/internal/domain"
+)
+
+func TestDiffPayload(t *testing.T) {
+	testCases := []struct {
+		name           string
+		old, new       string
+		expectedResult []domain.FieldChange
+	}{
+		{
+			name:           "Equal",
+			old:            `{"order_uid":"1","items":[{"price":453}]}`,
+			new:            `{"items":[{"price":453}],"order_uid":"1"}`,
+			expectedResult: nil,
+		},
+		{
+			name: "Changed nested fields",
+			old:  `{"order_uid":"1","payment":{"amount":1817},"items":[{"price":453}]}`,
+			new:  `{"order_uid":"1","payment":{"amount":1900},"items":[{"price":453},{"price":10}]}`,
+			expectedResult: []domain.FieldChange{
+				{Field: "items[1].price", Old: nil, New: float64(10)},
+				{Field: "payment.amount", Old: float64(1817), New: float64(1900)},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := diffPayload([]byte(test.old), []byte(test.new))
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
@@ -51,6 +51,67 @@ func (mr *MockorderStorageMockRecorder) Create(ctx, request interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockorderStorage)(nil).Create), ctx, request)
 }
 
+// CreateBatch mocks base method.
+func (m *MockorderStorage) CreateBatch(ctx context.Context, requests []*domain.OrderCreateRequest) ([]*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, requests)
+	ret0, _ := ret[0].([]*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockorderStorageMockRecorder) CreateBatch(ctx, requests interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockorderStorage)(nil).CreateBatch), ctx, requests)
+}
+
+// GetFingerprint mocks base method.
+func (m *MockorderStorage) GetFingerprint(ctx context.Context, orderUid string) (*domain.OrderFingerprint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFingerprint", ctx, orderUid)
+	ret0, _ := ret[0].(*domain.OrderFingerprint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFingerprint indicates an expected call of GetFingerprint.
+func (mr *MockorderStorageMockRecorder) GetFingerprint(ctx, orderUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFingerprint", reflect.TypeOf((*MockorderStorage)(nil).GetFingerprint), ctx, orderUid)
+}
+
+// GetStatusHistory mocks base method.
+func (m *MockorderStorage) GetStatusHistory(ctx context.Context, orderUid string) (string, []*model.StatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusHistory", ctx, orderUid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].([]*model.StatusChange)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetStatusHistory indicates an expected call of GetStatusHistory.
+func (mr *MockorderStorageMockRecorder) GetStatusHistory(ctx, orderUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusHistory", reflect.TypeOf((*MockorderStorage)(nil).GetStatusHistory), ctx, orderUid)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockorderStorage) UpdateStatus(ctx context.Context, request *domain.OrderStatusUpdateRequest) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, request)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockorderStorageMockRecorder) UpdateStatus(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockorderStorage)(nil).UpdateStatus), ctx, request)
+}
+
 // MockorderCache is a mock of orderCache interface.
 type MockorderCache struct {
 	ctrl     *gomock.Controller
@@ -74,16 +135,96 @@ func (m *MockorderCache) EXPECT() *MockorderCacheMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockorderCache) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockorderCacheMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockorderCache)(nil).Delete), ctx, key)
+}
+
+// DeleteCustomerSummary mocks base method.
+func (m *MockorderCache) DeleteCustomerSummary(ctx context.Context, customerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCustomerSummary", ctx, customerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCustomerSummary indicates an expected call of DeleteCustomerSummary.
+func (mr *MockorderCacheMockRecorder) DeleteCustomerSummary(ctx, customerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCustomerSummary", reflect.TypeOf((*MockorderCache)(nil).DeleteCustomerSummary), ctx, customerID)
+}
+
+// GetVersion mocks base method.
+func (m *MockorderCache) GetVersion(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockorderCacheMockRecorder) GetVersion(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockorderCache)(nil).GetVersion), ctx, key)
+}
+
 // Set mocks base method.
-func (m *MockorderCache) Set(ctx context.Context, key string, order *model.Order) error {
+func (m *MockorderCache) Set(ctx context.Context, key string, order *model.Order, version string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", ctx, key, order)
+	ret := m.ctrl.Call(m, "Set", ctx, key, order, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Set indicates an expected call of Set.
-func (mr *MockorderCacheMockRecorder) Set(ctx, key, order interface{}) *gomock.Call {
+func (mr *MockorderCacheMockRecorder) Set(ctx, key, order, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockorderCache)(nil).Set), ctx, key, order, version)
+}
+
+// MockcacheRepairStorage is a mock of cacheRepairStorage interface.
+type MockcacheRepairStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockcacheRepairStorageMockRecorder
+}
+
+// MockcacheRepairStorageMockRecorder is the mock recorder for MockcacheRepairStorage.
+type MockcacheRepairStorageMockRecorder struct {
+	mock *MockcacheRepairStorage
+}
+
+// NewMockcacheRepairStorage creates a new mock instance.
+func NewMockcacheRepairStorage(ctrl *gomock.Controller) *MockcacheRepairStorage {
+	mock := &MockcacheRepairStorage{ctrl: ctrl}
+	mock.recorder = &MockcacheRepairStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcacheRepairStorage) EXPECT() *MockcacheRepairStorageMockRecorder {
+	return m.recorder
+}
+
+// CreateCacheRepair mocks base method.
+func (m *MockcacheRepairStorage) CreateCacheRepair(ctx context.Context, order *model.Order, cause string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCacheRepair", ctx, order, cause)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCacheRepair indicates an expected call of CreateCacheRepair.
+func (mr *MockcacheRepairStorageMockRecorder) CreateCacheRepair(ctx, order, cause interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockorderCache)(nil).Set), ctx, key, order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCacheRepair", reflect.TypeOf((*MockcacheRepairStorage)(nil).CreateCacheRepair), ctx, order, cause)
 }
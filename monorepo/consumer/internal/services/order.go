@@ -2,6 +2,10 @@ package services
 
 import (
 	"context"
+	"github.com/dany-ykl/tracer"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"wb_test_task/consumer/internal/common"
 	"wb_test_task/consumer/internal/domain"
 	"wb_test_task/libs/model"
 )
@@ -9,31 +13,64 @@ import (
 //go:generate mockgen -source=order.go -destination=mocks/mock.go
 type orderStorage interface {
 	Create(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error)
+	CreateBatch(ctx context.Context, requests []*domain.OrderCreateRequest) ([]*model.Order, error)
+	GetFingerprint(ctx context.Context, orderUid string) (*domain.OrderFingerprint, error)
+	GetStatusHistory(ctx context.Context, orderUid string) (string, []*model.StatusChange, error)
+	UpdateStatus(ctx context.Context, request *domain.OrderStatusUpdateRequest) (bool, error)
 }
 
 type orderCache interface {
-	Set(ctx context.Context, key string, order *model.Order) error
+	Set(ctx context.Context, key string, order *model.Order, version string) error
+	GetVersion(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+	DeleteCustomerSummary(ctx context.Context, customerID string) error
+}
+
+type cacheRepairStorage interface {
+	CreateCacheRepair(ctx context.Context, order *model.Order, cause string) error
 }
 
 type orderService struct {
-	store orderStorage
-	cache orderCache
+	store   orderStorage
+	cache   orderCache
+	repairs cacheRepairStorage
 }
 
-func newOrderService(store orderStorage, cache orderCache) *orderService {
-	return &orderService{store: store, cache: cache}
+func newOrderService(store orderStorage, cache orderCache, repairs cacheRepairStorage) *orderService {
+	return &orderService{store: store, cache: cache, repairs: repairs}
 }
 
 // Create создание заказа
 func (o *orderService) Create(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+	ctx, span := tracer.StartTrace(ctx, "order-service-create")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
+	if violations := validateOrderCreateRequest(request); len(violations) != 0 {
+		return &model.Order{Items: []*model.Product{}}, common.WrapError{
+			Err:  domain.ErrOrderValidation,
+			Msg:  violations.Error(),
+			Body: violations,
+		}
+	}
+
 	order, err := o.store.Create(ctx, request)
 	if err != nil {
+		if errors.Is(err, domain.ErrOrderAlreadyExists) {
+			return o.resolveDuplicate(ctx, request, err)
+		}
 		return &model.Order{Items: []*model.Product{}}, err
 	}
-
-	if err := o.cache.Set(ctx, request.OrderUid, order); err != nil {
+	if err := o.invalidateCustomerSummary(ctx, order.CustomerID); err != nil {
 		return &model.Order{Items: []*model.Product{}}, err
 	}
 
+	// новый заказ еще не инвалидировался: если статус изменится до записи, заказ не попадет в кэш
+	if err := o.cache.Set(ctx, request.OrderUid, order, model.CacheVersionNone); err != nil {
+		if err := o.deferCacheWrite(ctx, order, err); err != nil {
+			return &model.Order{Items: []*model.Product{}}, err
+		}
+	}
+
 	return order, nil
 }
@@ -2,15 +2,29 @@ package services
 
 import (
 	"context"
+	"github.com/dany-ykl/logger"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"log"
 	"testing"
+	"time"
+	"wb_test_task/consumer/internal/common"
 	"wb_test_task/consumer/internal/domain"
 	mock_services "wb_test_task/consumer/internal/services/mocks"
 	"wb_test_task/libs/model"
 )
 
+func init() {
+	if err := logger.InitLogger(logger.Config{
+		Namespace:   "test.order.service",
+		Development: true,
+		Level:       logger.InfoLevel,
+	}); err != nil {
+		log.Fatalln(err)
+	}
+}
+
 func TestCreate(t *testing.T) {
 	createOrderRequest := &domain.OrderCreateRequest{
 		OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
@@ -107,17 +121,32 @@ func TestCreate(t *testing.T) {
 		DeliveryService:   "meest",
 		ShardKey:          "",
 		SmID:              99,
-		DateCreated:       "2021-11-26 06:22:19 +0000 UTC",
+		DateCreated:       "2021-11-26T06:22:19Z",
 		OofShard:          "1",
 	}
 
+	fingerprint, err := domain.NewOrderFingerprint(createOrderRequest)
+	if err != nil {
+		t.Error(err)
+	}
+	alreadyExistsErr := common.WrapError{Err: domain.ErrOrderAlreadyExists, Msg: "hint: Key (order_uid) already exists"}
+
+	// часы producer смены статуса отстают: последняя запись истории не текущий статус заказа
+	timeline := []*model.StatusChange{
+		{Status: "paid", ChangedAt: time.Date(2021, 11, 26, 6, 0, 0, 0, time.UTC)},
+		{Status: "created", ChangedAt: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)},
+	}
+	redeliveredOrder := *order
+	redeliveredOrder.Status = "paid"
+	redeliveredOrder.Timeline = timeline
+
 	testCases := []struct {
 		name      string
 		mockInput struct {
 			request *domain.OrderCreateRequest
 		}
 		mock func(storage *mock_services.MockorderStorage,
-			cache *mock_services.MockorderCache, ctx context.Context,
+			cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
 			request *domain.OrderCreateRequest, order *model.Order)
 		expectedResult *model.Order
 		wantErr        bool
@@ -127,20 +156,39 @@ func TestCreate(t *testing.T) {
 			name:      "OK",
 			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
 			mock: func(storage *mock_services.MockorderStorage,
-				cache *mock_services.MockorderCache, ctx context.Context,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
 				request *domain.OrderCreateRequest, order *model.Order) {
 				storage.EXPECT().Create(ctx, request).Return(order, nil)
-				cache.EXPECT().Set(ctx, request.OrderUid, order).Return(nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, order.CustomerID).Return(nil)
+				cache.EXPECT().Set(ctx, request.OrderUid, order, model.CacheVersionNone).Return(nil)
 			},
 			expectedResult: order,
 			wantErr:        false,
 			errMsg:         "",
 		},
+		{
+			name: "Validation error",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: &domain.OrderCreateRequest{
+				OrderUid:    "invalid",
+				TrackNumber: createOrderRequest.TrackNumber,
+				Delivery:    createOrderRequest.Delivery,
+				Payment:     createOrderRequest.Payment,
+				Items:       createOrderRequest.Items,
+				Locale:      createOrderRequest.Locale,
+			}},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         "order validation failed",
+		},
 		{
 			name:      "Error from storage",
 			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
 			mock: func(storage *mock_services.MockorderStorage,
-				cache *mock_services.MockorderCache, ctx context.Context,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
 				request *domain.OrderCreateRequest, order *model.Order) {
 				storage.EXPECT().Create(ctx, request).Return(&model.Order{Items: []*model.Product{}}, errors.New("error"))
 			},
@@ -149,18 +197,142 @@ func TestCreate(t *testing.T) {
 			errMsg:         "error",
 		},
 		{
-			name:      "Error from cache",
+			name:      "OK. Identical redelivery",
 			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
 			mock: func(storage *mock_services.MockorderStorage,
-				cache *mock_services.MockorderCache, ctx context.Context,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
 				request *domain.OrderCreateRequest, order *model.Order) {
-				storage.EXPECT().Create(ctx, request).Return(order, nil)
-				cache.EXPECT().Set(ctx, request.OrderUid, order).Return(errors.New("error"))
+				storage.EXPECT().Create(ctx, request).Return(&model.Order{}, alreadyExistsErr)
+				storage.EXPECT().GetFingerprint(ctx, request.OrderUid).Return(fingerprint, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, request.CustomerID).Return(nil)
+				cache.EXPECT().GetVersion(ctx, request.OrderUid).Return("2", nil)
+				storage.EXPECT().GetStatusHistory(ctx, request.OrderUid).Return("paid", timeline, nil)
+				cache.EXPECT().Set(ctx, request.OrderUid, &redeliveredOrder, "2").Return(nil)
+			},
+			expectedResult: &redeliveredOrder,
+			wantErr:        false,
+			errMsg:         "",
+		},
+		{
+			name:      "OK. Identical redelivery. Error from cache version, write is deferred",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(&model.Order{}, alreadyExistsErr)
+				storage.EXPECT().GetFingerprint(ctx, request.OrderUid).Return(fingerprint, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, request.CustomerID).Return(nil)
+				cache.EXPECT().GetVersion(ctx, request.OrderUid).Return("", errors.New("redis: connection refused"))
+				repairs.EXPECT().CreateCacheRepair(ctx, request.ToOrder(), "redis: connection refused").Return(nil)
+			},
+			expectedResult: createOrderRequest.ToOrder(),
+			wantErr:        false,
+			errMsg:         "",
+		},
+		{
+			name:      "Identical redelivery. Error from status history",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(&model.Order{}, alreadyExistsErr)
+				storage.EXPECT().GetFingerprint(ctx, request.OrderUid).Return(fingerprint, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, request.CustomerID).Return(nil)
+				cache.EXPECT().GetVersion(ctx, request.OrderUid).Return("2", nil)
+				storage.EXPECT().GetStatusHistory(ctx, request.OrderUid).Return("", nil, errors.New("error"))
 			},
 			expectedResult: &model.Order{Items: []*model.Product{}},
 			wantErr:        true,
 			errMsg:         "error",
 		},
+		{
+			name:      "Conflict with accepted order",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(&model.Order{}, alreadyExistsErr)
+				storage.EXPECT().GetFingerprint(ctx, request.OrderUid).Return(&domain.OrderFingerprint{
+					OrderUid: request.OrderUid,
+					Hash:     "other",
+					Payload:  []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f"}`),
+				}, nil)
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         "order conflicts with already accepted order",
+		},
+		{
+			name:      "Already exists without fingerprint",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(&model.Order{}, alreadyExistsErr)
+				storage.EXPECT().GetFingerprint(ctx, request.OrderUid).Return(&domain.OrderFingerprint{},
+					common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: "order fingerprint does not exists"})
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         "order already exists",
+		},
+		{
+			name:      "Error from customer summary invalidation",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(order, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, order.CustomerID).Return(errors.New("redis: connection refused"))
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         "redis: connection refused",
+		},
+		{
+			name:      "Identical redelivery. Error from customer summary invalidation",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(&model.Order{}, alreadyExistsErr)
+				storage.EXPECT().GetFingerprint(ctx, request.OrderUid).Return(fingerprint, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, request.CustomerID).Return(errors.New("redis: connection refused"))
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         "redis: connection refused",
+		},
+		{
+			name:      "OK. Error from cache, write is deferred",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(order, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, order.CustomerID).Return(nil)
+				cache.EXPECT().Set(ctx, request.OrderUid, order, model.CacheVersionNone).Return(errors.New("error"))
+				repairs.EXPECT().CreateCacheRepair(ctx, order, "error").Return(nil)
+			},
+			expectedResult: order,
+			wantErr:        false,
+			errMsg:         "",
+		},
+		{
+			name:      "Error from cache. Error from cache repair storage",
+			mockInput: struct{ request *domain.OrderCreateRequest }{request: createOrderRequest},
+			mock: func(storage *mock_services.MockorderStorage,
+				cache *mock_services.MockorderCache, repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher,
+				request *domain.OrderCreateRequest, order *model.Order) {
+				storage.EXPECT().Create(ctx, request).Return(order, nil)
+				cache.EXPECT().DeleteCustomerSummary(ctx, order.CustomerID).Return(nil)
+				cache.EXPECT().Set(ctx, request.OrderUid, order, model.CacheVersionNone).Return(errors.New("redis: connection refused"))
+				repairs.EXPECT().CreateCacheRepair(ctx, order, "redis: connection refused").Return(errors.New("error"))
+			},
+			expectedResult: &model.Order{Items: []*model.Product{}},
+			wantErr:        true,
+			errMsg:         "redis: connection refused",
+		},
 	}
 
 	for _, test := range testCases {
@@ -170,11 +342,12 @@ func TestCreate(t *testing.T) {
 
 			storage := mock_services.NewMockorderStorage(ct)
 			cache := mock_services.NewMockorderCache(ct)
+			repairs := mock_services.NewMockcacheRepairStorage(ct)
 
-			test.mock(storage, cache, context.Background(), createOrderRequest, order)
+			test.mock(storage, cache, repairs, gomock.Any(), createOrderRequest, order)
 
-			service := newOrderService(storage, cache)
-			result, err := service.Create(context.Background(), createOrderRequest)
+			service := newOrderService(storage, cache, repairs)
+			result, err := service.Create(context.Background(), test.mockInput.request)
 
 			if test.wantErr {
 				assert.EqualError(t, err, test.errMsg)
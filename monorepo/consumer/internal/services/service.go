@@ -7,10 +7,12 @@ type Service struct {
 type Depends struct {
 	OrderStorage orderStorage
 	OrderCache   orderCache
+	// CacheRepairStorage заказы, не записанные в кэш после сохранения в базе
+	CacheRepairStorage cacheRepairStorage
 }
 
 func New(depends Depends) *Service {
 	return &Service{
-		OrderService: newOrderService(depends.OrderStorage, depends.OrderCache),
+		OrderService: newOrderService(depends.OrderStorage, depends.OrderCache, depends.CacheRepairStorage),
 	}
 }
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+)
+
+// UpdateStatus изменение статуса заказа
+func (o *orderService) UpdateStatus(ctx context.Context, request *domain.OrderStatusUpdateRequest) error {
+	ctx, span := tracer.StartTrace(ctx, "order-service-update-status")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
+	if !request.Status.Valid() {
+		return common.WrapError{Err: domain.ErrInvalidOrderStatus, Msg: fmt.Sprintf("unknown status %q", request.Status)}
+	}
+
+	changed, err := o.store.UpdateStatus(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		// повторная доставка события, заказ уже в запрошенном статусе. Кэш все равно инвалидируется:
+		// повтор мог прийти после неудачной инвалидации
+		logger.Info("order status unchanged", zap.String("orderUID", request.OrderUid),
+			zap.String("status", string(request.Status)))
+	}
+
+	return o.invalidateCachedOrder(ctx, request.OrderUid)
+}
+
+// Cancel отмена заказа
+func (o *orderService) Cancel(ctx context.Context, request *domain.OrderCancelRequest) error {
+	return o.UpdateStatus(ctx, &domain.OrderStatusUpdateRequest{
+		OrderUid:  request.OrderUid,
+		Status:    domain.StatusCancelled,
+		Reason:    request.Reason,
+		ChangedAt: request.ChangedAt,
+	})
+}
+
+// invalidateCachedOrder удалить заказ из кэша после изменения статуса, api загрузит актуальный заказ
+// из базы. Ошибка возвращается, чтобы событие было доставлено повторно
+func (o *orderService) invalidateCachedOrder(ctx context.Context, orderUid string) error {
+	if err := o.cache.Delete(ctx, orderUid); err != nil {
+		logger.Warn("fail to invalidate cached order", zap.String("orderUID", orderUid), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/domain"
+	mock_services "wb_test_task/consumer/internal/services/mocks"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	changedAt := time.Date(2021, 11, 26, 7, 0, 0, 0, time.UTC)
+	request := &domain.OrderStatusUpdateRequest{
+		OrderUid:  "5d110e48-9e6b-4928-b436-14194b30d54f",
+		Status:    domain.StatusPaid,
+		ChangedAt: changedAt,
+	}
+	testCases := []struct {
+		name    string
+		request *domain.OrderStatusUpdateRequest
+		mock    func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache,
+			repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "OK. Cached order invalidated",
+			request: request,
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache,
+				repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher) {
+				storage.EXPECT().UpdateStatus(ctx, request).Return(true, nil)
+				cache.EXPECT().Delete(ctx, request.OrderUid).Return(nil)
+			},
+		},
+		{
+			name:    "OK. Status unchanged, cached order invalidated again",
+			request: request,
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache,
+				repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher) {
+				storage.EXPECT().UpdateStatus(ctx, request).Return(false, nil)
+				cache.EXPECT().Delete(ctx, request.OrderUid).Return(nil)
+			},
+		},
+		{
+			name:    "Error from cache invalidation",
+			request: request,
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache,
+				repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher) {
+				storage.EXPECT().UpdateStatus(ctx, request).Return(true, nil)
+				cache.EXPECT().Delete(ctx, request.OrderUid).Return(errors.New("redis: connection refused"))
+			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
+		},
+		{
+			name:    "Unknown status",
+			request: &domain.OrderStatusUpdateRequest{OrderUid: request.OrderUid, Status: "lost"},
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache,
+				repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher) {
+			},
+			wantErr: true,
+			errMsg:  "invalid order status",
+		},
+		{
+			name:    "Error from storage",
+			request: request,
+			mock: func(storage *mock_services.MockorderStorage, cache *mock_services.MockorderCache,
+				repairs *mock_services.MockcacheRepairStorage, ctx gomock.Matcher) {
+				storage.EXPECT().UpdateStatus(ctx, request).Return(false, errors.New("error"))
+			},
+			wantErr: true,
+			errMsg:  "error",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ct := gomock.NewController(t)
+			defer ct.Finish()
+
+			storage := mock_services.NewMockorderStorage(ct)
+			cache := mock_services.NewMockorderCache(ct)
+			repairs := mock_services.NewMockcacheRepairStorage(ct)
+
+			test.mock(storage, cache, repairs, gomock.Any())
+
+			service := newOrderService(storage, cache, repairs)
+			err := service.UpdateStatus(context.Background(), test.request)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ct := gomock.NewController(t)
+	defer ct.Finish()
+
+	storage := mock_services.NewMockorderStorage(ct)
+	cache := mock_services.NewMockorderCache(ct)
+
+	storage.EXPECT().UpdateStatus(gomock.Any(), &domain.OrderStatusUpdateRequest{
+		OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		Status:   domain.StatusCancelled,
+		Reason:   "customer request",
+	}).Return(false, nil)
+	cache.EXPECT().Delete(gomock.Any(), "5d110e48-9e6b-4928-b436-14194b30d54f").Return(nil)
+
+	service := newOrderService(storage, cache, mock_services.NewMockcacheRepairStorage(ct))
+	err := service.Cancel(context.Background(), &domain.OrderCancelRequest{
+		OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+		Reason:   "customer request",
+	})
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,11 @@
+package services
+
+import (
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+// validateOrderCreateRequest проверить бизнес-правила заказа до записи в базу
+func validateOrderCreateRequest(request *domain.OrderCreateRequest) domain.Violations {
+	return model.ValidateOrder(request.ToOrder())
+}
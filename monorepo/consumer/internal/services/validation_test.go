@@ -0,0 +1,151 @@
+package services
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+func newValidOrderCreateRequest() *domain.OrderCreateRequest {
+	return &domain.OrderCreateRequest{
+		OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+		TrackNumber: "WBILMTESTTRACK3",
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: model.Payment{
+			Transaction:  "5d110e48-9e6b-4928-b436-14194b30d54f",
+			RequestID:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+			CustomFee:    0,
+		},
+		Items: []*model.Product{
+			{
+				ChrtID:      9934930,
+				TrackNumber: "WBILMTESTTRACK3",
+				Price:       453,
+				Rid:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmID:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+		Locale:            "en",
+		InternalSignature: "",
+		CustomerID:        "test",
+		DeliveryService:   "meest",
+		ShardKey:          "",
+		SmID:              99,
+		DateCreated:       "2021-11-26 06:22:19 +0000 UTC",
+		OofShard:          "1",
+	}
+}
+
+func TestValidateOrderCreateRequest(t *testing.T) {
+	testCases := []struct {
+		name           string
+		modify         func(request *domain.OrderCreateRequest)
+		expectedResult domain.Violations
+	}{
+		{
+			name:           "OK",
+			modify:         func(request *domain.OrderCreateRequest) {},
+			expectedResult: nil,
+		},
+		{
+			name: "Invalid order uid",
+			modify: func(request *domain.OrderCreateRequest) {
+				request.OrderUid = "b563feb7b2b84b6test"
+			},
+			expectedResult: domain.Violations{{Field: "order_uid", Message: "must be a uuid"}},
+		},
+		{
+			name: "Invalid email and phone",
+			modify: func(request *domain.OrderCreateRequest) {
+				request.Delivery.Email = "Test <test@gmail.com>"
+				request.Delivery.Phone = "phone"
+			},
+			expectedResult: domain.Violations{
+				{Field: "delivery.email", Message: "invalid email format"},
+				{Field: "delivery.phone", Message: "invalid phone format"},
+			},
+		},
+		{
+			name: "Not allowed values",
+			modify: func(request *domain.OrderCreateRequest) {
+				request.Locale = "ru"
+				request.Payment.Currency = "EUR"
+				request.Payment.Provider = "paypal"
+			},
+			expectedResult: domain.Violations{
+				{Field: "locale", Message: "must be one of [en]"},
+				{Field: "payment.currency", Message: "must be one of [USD RUB]"},
+				{Field: "payment.provider", Message: "must be one of [wbpay]"},
+			},
+		},
+		{
+			name: "Item track number mismatch",
+			modify: func(request *domain.OrderCreateRequest) {
+				request.Items[0].TrackNumber = "WBILMTESTTRACK"
+			},
+			expectedResult: domain.Violations{
+				{Field: "items[0].track_number", Message: `must be equal to order track_number "WBILMTESTTRACK3"`},
+			},
+		},
+		{
+			name: "Item total price does not match sale",
+			modify: func(request *domain.OrderCreateRequest) {
+				request.Items[0].Sale = 10
+			},
+			expectedResult: domain.Violations{
+				{Field: "items[0].total_price", Message: "must be equal to price with sale applied (407.70)"},
+			},
+		},
+		{
+			name: "Goods total and amount mismatch",
+			modify: func(request *domain.OrderCreateRequest) {
+				request.Payment.GoodsTotal = 634
+			},
+			expectedResult: domain.Violations{
+				{Field: "payment.goods_total", Message: "must be equal to sum of items total_price (317.00)"},
+				{Field: "payment.amount", Message: "must be equal to goods_total + delivery_cost + custom_fee (2134.00)"},
+			},
+		},
+		{
+			name: "Empty items",
+			modify: func(request *domain.OrderCreateRequest) {
+				request.Items = []*model.Product{}
+				request.Payment.GoodsTotal = 0
+				request.Payment.Amount = 1500
+			},
+			expectedResult: domain.Violations{{Field: "items", Message: "must contain at least one item"}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			request := newValidOrderCreateRequest()
+			test.modify(request)
+
+			assert.Equal(t, test.expectedResult, validateOrderCreateRequest(request))
+		})
+	}
+}
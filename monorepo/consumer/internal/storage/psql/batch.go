@@ -0,0 +1,264 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/consumer/internal/metrics"
+	"wb_test_task/libs/model"
+)
+
+// CreateBatch создание пачки заказов в одной транзакции через COPY
+func (o *orderStorage) CreateBatch(ctx context.Context, requests []*domain.OrderCreateRequest) ([]*model.Order, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-create-order-batch")
+	span.SetAttributes(attribute.Int("size", len(requests)))
+	defer span.End()
+
+	orderRows := make([][]interface{}, 0, len(requests))
+	paymentRows := make([][]interface{}, 0, len(requests))
+	deliveryRows := make([][]interface{}, 0, len(requests))
+	productRows := make([][]interface{}, 0, len(requests))
+	fingerprintRows := make([][]interface{}, 0, len(requests))
+	orderUids := make([]uuid.UUID, 0, len(requests))
+
+	// COPY передает значения в бинарном формате, поэтому uuid и даты разбираются до записи
+	for _, request := range requests {
+		orderUid, err := uuid.Parse(request.OrderUid)
+		if err != nil {
+			return nil, common.WrapError{Err: domain.ErrInvalidValue, Msg: fmt.Sprintf("order_uid: %s", err)}
+		}
+
+		requestID, err := uuid.Parse(request.Payment.RequestID)
+		if err != nil {
+			return nil, common.WrapError{Err: domain.ErrInvalidValue, Msg: fmt.Sprintf("request_id: %s", err)}
+		}
+
+		dateCreated, err := parseDateCreated(request.DateCreated)
+		if err != nil {
+			return nil, err
+		}
+
+		fingerprint, err := domain.NewOrderFingerprint(request)
+		if err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to calculate order fingerprint"}
+		}
+
+		orderUids = append(orderUids, orderUid)
+		orderRows = append(orderRows, []interface{}{orderUid, request.TrackNumber, request.Entry, request.Locale,
+			request.InternalSignature, request.CustomerID, request.DeliveryService, request.ShardKey, request.SmID,
+			request.OofShard, dateCreated})
+		paymentRows = append(paymentRows, []interface{}{orderUid, requestID, request.Payment.Currency,
+			request.Payment.Provider, request.Payment.Amount, request.Payment.PaymentDt, request.Payment.Bank,
+			request.Payment.DeliveryCost, request.Payment.GoodsTotal, request.Payment.CustomFee})
+		deliveryRows = append(deliveryRows, []interface{}{orderUid, request.Delivery.Name, request.Delivery.Phone,
+			request.Delivery.Zip, request.Delivery.City, request.Delivery.Address, request.Delivery.Region,
+			request.Delivery.Email})
+		fingerprintRows = append(fingerprintRows, []interface{}{orderUid, fingerprint.Hash, fingerprint.Payload})
+
+		for _, product := range request.Items {
+			productRows = append(productRows, []interface{}{product.ChrtID, product.TrackNumber, product.Price,
+				product.Rid, product.Name, product.Sale, product.Size, product.TotalPrice, product.NmID,
+				product.Brand, product.Status})
+		}
+	}
+
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to create transaction"}
+	}
+	defer tx.Rollback(context.Background())
+
+	// создание покупателей
+	if err := o.provisionCustomers(ctx, tx, requests); err != nil {
+		return nil, err
+	}
+
+	tables := []struct {
+		name    string
+		columns []string
+		rows    [][]interface{}
+	}{
+		{
+			name: "orders",
+			columns: []string{"order_uid", "track_number", "entry", "locale", "internal_signature",
+				"customer_id", "delivery_service", "shardkey", "sm_id", "oof_shard", "date_created"},
+			rows: orderRows,
+		},
+		{
+			name: "transaction",
+			columns: []string{"id", "request_id", "currency", "provider", "amount", "payment_dt",
+				"bank", "delivery_cost", "goods_total", "custom_fee"},
+			rows: paymentRows,
+		},
+		{
+			name:    "delivery",
+			columns: []string{"order_uid", "name", "phone", "zip", "city", "address", "region", "email"},
+			rows:    deliveryRows,
+		},
+		{
+			name: "product",
+			columns: []string{"chrt_id", "track_number", "price", "rid", "name", "sale", "size",
+				"total_price", "nm_id", "brand", "status"},
+			rows: productRows,
+		},
+		{
+			name:    "order_fingerprint",
+			columns: []string{"order_uid", "hash", "payload"},
+			rows:    fingerprintRows,
+		},
+	}
+
+	for _, table := range tables {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{table.name}, table.columns, pgx.CopyFromRows(table.rows)); err != nil {
+			return nil, o.mapBatchError(err, table.name)
+		}
+	}
+
+	// запись начальных статусов заказов
+	changes, err := o.createStatusHistoryBatch(ctx, tx, orderUids)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*model.Order, 0, len(requests))
+	outboxRows := make([][]interface{}, 0, len(requests))
+	sentAt := o.outboxSentAt()
+	for i, request := range requests {
+		order := request.ToOrder()
+		if change, ok := changes[orderUids[i].String()]; ok {
+			order.Status = change.Status
+			order.Timeline = []*model.StatusChange{change}
+		}
+		orders = append(orders, order)
+
+		event, err := newOrderCreatedEvent(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+		header, err := json.Marshal(event.Header)
+		if err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to marshal outbox event header"}
+		}
+		outboxRows = append(outboxRows, []interface{}{event.MsgID, event.Subject, orderUids[i], header, event.Payload, sentAt})
+	}
+
+	// события order.created пачки
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"outbox"},
+		[]string{"msg_id", "subject", "order_uid", "headers", "payload", "sent_at"}, pgx.CopyFromRows(outboxRows)); err != nil {
+		return nil, o.mapBatchError(err, "outbox")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to commit transaction"}
+	}
+
+	return orders, nil
+}
+
+// provisionCustomers создание покупателей пачки заказов, если включена политика auto_create
+func (o *orderStorage) provisionCustomers(ctx context.Context, tx pgx.Tx, requests []*domain.OrderCreateRequest) error {
+	if o.customerPolicy != domain.CustomerPolicyAutoCreate {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(requests))
+	for _, request := range requests {
+		if _, ok := seen[request.CustomerID]; ok {
+			continue
+		}
+		seen[request.CustomerID] = struct{}{}
+
+		created, err := o.users.CreateIfNotExists(ctx, tx, request.CustomerID)
+		if err != nil {
+			return err
+		}
+
+		if created {
+			metrics.CustomersAutoCreated.Inc()
+			logger.Info("customer auto-created", zap.String("customerID", request.CustomerID),
+				zap.String("orderUID", request.OrderUid))
+		}
+	}
+
+	return nil
+}
+
+// createStatusHistoryBatch запись начальных статусов пачки заказов в историю
+func (o *orderStorage) createStatusHistoryBatch(ctx context.Context, tx pgx.Tx, orderUids []uuid.UUID) (map[string]*model.StatusChange, error) {
+	query := `
+		INSERT INTO order_status_history(order_uid, status, changed_at)
+		SELECT order_uid, status, COALESCE(date_created, now()) FROM orders WHERE order_uid = ANY($1)
+		RETURNING order_uid::text, status, changed_at
+	`
+
+	rows, err := tx.Query(ctx, query, orderUids)
+	if err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to create order status history"}
+	}
+	defer rows.Close()
+
+	changes := make(map[string]*model.StatusChange, len(orderUids))
+	for rows.Next() {
+		var (
+			orderUid string
+			change   model.StatusChange
+		)
+		if err := rows.Scan(&orderUid, &change.Status, &change.ChangedAt); err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+		changes[orderUid] = &change
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to create order status history"}
+	}
+
+	return changes, nil
+}
+
+// mapBatchError привести ошибку COPY к ошибкам домена
+func (o *orderStorage) mapBatchError(err error, table string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case domain.CodeErrDuplicateKey:
+			return common.WrapError{Err: domain.ErrOrderAlreadyExists, Msg: fmt.Sprintf("hint: %s", pgErr.Detail)}
+		case domain.CodeErrConstraintLenValue:
+			return common.WrapError{Err: domain.ErrInvalidOrderValue, Msg: pgErr.Message}
+		case domain.CodeErrInvalidSyntax:
+			return common.WrapError{Err: domain.ErrInvalidValue, Msg: pgErr.Message}
+		case domain.CodeErrForeignKey:
+			if table == "orders" {
+				metrics.CustomersMissing.WithLabelValues(string(o.customerPolicy)).Inc()
+				return common.WrapError{Err: domain.ErrUserDoesNotExists, Msg: domain.ErrUserDoesNotExists.Error()}
+			}
+			return common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: domain.ErrOrderDoesNotExists.Error()}
+		}
+	}
+
+	return common.WrapError{Err: err, Msg: fmt.Sprintf("fail to copy %s", table)}
+}
+
+// parseDateCreated разобрать date_created заказа, пустая дата записывается как NULL
+func parseDateCreated(value string) (*time.Time, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	date, err := model.ParseDateCreated(value)
+	if err != nil {
+		return nil, common.WrapError{Err: domain.ErrInvalidValue, Msg: fmt.Sprintf("date_created: invalid format %q", value)}
+	}
+	return &date, nil
+}
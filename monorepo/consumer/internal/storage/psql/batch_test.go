@@ -0,0 +1,126 @@
+package psql
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+func TestCreateBatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	createdAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	request := &domain.OrderCreateRequest{
+		OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+		TrackNumber: "WBILMTESTTRACK3",
+		Payment:     model.Payment{RequestID: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+		Items:       []*model.Product{{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK3"}},
+		CustomerID:  "test",
+		DateCreated: "2021-11-26T06:22:19Z",
+	}
+	order := request.ToOrder()
+	order.Status = "created"
+	order.Timeline = []*model.StatusChange{{Status: "created", ChangedAt: createdAt}}
+
+	orderColumns := []string{"order_uid", "track_number", "entry", "locale", "internal_signature",
+		"customer_id", "delivery_service", "shardkey", "sm_id", "oof_shard", "date_created"}
+	paymentColumns := []string{"id", "request_id", "currency", "provider", "amount", "payment_dt",
+		"bank", "delivery_cost", "goods_total", "custom_fee"}
+	deliveryColumns := []string{"order_uid", "name", "phone", "zip", "city", "address", "region", "email"}
+	productColumns := []string{"chrt_id", "track_number", "price", "rid", "name", "sale", "size",
+		"total_price", "nm_id", "brand", "status"}
+	fingerprintColumns := []string{"order_uid", "hash", "payload"}
+	outboxColumns := []string{"msg_id", "subject", "order_uid", "headers", "payload", "sent_at"}
+
+	testCases := []struct {
+		name           string
+		requests       []*domain.OrderCreateRequest
+		mock           func()
+		expectedResult []*model.Order
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name:     "OK",
+			requests: []*domain.OrderCreateRequest{request},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectCopyFrom(pgx.Identifier{"orders"}, orderColumns).WillReturnResult(1)
+				mock.ExpectCopyFrom(pgx.Identifier{"transaction"}, paymentColumns).WillReturnResult(1)
+				mock.ExpectCopyFrom(pgx.Identifier{"delivery"}, deliveryColumns).WillReturnResult(1)
+				mock.ExpectCopyFrom(pgx.Identifier{"product"}, productColumns).WillReturnResult(1)
+				mock.ExpectCopyFrom(pgx.Identifier{"order_fingerprint"}, fingerprintColumns).WillReturnResult(1)
+				mock.ExpectQuery(`INSERT INTO order_status_history`).WithArgs(pgxmock.AnyArg()).
+					WillReturnRows(mock.NewRows([]string{"order_uid", "status", "changed_at"}).
+						AddRow(request.OrderUid, "created", createdAt))
+				mock.ExpectCopyFrom(pgx.Identifier{"outbox"}, outboxColumns).WillReturnResult(1)
+				mock.ExpectCommit()
+			},
+			expectedResult: []*model.Order{order},
+		},
+		{
+			name:     "Duplicate order",
+			requests: []*domain.OrderCreateRequest{request},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectCopyFrom(pgx.Identifier{"orders"}, orderColumns).
+					WillReturnError(&pgconn.PgError{Code: domain.CodeErrDuplicateKey})
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			errMsg:  "order already exists",
+		},
+		{
+			name: "Invalid date created",
+			requests: []*domain.OrderCreateRequest{{
+				OrderUid:    request.OrderUid,
+				Payment:     request.Payment,
+				DateCreated: "yesterday",
+			}},
+			mock:    func() {},
+			wantErr: true,
+			errMsg:  "invalid value",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			storage := newOrderStorage(mock, newUserStorage(mock), domain.CustomerPolicyStrict)
+			result, err := storage.CreateBatch(context.Background(), test.requests)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestParseDateCreated(t *testing.T) {
+	expected := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	for _, value := range []string{"2021-11-26T06:22:19Z", "2021-11-26 06:22:19 +0000 UTC"} {
+		result, err := parseDateCreated(value)
+		assert.NoError(t, err)
+		assert.True(t, expected.Equal(*result), value)
+	}
+
+	result, err := parseDateCreated("")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,162 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/dany-ykl/logger"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+type cacheRepairStorage struct {
+	pool pool
+}
+
+func newCacheRepairStorage(pool pool) *cacheRepairStorage {
+	return &cacheRepairStorage{pool: pool}
+}
+
+// CreateCacheRepair записать заказ, который не удалось записать в кэш. Повторная запись
+// заказа обновляет снимок заказа и назначает повтор сразу, дата первой ошибки сохраняется
+func (c *cacheRepairStorage) CreateCacheRepair(ctx context.Context, order *model.Order, cause string) error {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return common.WrapError{Err: err, Msg: "fail to marshal order"}
+	}
+
+	query := `
+		INSERT INTO cache_repair(order_uid, payload, last_error)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (order_uid) DO UPDATE
+		SET payload=EXCLUDED.payload, last_error=EXCLUDED.last_error, next_attempt_at=now()
+	`
+
+	if _, err := c.pool.Exec(ctx, query, order.OrderUid, payload, cause); err != nil {
+		return common.WrapError{Err: err, Msg: "fail to create cache repair"}
+	}
+
+	return nil
+}
+
+// ProcessCacheRepairs выбрать до limit заказов, время повтора которых наступило, и передать их
+// в repair. Записанные в кэш заказы удаляются, для остальных следующий повтор назначается
+// через backoff от количества попыток. Вернуть количество записанных и неудачных заказов
+func (c *cacheRepairStorage) ProcessCacheRepairs(ctx context.Context, limit int,
+	repair func(ctx context.Context, order *model.Order) error,
+	backoff func(attempts int) time.Duration) (int, int, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, common.WrapError{Err: err, Msg: "fail to create transaction"}
+	}
+	defer tx.Rollback(context.Background())
+
+	repairs, err := c.getDueRepairs(ctx, tx, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(repairs) == 0 {
+		return 0, 0, nil
+	}
+
+	querySetFailed := `
+		UPDATE cache_repair SET attempts=attempts+1, last_error=$2, next_attempt_at=$3 WHERE order_uid=$1
+	`
+
+	repaired := make([]string, 0, len(repairs))
+	failed := 0
+	for _, item := range repairs {
+		if err := repair(ctx, item.Order); err != nil {
+			failed++
+			attempts := item.Attempts + 1
+			logger.Warn("fail to repair cached order", zap.String("orderUID", item.OrderUid),
+				zap.Int("attempts", attempts), zap.Error(err))
+
+			nextAttemptAt := time.Now().Add(backoff(attempts))
+			if _, err := tx.Exec(ctx, querySetFailed, item.OrderUid, err.Error(), nextAttemptAt); err != nil {
+				return 0, 0, common.WrapError{Err: err, Msg: "fail to update cache repair"}
+			}
+			continue
+		}
+		repaired = append(repaired, item.OrderUid)
+	}
+
+	if len(repaired) != 0 {
+		queryDelete := `
+			DELETE FROM cache_repair WHERE order_uid = ANY($1::text[]::uuid[])
+		`
+		if _, err := tx.Exec(ctx, queryDelete, repaired); err != nil {
+			return 0, 0, common.WrapError{Err: err, Msg: "fail to delete cache repairs"}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, common.WrapError{Err: err, Msg: "fail to commit transaction"}
+	}
+
+	return len(repaired), failed, nil
+}
+
+// getDueRepairs вернуть заказы, время повтора которых наступило
+func (c *cacheRepairStorage) getDueRepairs(ctx context.Context, tx pgx.Tx, limit int) ([]*domain.CacheRepair, error) {
+	query := `
+		SELECT order_uid::text, payload, attempts, created_at
+		FROM cache_repair
+		WHERE next_attempt_at <= now()
+		ORDER BY next_attempt_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get cache repairs"}
+	}
+	defer rows.Close()
+
+	var repairs []*domain.CacheRepair
+	for rows.Next() {
+		var (
+			item    domain.CacheRepair
+			payload []byte
+		)
+		if err := rows.Scan(&item.OrderUid, &payload, &item.Attempts, &item.CreatedAt); err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+
+		if err := json.Unmarshal(payload, &item.Order); err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to unmarshal cached order"}
+		}
+		repairs = append(repairs, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get cache repairs"}
+	}
+
+	return repairs, nil
+}
+
+// GetCacheLag количество заказов, ожидающих записи в кэш, и дата самой старой неудачной записи
+func (c *cacheRepairStorage) GetCacheLag(ctx context.Context) (*domain.CacheLag, error) {
+	query := `
+		SELECT count(*), min(created_at) FROM cache_repair
+	`
+
+	var (
+		lag    domain.CacheLag
+		oldest *time.Time
+	)
+	if err := c.pool.QueryRow(ctx, query).Scan(&lag.Pending, &oldest); err != nil {
+		return &domain.CacheLag{}, common.WrapError{Err: err, Msg: "fail to get cache lag"}
+	}
+	if oldest != nil {
+		lag.Oldest = *oldest
+	}
+
+	return &lag, nil
+}
@@ -0,0 +1,147 @@
+package psql
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/libs/model"
+)
+
+func TestCreateCacheRepair(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newCacheRepairStorage(mock)
+	order := &model.Order{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"}
+
+	mock.ExpectExec(`INSERT INTO cache_repair`).
+		WithArgs(order.OrderUid, pgxmock.AnyArg(), "redis: connection refused").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, storage.CreateCacheRepair(context.Background(), order, "redis: connection refused"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessCacheRepairs(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newCacheRepairStorage(mock)
+
+	selectRepairsQuery := `SELECT order_uid::text, payload, attempts, created_at`
+	setFailedQuery := `UPDATE cache_repair SET attempts=attempts\+1`
+	deleteQuery := `DELETE FROM cache_repair`
+	columns := []string{"order_uid", "payload", "attempts", "created_at"}
+	createdAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	testCases := []struct {
+		name             string
+		mock             func()
+		repair           func(ctx context.Context, order *model.Order) error
+		expectedRepaired int
+		expectedFailed   int
+		wantErr          bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectRepairsQuery).WithArgs(10).
+					WillReturnRows(mock.NewRows(columns).
+						AddRow("1", []byte(`{"order_uid":"1"}`), 0, createdAt).
+						AddRow("2", []byte(`{"order_uid":"2"}`), 2, createdAt))
+				mock.ExpectExec(setFailedQuery).WithArgs("2", "redis: connection refused", pgxmock.AnyArg()).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(deleteQuery).WithArgs([]string{"1"}).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectCommit()
+			},
+			repair: func(ctx context.Context, order *model.Order) error {
+				if order.OrderUid == "2" {
+					return errors.New("redis: connection refused")
+				}
+				return nil
+			},
+			expectedRepaired: 1,
+			expectedFailed:   1,
+		},
+		{
+			name: "Nothing is due",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectRepairsQuery).WithArgs(10).WillReturnRows(mock.NewRows(columns))
+				mock.ExpectRollback()
+			},
+			repair: func(ctx context.Context, order *model.Order) error {
+				t.Error("unexpected repair")
+				return nil
+			},
+		},
+		{
+			name: "Error from database",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectRepairsQuery).WithArgs(10).WillReturnError(errors.New("error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	backoff := func(attempts int) time.Duration {
+		assert.Equal(t, 3, attempts)
+		return time.Second
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			repaired, failed, err := storage.ProcessCacheRepairs(context.Background(), 10, test.repair, backoff)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedRepaired, repaired)
+			assert.Equal(t, test.expectedFailed, failed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetCacheLag(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newCacheRepairStorage(mock)
+	oldest := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	query := `SELECT count\(\*\), min\(created_at\) FROM cache_repair`
+
+	mock.ExpectQuery(query).WillReturnRows(mock.NewRows([]string{"count", "min"}).AddRow(2, &oldest))
+	lag, err := storage.GetCacheLag(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lag.Pending)
+	assert.Equal(t, oldest, lag.Oldest)
+
+	mock.ExpectQuery(query).WillReturnRows(mock.NewRows([]string{"count", "min"}).AddRow(0, nil))
+	lag, err = storage.GetCacheLag(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, lag.Pending)
+	assert.True(t, lag.Oldest.IsZero())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,51 @@
+package psql
+
+import (
+	"context"
+	"github.com/dany-ykl/tracer"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+)
+
+// createFingerprint сохранение отпечатка принятого заказа
+func (o *orderStorage) createFingerprint(ctx context.Context, tx pgx.Tx, request *domain.OrderCreateRequest) error {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-insert-fingerprint")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
+	fingerprint, err := domain.NewOrderFingerprint(request)
+	if err != nil {
+		return common.WrapError{Err: err, Msg: "fail to calculate order fingerprint"}
+	}
+
+	query := `
+		INSERT INTO order_fingerprint(order_uid, hash, payload)
+		VALUES ($1, $2, $3)
+	`
+
+	if _, err := tx.Exec(ctx, query, fingerprint.OrderUid, fingerprint.Hash, fingerprint.Payload); err != nil {
+		return common.WrapError{Err: err, Msg: "fail to create order fingerprint"}
+	}
+
+	return nil
+}
+
+// GetFingerprint вернуть отпечаток принятого заказа по id
+func (o *orderStorage) GetFingerprint(ctx context.Context, orderUid string) (*domain.OrderFingerprint, error) {
+	query := `
+		SELECT order_uid, hash, payload FROM order_fingerprint WHERE order_uid=$1
+	`
+
+	var fingerprint domain.OrderFingerprint
+	if err := o.pool.QueryRow(ctx, query, orderUid).Scan(&fingerprint.OrderUid, &fingerprint.Hash, &fingerprint.Payload); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &domain.OrderFingerprint{}, common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: "order fingerprint does not exists"}
+		}
+		return &domain.OrderFingerprint{}, common.WrapError{Err: err, Msg: "fail to get order fingerprint"}
+	}
+
+	return &fingerprint, nil
+}
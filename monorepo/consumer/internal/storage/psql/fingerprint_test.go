@@ -0,0 +1,71 @@
+package psql
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/consumer/internal/domain"
+)
+
+func TestGetFingerprint(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newOrderStorage(mock, newUserStorage(mock), domain.CustomerPolicyStrict)
+
+	testCases := []struct {
+		name      string
+		mockInput struct {
+			orderUid string
+		}
+		mock           func(orderUid string)
+		expectedResult *domain.OrderFingerprint
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name:      "OK",
+			mockInput: struct{ orderUid string }{orderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			mock: func(orderUid string) {
+				rows := mock.NewRows([]string{"order_uid", "hash", "payload"})
+				rows.AddRow(orderUid, "hash", []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f"}`))
+				mock.ExpectQuery("SELECT order_uid, hash, payload FROM order_fingerprint").WithArgs(orderUid).WillReturnRows(rows)
+			},
+			expectedResult: &domain.OrderFingerprint{
+				OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+				Hash:     "hash",
+				Payload:  []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f"}`),
+			},
+		},
+		{
+			name:      "Fingerprint does not exists",
+			mockInput: struct{ orderUid string }{orderUid: "5d110e48-9e6b-4928-b436-14194b30d54f"},
+			mock: func(orderUid string) {
+				rows := mock.NewRows([]string{"order_uid", "hash", "payload"})
+				mock.ExpectQuery("SELECT order_uid, hash, payload FROM order_fingerprint").WithArgs(orderUid).WillReturnRows(rows)
+			},
+			expectedResult: &domain.OrderFingerprint{},
+			wantErr:        true,
+			errMsg:         "order does not exists",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.mockInput.orderUid)
+
+			result, err := storage.GetFingerprint(context.Background(), test.mockInput.orderUid)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+		})
+	}
+}
@@ -3,30 +3,102 @@ package psql
 import (
 	"context"
 	"fmt"
-	"github.com/jackc/pgconn"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 	"wb_test_task/consumer/internal/common"
 	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/consumer/internal/metrics"
 	"wb_test_task/libs/model"
 )
 
 type orderStorage struct {
-	pool pool
+	pool           pool
+	users          *userStorage
+	customerPolicy domain.CustomerPolicy
+	// outboxSent записывать события outbox опубликованными: при replay события order.created
+	// уже были опубликованы при первой обработке и relay не должен публиковать их повторно
+	outboxSent bool
 }
 
-func newOrderStorage(pool pool) *orderStorage {
-	return &orderStorage{pool: pool}
+func newOrderStorage(pool pool, users *userStorage, customerPolicy domain.CustomerPolicy) *orderStorage {
+	return &orderStorage{pool: pool, users: users, customerPolicy: customerPolicy}
 }
 
 // Create создание заказа
 func (o *orderStorage) Create(ctx context.Context, request *domain.OrderCreateRequest) (*model.Order, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-create-order")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
 	tx, err := o.pool.Begin(ctx)
 	if err != nil {
 		return &model.Order{}, common.WrapError{Err: err, Msg: "fail to create transaction"}
 	}
 	defer tx.Rollback(context.Background())
 
+	// создание покупателя
+	if err := o.provisionCustomer(ctx, tx, request); err != nil {
+		return &model.Order{}, err
+	}
+
+	// создание заказа
+	if err := o.createOrder(ctx, tx, request); err != nil {
+		return &model.Order{}, err
+	}
+
+	// создание транзакции
+	if err := o.createPayment(ctx, tx, request); err != nil {
+		return &model.Order{}, err
+	}
+
+	// создание доставки
+	if err := o.createDelivery(ctx, tx, request); err != nil {
+		return &model.Order{}, err
+	}
+
+	// создание продуктов
+	if err := o.createProducts(ctx, tx, request); err != nil {
+		return &model.Order{}, err
+	}
+
+	// сохранение отпечатка принятого заказа
+	if err := o.createFingerprint(ctx, tx, request); err != nil {
+		return &model.Order{}, err
+	}
+
+	// запись начального статуса заказа
+	change, err := o.createStatusHistory(ctx, tx, request)
+	if err != nil {
+		return &model.Order{}, err
+	}
+
+	order := request.ToOrder()
+	order.Status = change.Status
+	order.Timeline = []*model.StatusChange{change}
+
+	// событие order.created публикуется relay только после фиксации транзакции
+	if err := o.createOutboxEvent(ctx, tx, order); err != nil {
+		return &model.Order{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return &model.Order{}, common.WrapError{Err: err, Msg: "fail to commit transaction"}
+	}
+
+	return order, nil
+}
+
+// createOrder создание записи заказа
+func (o *orderStorage) createOrder(ctx context.Context, tx pgx.Tx, request *domain.OrderCreateRequest) error {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-insert-order")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
 	queryOrderCreate := `
 		INSERT INTO orders(order_uid, track_number, entry, locale, internal_signature,
 		                   customer_id, delivery_service, shardkey, sm_id, oof_shard,
@@ -34,7 +106,7 @@ func (o *orderStorage) Create(ctx context.Context, request *domain.OrderCreateRe
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	_, err = tx.Exec(ctx, queryOrderCreate, request.OrderUid, request.TrackNumber, request.Entry, request.Locale,
+	_, err := tx.Exec(ctx, queryOrderCreate, request.OrderUid, request.TrackNumber, request.Entry, request.Locale,
 		request.InternalSignature, request.CustomerID, request.DeliveryService, request.ShardKey, request.SmID,
 		request.OofShard, request.DateCreated)
 	if err != nil {
@@ -42,45 +114,55 @@ func (o *orderStorage) Create(ctx context.Context, request *domain.OrderCreateRe
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
 			case domain.CodeErrDuplicateKey:
-				return &model.Order{}, common.WrapError{Err: domain.ErrOrderAlreadyExists,
+				return common.WrapError{Err: domain.ErrOrderAlreadyExists,
 					Msg: fmt.Sprintf("hint: %s", pgErr.Detail),
 				}
 			case domain.CodeErrConstraintLenValue:
-				return &model.Order{}, common.WrapError{Err: domain.ErrInvalidOrderValue, Msg: pgErr.Message}
+				return common.WrapError{Err: domain.ErrInvalidOrderValue, Msg: pgErr.Message}
 			case domain.CodeErrInvalidSyntax:
-				return &model.Order{}, common.WrapError{Err: domain.ErrInvalidValue, Msg: pgErr.Message}
+				return common.WrapError{Err: domain.ErrInvalidValue, Msg: pgErr.Message}
 			case domain.CodeErrForeignKey:
-				return &model.Order{}, common.WrapError{Err: domain.ErrUserDoesNotExists, Msg: domain.ErrUserDoesNotExists.Error()}
+				metrics.CustomersMissing.WithLabelValues(string(o.customerPolicy)).Inc()
+				return common.WrapError{Err: domain.ErrUserDoesNotExists, Msg: domain.ErrUserDoesNotExists.Error()}
 			}
 		}
 
-		return &model.Order{}, common.WrapError{Err: err, Msg: "fail to create order"}
+		return common.WrapError{Err: err, Msg: "fail to create order"}
 	}
 
-	// создание транзакции
-	if err := o.createPayment(ctx, tx, request); err != nil {
-		return &model.Order{}, err
-	}
+	return nil
+}
 
-	// создание доставки
-	if err := o.createDelivery(ctx, tx, request); err != nil {
-		return &model.Order{}, err
+// provisionCustomer создание покупателя заказа, если включена политика auto_create
+func (o *orderStorage) provisionCustomer(ctx context.Context, tx pgx.Tx, request *domain.OrderCreateRequest) error {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-provision-customer")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
+	if o.customerPolicy != domain.CustomerPolicyAutoCreate {
+		return nil
 	}
 
-	// создание продуктов
-	if err := o.createProducts(ctx, tx, request); err != nil {
-		return &model.Order{}, err
+	created, err := o.users.CreateIfNotExists(ctx, tx, request.CustomerID)
+	if err != nil {
+		return err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return &model.Order{}, common.WrapError{Err: err, Msg: "fail to commit transaction"}
+	if created {
+		metrics.CustomersAutoCreated.Inc()
+		logger.Info("customer auto-created", zap.String("customerID", request.CustomerID),
+			zap.String("orderUID", request.OrderUid))
 	}
 
-	return mapOrderCreateRequestToOrder(request), nil
+	return nil
 }
 
 // createPayment создание транзакции
 func (o *orderStorage) createPayment(ctx context.Context, tx pgx.Tx, request *domain.OrderCreateRequest) error {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-insert-transaction")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
 	queryOrderPaymentCreate := `
 		INSERT INTO transaction(id, request_id, currency, provider, amount, payment_dt,
 		                        bank, delivery_cost, goods_total, custom_fee)
@@ -115,6 +197,10 @@ func (o *orderStorage) createPayment(ctx context.Context, tx pgx.Tx, request *do
 
 // createDelivery создание доставки
 func (o *orderStorage) createDelivery(ctx context.Context, tx pgx.Tx, request *domain.OrderCreateRequest) error {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-insert-delivery")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
 	queryOrderDeliveryCreate := `
 		INSERT INTO delivery(order_uid, name, phone, zip, city, address, region, email)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
@@ -144,6 +230,10 @@ func (o *orderStorage) createDelivery(ctx context.Context, tx pgx.Tx, request *d
 
 // createProducts создание продукта
 func (o *orderStorage) createProducts(ctx context.Context, tx pgx.Tx, request *domain.OrderCreateRequest) error {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-copy-products")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
 	rows := make([][]interface{}, 0, len(request.Items))
 
 	for _, product := range request.Items {
@@ -178,22 +268,3 @@ func (o *orderStorage) createProducts(ctx context.Context, tx pgx.Tx, request *d
 
 	return nil
 }
-
-func mapOrderCreateRequestToOrder(request *domain.OrderCreateRequest) *model.Order {
-	return &model.Order{
-		OrderUid:          request.OrderUid,
-		TrackNumber:       request.TrackNumber,
-		Entry:             request.Entry,
-		Delivery:          request.Delivery,
-		Payment:           request.Payment,
-		Items:             request.Items,
-		Locale:            request.Locale,
-		InternalSignature: request.InternalSignature,
-		CustomerID:        request.CustomerID,
-		DeliveryService:   request.DeliveryService,
-		ShardKey:          request.ShardKey,
-		SmID:              request.SmID,
-		DateCreated:       request.DateCreated,
-		OofShard:          request.OofShard,
-	}
-}
@@ -2,19 +2,35 @@ package psql
 
 import (
 	"context"
+	"github.com/dany-ykl/logger"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
+	"log"
 	"testing"
+	"time"
 	"wb_test_task/consumer/internal/domain"
 	"wb_test_task/libs/model"
 )
 
+func init() {
+	if err := logger.InitLogger(logger.Config{
+		Namespace:   "test.psql",
+		Development: true,
+		Level:       logger.InfoLevel,
+	}); err != nil {
+		log.Fatalln(err)
+	}
+}
+
 func TestGetByID(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Error(err)
 	}
+
+	createdAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
 	defer mock.Close()
 
 	createOrderRequest := &domain.OrderCreateRequest{
@@ -112,30 +128,109 @@ func TestGetByID(t *testing.T) {
 		DeliveryService:   "meest",
 		ShardKey:          "",
 		SmID:              99,
-		DateCreated:       "2021-11-26 06:22:19 +0000 UTC",
+		DateCreated:       "2021-11-26T06:22:19Z",
 		OofShard:          "1",
+		Status:            "created",
+		Timeline:          []*model.StatusChange{{Status: "created", ChangedAt: createdAt}},
 	}
 
-	storage := newOrderStorage(mock)
+	fingerprint, err := domain.NewOrderFingerprint(createOrderRequest)
+	if err != nil {
+		t.Error(err)
+	}
 
+	createUserQuery := `INSERT INTO users`
+	createFingerprintQuery := `INSERT INTO order_fingerprint`
 	createOrderQuery := `INSERT INTO orders`
 	createTransactionQuery := `INSERT INTO transaction`
 	createDeliveryQuery := `INSERT INTO delivery`
+	createStatusHistoryQuery := `INSERT INTO order_status_history`
+	createOutboxQuery := `INSERT INTO outbox`
 
 	productRows := []string{"chrt_id", "track_number", "price", "rid", "name", "sale", "size",
 		"total_price", "nm_id", "brand", "status"}
 
 	testCases := []struct {
 		name           string
+		policy         domain.CustomerPolicy
+		outboxSent     bool
 		mock           func()
 		expectedResult *model.Order
 		wantErr        bool
 		errMsg         string
 	}{
 		{
-			name: "OK",
+			name:   "OK",
+			policy: domain.CustomerPolicyStrict,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(createOrderQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK3", "WBIL", "en", "", "test", "meest",
+					"", 99, "1", "2021-11-26 06:22:19 +0000 UTC").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				mock.ExpectExec(createTransactionQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "5d110e48-9e6b-4928-b436-14194b30d54f",
+					"USD", "wbpay", float64(1817), int64(1637907727), "alpha", float64(1500), 317, 0).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				mock.ExpectExec(createDeliveryQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "Test Testov", "+9720000000", "2639809", "Kiryat Mozkin",
+					"Ploshad Mira 15", "Kraiot", "test@gmail.com").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				mock.ExpectCopyFrom(pgx.Identifier{"product"}, productRows).WillReturnResult(1)
+				mock.ExpectExec(createFingerprintQuery).WithArgs(fingerprint.OrderUid, fingerprint.Hash, fingerprint.Payload).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery(createStatusHistoryQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f").
+					WillReturnRows(pgxmock.NewRows([]string{"status", "changed_at"}).AddRow("created", createdAt))
+				mock.ExpectExec(createOutboxQuery).WithArgs("order.created:5d110e48-9e6b-4928-b436-14194b30d54f", "order.created",
+					"5d110e48-9e6b-4928-b436-14194b30d54f", pgxmock.AnyArg(), pgxmock.AnyArg(), (*time.Time)(nil)).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			expectedResult: order,
+		},
+		{
+			name:       "OK. Replay, outbox event is written as sent",
+			policy:     domain.CustomerPolicyStrict,
+			outboxSent: true,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(createOrderQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK3", "WBIL", "en", "", "test", "meest",
+					"", 99, "1", "2021-11-26 06:22:19 +0000 UTC").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				mock.ExpectExec(createTransactionQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "5d110e48-9e6b-4928-b436-14194b30d54f",
+					"USD", "wbpay", float64(1817), int64(1637907727), "alpha", float64(1500), 317, 0).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				mock.ExpectExec(createDeliveryQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "Test Testov", "+9720000000", "2639809", "Kiryat Mozkin",
+					"Ploshad Mira 15", "Kraiot", "test@gmail.com").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				mock.ExpectCopyFrom(pgx.Identifier{"product"}, productRows).WillReturnResult(1)
+				mock.ExpectExec(createFingerprintQuery).WithArgs(fingerprint.OrderUid, fingerprint.Hash, fingerprint.Payload).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery(createStatusHistoryQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f").
+					WillReturnRows(pgxmock.NewRows([]string{"status", "changed_at"}).AddRow("created", createdAt))
+				mock.ExpectExec(createOutboxQuery).WithArgs("order.created:5d110e48-9e6b-4928-b436-14194b30d54f", "order.created",
+					"5d110e48-9e6b-4928-b436-14194b30d54f", pgxmock.AnyArg(), pgxmock.AnyArg(), sentAtArg{}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			expectedResult: order,
+		},
+		{
+			name:   "Customer does not exists. Strict policy",
+			policy: domain.CustomerPolicyStrict,
 			mock: func() {
 				mock.ExpectBegin()
+				mock.ExpectExec(createOrderQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK3", "WBIL", "en", "", "test", "meest",
+					"", 99, "1", "2021-11-26 06:22:19 +0000 UTC").WillReturnError(&pgconn.PgError{Code: domain.CodeErrForeignKey})
+				mock.ExpectRollback()
+			},
+			expectedResult: &model.Order{},
+			wantErr:        true,
+			errMsg:         "user does not exists",
+		},
+		{
+			name:   "OK. Auto create customer",
+			policy: domain.CustomerPolicyAutoCreate,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(createUserQuery).WithArgs("test").WillReturnResult(pgxmock.NewResult("INSERT", 1))
 				mock.ExpectExec(createOrderQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f", "WBILMTESTTRACK3", "WBIL", "en", "", "test", "meest",
 					"", 99, "1", "2021-11-26 06:22:19 +0000 UTC").WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
@@ -146,6 +241,13 @@ func TestGetByID(t *testing.T) {
 					"Ploshad Mira 15", "Kraiot", "test@gmail.com").WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 				mock.ExpectCopyFrom(pgx.Identifier{"product"}, productRows).WillReturnResult(1)
+				mock.ExpectExec(createFingerprintQuery).WithArgs(fingerprint.OrderUid, fingerprint.Hash, fingerprint.Payload).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery(createStatusHistoryQuery).WithArgs("5d110e48-9e6b-4928-b436-14194b30d54f").
+					WillReturnRows(pgxmock.NewRows([]string{"status", "changed_at"}).AddRow("created", createdAt))
+				mock.ExpectExec(createOutboxQuery).WithArgs("order.created:5d110e48-9e6b-4928-b436-14194b30d54f", "order.created",
+					"5d110e48-9e6b-4928-b436-14194b30d54f", pgxmock.AnyArg(), pgxmock.AnyArg(), (*time.Time)(nil)).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 				mock.ExpectCommit()
 			},
 			expectedResult: order,
@@ -156,6 +258,9 @@ func TestGetByID(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			test.mock()
 
+			storage := newOrderStorage(mock, newUserStorage(mock), test.policy)
+			storage.outboxSent = test.outboxSent
+
 			result, err := storage.Create(context.Background(), createOrderRequest)
 
 			if test.wantErr {
@@ -165,6 +270,15 @@ func TestGetByID(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, test.expectedResult, result)
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
+
+// sentAtArg аргумент sent_at события outbox, записанного опубликованным
+type sentAtArg struct{}
+
+func (sentAtArg) Match(value interface{}) bool {
+	sentAt, ok := value.(*time.Time)
+	return ok && sentAt != nil
+}
@@ -0,0 +1,181 @@
+package psql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/dany-ykl/logger"
+	"github.com/dany-ykl/tracer"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"time"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/envelope"
+	"wb_test_task/libs/model"
+	"wb_test_task/libs/tracing"
+)
+
+// outboxSource сервис-источник событий outbox
+const outboxSource = "consumer"
+
+type outboxStorage struct {
+	pool pool
+}
+
+func newOutboxStorage(pool pool) *outboxStorage {
+	return &outboxStorage{pool: pool}
+}
+
+// newOrderCreatedEvent событие order.created с контекстом трассировки транзакции заказа
+func newOrderCreatedEvent(ctx context.Context, order *model.Order) (*domain.OutboxEvent, error) {
+	header := map[string][]string{}
+	tracing.Inject(ctx, header)
+	envelope.Metadata{
+		SchemaVersion: domain.OrderCreatedSchemaVersion,
+		Source:        outboxSource,
+		ProducedAt:    time.Now(),
+	}.SetHeader(header)
+
+	event, err := domain.NewOrderCreatedEvent(order, header)
+	if err != nil {
+		return &domain.OutboxEvent{}, common.WrapError{Err: err, Msg: "fail to create order created event"}
+	}
+
+	return event, nil
+}
+
+// createOutboxEvent запись события order.created в транзакции заказа
+func (o *orderStorage) createOutboxEvent(ctx context.Context, tx pgx.Tx, order *model.Order) error {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-insert-outbox-event")
+	span.SetAttributes(attribute.String("order-id", order.OrderUid))
+	defer span.End()
+
+	event, err := newOrderCreatedEvent(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	header, err := json.Marshal(event.Header)
+	if err != nil {
+		return common.WrapError{Err: err, Msg: "fail to marshal outbox event header"}
+	}
+
+	query := `
+		INSERT INTO outbox(msg_id, subject, order_uid, headers, payload, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if _, err := tx.Exec(ctx, query, event.MsgID, event.Subject, event.OrderUid, header, event.Payload, o.outboxSentAt()); err != nil {
+		return common.WrapError{Err: err, Msg: "fail to create outbox event"}
+	}
+
+	return nil
+}
+
+// outboxSentAt дата публикации нового события outbox, nil - событие опубликует relay
+func (o *orderStorage) outboxSentAt() *time.Time {
+	if !o.outboxSent {
+		return nil
+	}
+
+	sentAt := time.Now().UTC()
+	return &sentAt
+}
+
+// ProcessOutbox выбрать до limit неопубликованных событий, передать их в publish и отметить
+// опубликованные. События блокируются до конца транзакции, поэтому несколько relay
+// не публикуют одно событие одновременно. Вернуть количество опубликованных и неудачных событий
+func (o *outboxStorage) ProcessOutbox(ctx context.Context, limit int,
+	publish func(ctx context.Context, event *domain.OutboxEvent) error) (int, int, error) {
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, common.WrapError{Err: err, Msg: "fail to create transaction"}
+	}
+	defer tx.Rollback(context.Background())
+
+	events, err := o.getPendingEvents(ctx, tx, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(events) == 0 {
+		return 0, 0, nil
+	}
+
+	querySetFailed := `
+		UPDATE outbox SET attempts=attempts+1, last_error=$2 WHERE id=$1
+	`
+
+	sent := make([]int64, 0, len(events))
+	failed := 0
+	for _, event := range events {
+		if err := publish(ctx, event); err != nil {
+			failed++
+			logger.Warn("fail to publish outbox event", zap.Int64("id", event.ID), zap.String("msgID", event.MsgID),
+				zap.Int("attempts", event.Attempts+1), zap.Error(err))
+
+			if _, err := tx.Exec(ctx, querySetFailed, event.ID, err.Error()); err != nil {
+				return 0, 0, common.WrapError{Err: err, Msg: "fail to update outbox event"}
+			}
+			continue
+		}
+		sent = append(sent, event.ID)
+	}
+
+	if len(sent) != 0 {
+		querySetSent := `
+			UPDATE outbox SET sent_at=now() WHERE id = ANY($1)
+		`
+		if _, err := tx.Exec(ctx, querySetSent, sent); err != nil {
+			return 0, 0, common.WrapError{Err: err, Msg: "fail to mark outbox events as sent"}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, common.WrapError{Err: err, Msg: "fail to commit transaction"}
+	}
+
+	return len(sent), failed, nil
+}
+
+// getPendingEvents вернуть неопубликованные события в порядке записи
+func (o *outboxStorage) getPendingEvents(ctx context.Context, tx pgx.Tx, limit int) ([]*domain.OutboxEvent, error) {
+	query := `
+		SELECT id, msg_id, subject, order_uid::text, headers, payload, attempts
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get outbox events"}
+	}
+	defer rows.Close()
+
+	var events []*domain.OutboxEvent
+	for rows.Next() {
+		var (
+			event  domain.OutboxEvent
+			header []byte
+		)
+		if err := rows.Scan(&event.ID, &event.MsgID, &event.Subject, &event.OrderUid, &header,
+			&event.Payload, &event.Attempts); err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+
+		if err := json.Unmarshal(header, &event.Header); err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to unmarshal outbox event header"}
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get outbox events"}
+	}
+
+	return events, nil
+}
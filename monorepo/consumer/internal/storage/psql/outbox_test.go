@@ -0,0 +1,97 @@
+package psql
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"wb_test_task/consumer/internal/domain"
+)
+
+func TestProcessOutbox(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newOutboxStorage(mock)
+
+	selectEventsQuery := `SELECT id, msg_id, subject, order_uid::text, headers, payload, attempts`
+	setFailedQuery := `UPDATE outbox SET attempts=attempts\+1`
+	setSentQuery := `UPDATE outbox SET sent_at=now\(\)`
+	columns := []string{"id", "msg_id", "subject", "order_uid", "headers", "payload", "attempts"}
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		publish        func(ctx context.Context, event *domain.OutboxEvent) error
+		expectedSent   int
+		expectedFailed int
+		wantErr        bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectEventsQuery).WithArgs(10).
+					WillReturnRows(mock.NewRows(columns).
+						AddRow(int64(1), "order.created:1", "order.created", "1", []byte(`{"Schema-Version":["1"]}`), []byte(`{}`), 0).
+						AddRow(int64(2), "order.created:2", "order.created", "2", []byte(`{}`), []byte(`{}`), 2))
+				mock.ExpectExec(setFailedQuery).WithArgs(int64(2), "nats: timeout").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(setSentQuery).WithArgs([]int64{1}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectCommit()
+			},
+			publish: func(ctx context.Context, event *domain.OutboxEvent) error {
+				if event.ID == 2 {
+					return errors.New("nats: timeout")
+				}
+				assert.Equal(t, []string{"1"}, event.Header["Schema-Version"])
+				return nil
+			},
+			expectedSent:   1,
+			expectedFailed: 1,
+		},
+		{
+			name: "Outbox is empty",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectEventsQuery).WithArgs(10).WillReturnRows(mock.NewRows(columns))
+				mock.ExpectRollback()
+			},
+			publish: func(ctx context.Context, event *domain.OutboxEvent) error {
+				t.Error("unexpected publish")
+				return nil
+			},
+		},
+		{
+			name: "Error from database",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectEventsQuery).WithArgs(10).WillReturnError(errors.New("error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			sent, failed, err := storage.ProcessOutbox(context.Background(), 10, test.publish)
+
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedSent, sent)
+			assert.Equal(t, test.expectedFailed, failed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
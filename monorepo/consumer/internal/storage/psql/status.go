@@ -0,0 +1,163 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/tracer"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+	"wb_test_task/consumer/internal/common"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+// createStatusHistory запись начального статуса заказа в историю
+func (o *orderStorage) createStatusHistory(ctx context.Context, tx pgx.Tx, request *domain.OrderCreateRequest) (*model.StatusChange, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-insert-status-history")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
+	query := `
+		INSERT INTO order_status_history(order_uid, status, changed_at)
+		SELECT order_uid, status, COALESCE(date_created, now()) FROM orders WHERE order_uid=$1
+		RETURNING status, changed_at
+	`
+
+	var change model.StatusChange
+	if err := tx.QueryRow(ctx, query, request.OrderUid).Scan(&change.Status, &change.ChangedAt); err != nil {
+		return &model.StatusChange{}, common.WrapError{Err: err, Msg: "fail to create order status history"}
+	}
+
+	return &change, nil
+}
+
+// UpdateStatus изменение статуса заказа с записью в историю, вернуть false,
+// если заказ уже находится в запрошенном статусе
+func (o *orderStorage) UpdateStatus(ctx context.Context, request *domain.OrderStatusUpdateRequest) (bool, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-update-order-status")
+	span.SetAttributes(attribute.String("order-id", request.OrderUid))
+	defer span.End()
+
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return false, common.WrapError{Err: err, Msg: "fail to create transaction"}
+	}
+	defer tx.Rollback(context.Background())
+
+	querySelectStatus := `
+		SELECT status FROM orders WHERE order_uid=$1 FOR UPDATE
+	`
+
+	var current string
+	if err := tx.QueryRow(ctx, querySelectStatus, request.OrderUid).Scan(&current); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: domain.ErrOrderDoesNotExists.Error()}
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == domain.CodeErrInvalidSyntax {
+			return false, common.WrapError{Err: domain.ErrInvalidValue, Msg: pgErr.Message}
+		}
+		return false, common.WrapError{Err: err, Msg: "fail to get order status"}
+	}
+
+	if domain.OrderStatus(current) == request.Status {
+		return false, nil
+	}
+
+	if !domain.OrderStatus(current).CanTransitionTo(request.Status) {
+		return false, common.WrapError{Err: domain.ErrInvalidStatusTransition,
+			Msg: fmt.Sprintf("%s -> %s", current, request.Status)}
+	}
+
+	queryUpdateStatus := `
+		UPDATE orders SET status=$2 WHERE order_uid=$1
+	`
+	if _, err := tx.Exec(ctx, queryUpdateStatus, request.OrderUid, request.Status); err != nil {
+		return false, common.WrapError{Err: err, Msg: "fail to update order status"}
+	}
+
+	var changedAt *time.Time
+	if !request.ChangedAt.IsZero() {
+		changedAt = &request.ChangedAt
+	}
+
+	queryCreateHistory := `
+		INSERT INTO order_status_history(order_uid, status, reason, changed_at)
+		VALUES ($1, $2, NULLIF($3, ''), COALESCE($4, now()))
+	`
+	if _, err := tx.Exec(ctx, queryCreateHistory, request.OrderUid, request.Status, request.Reason, changedAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == domain.CodeErrConstraintLenValue {
+			return false, common.WrapError{Err: domain.ErrInvalidValue, Msg: pgErr.Message}
+		}
+		return false, common.WrapError{Err: err, Msg: "fail to create order status history"}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, common.WrapError{Err: err, Msg: "fail to commit transaction"}
+	}
+
+	return true, nil
+}
+
+// GetStatusHistory вернуть текущий статус заказа и историю статусов. Текущий статус читается
+// из orders: changed_at истории приходит от producer и не определяет последний статус
+func (o *orderStorage) GetStatusHistory(ctx context.Context, orderUid string) (string, []*model.StatusChange, error) {
+	ctx, span := tracer.StartTrace(ctx, "psql-storage-get-status-history")
+	span.SetAttributes(attribute.String("order-id", orderUid))
+	defer span.End()
+
+	query := `
+		SELECT status FROM orders WHERE order_uid=$1
+	`
+
+	var status string
+	if err := o.pool.QueryRow(ctx, query, orderUid).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil, common.WrapError{Err: domain.ErrOrderDoesNotExists, Msg: domain.ErrOrderDoesNotExists.Error()}
+		}
+		return "", nil, common.WrapError{Err: err, Msg: "fail to get order status"}
+	}
+
+	timeline, err := o.getStatusHistory(ctx, o.pool, orderUid)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return status, timeline, nil
+}
+
+// getStatusHistory вернуть историю статусов заказа
+func (o *orderStorage) getStatusHistory(ctx context.Context, tx querier, orderUid string) ([]*model.StatusChange, error) {
+	query := `
+		SELECT status, COALESCE(reason, ''), changed_at
+		FROM order_status_history
+		WHERE order_uid=$1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := tx.Query(ctx, query, orderUid)
+	if err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get order status history"}
+	}
+	defer rows.Close()
+
+	var timeline []*model.StatusChange
+	for rows.Next() {
+		var change model.StatusChange
+		if err := rows.Scan(&change.Status, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, common.WrapError{Err: err, Msg: "fail to scan rows"}
+		}
+		timeline = append(timeline, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, common.WrapError{Err: err, Msg: "fail to get order status history"}
+	}
+
+	return timeline, nil
+}
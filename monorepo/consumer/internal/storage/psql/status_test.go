@@ -0,0 +1,182 @@
+package psql
+
+import (
+	"context"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/consumer/internal/domain"
+	"wb_test_task/libs/model"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newOrderStorage(mock, newUserStorage(mock), domain.CustomerPolicyStrict)
+
+	paidAt := time.Date(2021, 11, 26, 7, 0, 0, 0, time.UTC)
+
+	selectStatusQuery := `SELECT status FROM orders`
+	updateStatusQuery := `UPDATE orders SET status`
+	createHistoryQuery := `INSERT INTO order_status_history`
+
+	testCases := []struct {
+		name           string
+		request        *domain.OrderStatusUpdateRequest
+		mock           func(request *domain.OrderStatusUpdateRequest)
+		expectedChange bool
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			request: &domain.OrderStatusUpdateRequest{
+				OrderUid:  "5d110e48-9e6b-4928-b436-14194b30d54f",
+				Status:    domain.StatusPaid,
+				ChangedAt: paidAt,
+			},
+			mock: func(request *domain.OrderStatusUpdateRequest) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStatusQuery).WithArgs(request.OrderUid).
+					WillReturnRows(mock.NewRows([]string{"status"}).AddRow("created"))
+				mock.ExpectExec(updateStatusQuery).WithArgs(request.OrderUid, request.Status).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(createHistoryQuery).WithArgs(request.OrderUid, request.Status, "", &request.ChangedAt).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectCommit()
+			},
+			expectedChange: true,
+		},
+		{
+			name: "Same status",
+			request: &domain.OrderStatusUpdateRequest{
+				OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+				Status:   domain.StatusPaid,
+			},
+			mock: func(request *domain.OrderStatusUpdateRequest) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStatusQuery).WithArgs(request.OrderUid).
+					WillReturnRows(mock.NewRows([]string{"status"}).AddRow("paid"))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "Invalid transition",
+			request: &domain.OrderStatusUpdateRequest{
+				OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+				Status:   domain.StatusCancelled,
+			},
+			mock: func(request *domain.OrderStatusUpdateRequest) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStatusQuery).WithArgs(request.OrderUid).
+					WillReturnRows(mock.NewRows([]string{"status"}).AddRow("delivered"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			errMsg:  "invalid order status transition",
+		},
+		{
+			name: "Order does not exists",
+			request: &domain.OrderStatusUpdateRequest{
+				OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f",
+				Status:   domain.StatusPaid,
+			},
+			mock: func(request *domain.OrderStatusUpdateRequest) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectStatusQuery).WithArgs(request.OrderUid).
+					WillReturnRows(mock.NewRows([]string{"status"}))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+			errMsg:  "order does not exists",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.request)
+
+			changed, err := storage.UpdateStatus(context.Background(), test.request)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedChange, changed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetStatusHistory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newOrderStorage(mock, newUserStorage(mock), domain.CustomerPolicyStrict)
+
+	orderUid := "5d110e48-9e6b-4928-b436-14194b30d54f"
+	createdAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	selectStatusQuery := `SELECT status FROM orders WHERE order_uid=\$1`
+	selectHistoryQuery := `SELECT status, COALESCE\(reason, ''\), changed_at`
+
+	testCases := []struct {
+		name           string
+		mock           func()
+		expectedStatus string
+		expectedResult []*model.StatusChange
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(selectStatusQuery).WithArgs(orderUid).
+					WillReturnRows(mock.NewRows([]string{"status"}).AddRow("paid"))
+				mock.ExpectQuery(selectHistoryQuery).WithArgs(orderUid).
+					WillReturnRows(mock.NewRows([]string{"status", "reason", "changed_at"}).
+						AddRow("paid", "", createdAt.Add(-time.Hour)).
+						AddRow("created", "", createdAt))
+			},
+			expectedStatus: "paid",
+			expectedResult: []*model.StatusChange{
+				{Status: "paid", ChangedAt: createdAt.Add(-time.Hour)},
+				{Status: "created", ChangedAt: createdAt},
+			},
+		},
+		{
+			name: "Order does not exists",
+			mock: func() {
+				mock.ExpectQuery(selectStatusQuery).WithArgs(orderUid).
+					WillReturnRows(mock.NewRows([]string{"status"}))
+			},
+			wantErr: true,
+			errMsg:  "order does not exists",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			status, result, err := storage.GetStatusHistory(context.Background(), orderUid)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedStatus, status)
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
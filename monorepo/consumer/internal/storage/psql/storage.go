@@ -7,20 +7,38 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"time"
 	"wb_test_task/consumer/internal/config"
+	"wb_test_task/consumer/internal/domain"
 )
 
 type pool interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, sql string, optionsAndArgs ...interface{}) pgx.Row
+	Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error)
+}
+
+// querier общий интерфейс пула и транзакции для чтения строк
+type querier interface {
+	Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error)
+}
+
+// executor общий интерфейс пула и транзакции для выполнения запросов
+type executor interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
 type Storage struct {
-	conn         *pgxpool.Pool
-	OrderStorage *orderStorage
+	conn          *pgxpool.Pool
+	OrderStorage  *orderStorage
+	UserStorage   *userStorage
+	OutboxStorage *outboxStorage
+	// CacheRepairStorage заказы, не записанные в кэш после сохранения в базе
+	CacheRepairStorage *cacheRepairStorage
 }
 
-func New(ctx context.Context, cfg config.PostgresDatabase) (*Storage, error) {
+// New подключиться к базе. replay - заказы записываются повторной обработкой стрима, события outbox
+// записываются уже опубликованными
+func New(ctx context.Context, cfg config.PostgresDatabase, customerPolicy domain.CustomerPolicy, replay bool) (*Storage, error) {
 	connectCfg, err := pgxpool.ParseConfig(cfg.Url)
 	if err != nil {
 		return nil, err
@@ -37,12 +55,29 @@ func New(ctx context.Context, cfg config.PostgresDatabase) (*Storage, error) {
 		return nil, err
 	}
 
+	userStorage := newUserStorage(conn)
+	orderStorage := newOrderStorage(conn, userStorage, customerPolicy)
+	orderStorage.outboxSent = replay
+
 	return &Storage{
-		conn:         conn,
-		OrderStorage: newOrderStorage(conn),
+		conn:               conn,
+		OrderStorage:       orderStorage,
+		UserStorage:        userStorage,
+		OutboxStorage:      newOutboxStorage(conn),
+		CacheRepairStorage: newCacheRepairStorage(conn),
 	}, nil
 }
 
+// Ping проверить соединение с базой
+func (p *Storage) Ping(ctx context.Context) error {
+	return p.conn.Ping(ctx)
+}
+
+// Stat статистика пула соединений
+func (p *Storage) Stat() *pgxpool.Stat {
+	return p.conn.Stat()
+}
+
 func (p *Storage) Shutdown() error {
 	p.conn.Close()
 	return nil
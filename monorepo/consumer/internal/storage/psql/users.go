@@ -40,6 +40,24 @@ func (u *userStorage) Create(ctx context.Context, id string) (*model.User, error
 	return &model.User{ID: id}, nil
 }
 
+// CreateIfNotExists создание пользователя, если его еще нет, вернуть true, если пользователь был создан
+func (u *userStorage) CreateIfNotExists(ctx context.Context, exec executor, id string) (bool, error) {
+	query := `
+		INSERT INTO users(id) VALUES ($1) ON CONFLICT (id) DO NOTHING
+	`
+
+	tag, err := exec.Exec(ctx, query, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == domain.CodeErrConstraintLenValue {
+			return false, common.WrapError{Err: domain.ErrInvalidUserID, Msg: "length of id bigger them 500"}
+		}
+		return false, common.WrapError{Err: err, Msg: "fail to create user"}
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
 func (u *userStorage) GetByID(ctx context.Context, id string) (*model.User, error) {
 	query := `
 		SELECT u.id FROM users u WHERE id=$1
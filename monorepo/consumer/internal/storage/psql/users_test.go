@@ -2,9 +2,11 @@ package psql
 
 import (
 	"context"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"wb_test_task/consumer/internal/domain"
 	"wb_test_task/libs/model"
 )
 
@@ -57,6 +59,72 @@ func TestUserStorage_Create(t *testing.T) {
 	}
 }
 
+func TestUserStorage_CreateIfNotExists(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Error(err)
+	}
+	defer mock.Close()
+
+	storage := newUserStorage(mock)
+
+	testCases := []struct {
+		name      string
+		mockInput struct {
+			id string
+		}
+		mock           func(id string)
+		expectedResult bool
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name:      "OK. User created",
+			mockInput: struct{ id string }{id: "test"},
+			mock: func(id string) {
+				mock.ExpectExec("INSERT INTO users(.+) ON CONFLICT").WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+			expectedResult: true,
+		},
+		{
+			name:      "OK. User already exists",
+			mockInput: struct{ id string }{id: "test"},
+			mock: func(id string) {
+				mock.ExpectExec("INSERT INTO users(.+) ON CONFLICT").WithArgs(id).
+					WillReturnResult(pgxmock.NewResult("INSERT", 0))
+			},
+			expectedResult: false,
+		},
+		{
+			name:      "Invalid user id",
+			mockInput: struct{ id string }{id: "test"},
+			mock: func(id string) {
+				mock.ExpectExec("INSERT INTO users(.+) ON CONFLICT").WithArgs(id).
+					WillReturnError(&pgconn.PgError{Code: domain.CodeErrConstraintLenValue})
+			},
+			expectedResult: false,
+			wantErr:        true,
+			errMsg:         "invalid user id",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.mockInput.id)
+
+			created, err := storage.CreateIfNotExists(context.Background(), mock, test.mockInput.id)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, created)
+		})
+	}
+}
+
 func TestUserStorage_GetByID(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -17,6 +17,7 @@ func New(cfg config.RedisCache) (*Cache, error) {
 		Addr:     cfg.Address,
 		Password: cfg.Password,
 	})
+	conn.AddHook(metricsHook{})
 
 	if cmd := conn.Ping(context.Background()); cmd.Err() != nil {
 		return &Cache{}, cmd.Err()
@@ -29,6 +30,11 @@ func New(cfg config.RedisCache) (*Cache, error) {
 	}, nil
 }
 
+// Ping проверить соединение с redis
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.conn.Ping(ctx).Err()
+}
+
 func (c *Cache) Shutdown() error {
 	if err := c.conn.Close(); err != nil {
 		return err
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/dany-ykl/tracer"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+	"wb_test_task/consumer/internal/common"
+)
+
+const (
+	// customerSummaryPrefix префикс сводок покупателей, сводки записывает api
+	customerSummaryPrefix = "customer_summary"
+	// customerSummaryVersionPrefix префикс версий сводок, api записывает сводку, только если версия
+	// не изменилась с начала чтения из базы
+	customerSummaryVersionPrefix = "customer_summary_version"
+	// customerSummaryVersionTtl время жизни версии, должно превышать время расчета сводки в api
+	customerSummaryVersionTtl = time.Hour
+)
+
+// DeleteCustomerSummary удалить сводку покупателя из redis cache и увеличить ее версию,
+// чтобы api не записал обратно сводку, прочитанную до удаления
+func (o *orderCache) DeleteCustomerSummary(ctx context.Context, customerID string) error {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-delete-customer-summary")
+	span.SetAttributes(attribute.String("customer-id", customerID))
+	defer span.End()
+
+	versionKey := fmt.Sprintf("%s:%s", customerSummaryVersionPrefix, customerID)
+	_, err := o.conn.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, versionKey)
+		pipe.Expire(ctx, versionKey, customerSummaryVersionTtl)
+		pipe.Del(ctx, fmt.Sprintf("%s:%s", customerSummaryPrefix, customerID))
+		return nil
+	})
+	if err != nil {
+		return common.WrapError{Err: err, Msg: "fail to delete customer summary from cache"}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"github.com/go-redis/redismock/v9"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDeleteCustomerSummary(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, 100)
+
+	testCases := []struct {
+		name   string
+		mock   func()
+		errMsg string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectTxPipeline()
+				mock.ExpectIncr("customer_summary_version:test").SetVal(2)
+				mock.ExpectExpire("customer_summary_version:test", customerSummaryVersionTtl).SetVal(true)
+				mock.ExpectDel("customer_summary:test").SetVal(1)
+				mock.ExpectTxPipelineExec()
+			},
+		},
+		{
+			name: "OK. Summary is not cached",
+			mock: func() {
+				mock.ExpectTxPipeline()
+				mock.ExpectIncr("customer_summary_version:test").SetVal(1)
+				mock.ExpectExpire("customer_summary_version:test", customerSummaryVersionTtl).SetVal(true)
+				mock.ExpectDel("customer_summary:test").SetVal(0)
+				mock.ExpectTxPipelineExec()
+			},
+		},
+		{
+			name: "Error from redis",
+			mock: func() {
+				mock.ExpectTxPipeline()
+				mock.ExpectIncr("customer_summary_version:test").SetErr(errors.New("redis: connection refused"))
+			},
+			errMsg: "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			err := cache.DeleteCustomerSummary(context.Background(), "test")
+			if len(test.errMsg) != 0 {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
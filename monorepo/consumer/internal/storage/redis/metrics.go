@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"net"
+	"time"
+	"wb_test_task/consumer/internal/metrics"
+)
+
+// metricsHook учитывать длительность команд redis
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+
+		metrics.RedisCommandDuration.WithLabelValues(cmd.Name(), commandResult(err)).
+			Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+
+		metrics.RedisCommandDuration.WithLabelValues("pipeline", commandResult(err)).
+			Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// commandResult результат команды для метрик, отсутствие ключа не считается ошибкой
+func commandResult(err error) string {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "error"
+	}
+	return "ok"
+}
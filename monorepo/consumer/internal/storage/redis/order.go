@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/dany-ykl/tracer"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 	"time"
 	"wb_test_task/consumer/internal/common"
 	"wb_test_task/libs/model"
@@ -15,23 +17,85 @@ type orderCache struct {
 	ttlSecond int
 }
 
-const orderObjectPrefix = "orders"
+const (
+	orderObjectPrefix = "orders"
+	// orderETagPrefix ETag заказа для условных запросов api, пишется вместе с заказом
+	orderETagPrefix = "orders_etag"
+	// orderVersionPrefix версия заказа в кэше, увеличивается при инвалидации заказа
+	orderVersionPrefix = "orders_version"
+	// orderVersionTtl время жизни версии, должно превышать время между чтением версии и записью заказа
+	orderVersionTtl = time.Hour
+)
+
+// setOrderScript записать заказ и его ETag, только если версия заказа не изменилась с момента чтения
+var setOrderScript = `if (redis.call('GET', KEYS[1]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[2], ARGV[2], 'EX', ARGV[4])
+redis.call('SET', KEYS[3], ARGV[3], 'EX', ARGV[4])
+return 1`
 
 func newOrderCache(conn *redis.Client, ttlSecond int) *orderCache {
 	return &orderCache{conn: conn, ttlSecond: ttlSecond}
 }
 
-// Set вставить order в redis cache
-func (o *orderCache) Set(ctx context.Context, key string, order *model.Order) error {
+// Set вставить order в redis cache вместе с ETag, если версия заказа в кэше совпадает с version.
+// Иначе заказ был инвалидирован после чтения из базы и не записывается
+func (o *orderCache) Set(ctx context.Context, key string, order *model.Order, version string) error {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-set-order")
+	span.SetAttributes(attribute.String("order-id", key))
+	defer span.End()
+
 	data, err := json.Marshal(order)
 	if err != nil {
 		return common.WrapError{Err: err, Msg: "fail to unmarshal order"}
 	}
 
-	cmd := o.conn.Set(ctx, fmt.Sprintf("%s:%s", orderObjectPrefix, key), data, time.Duration(o.ttlSecond)*time.Second)
-	if err := cmd.Err(); err != nil {
+	keys := []string{
+		fmt.Sprintf("%s:%s", orderVersionPrefix, key),
+		fmt.Sprintf("%s:%s", orderObjectPrefix, key),
+		fmt.Sprintf("%s:%s", orderETagPrefix, key),
+	}
+	if err := o.conn.Eval(ctx, setOrderScript, keys, version, data, model.ETag(data), o.ttlSecond).Err(); err != nil {
 		return common.WrapError{Err: err, Msg: "fail to set order in cache"}
 	}
 
 	return nil
 }
+
+// GetVersion вернуть версию заказа в кэше, версию нужно прочитать до чтения заказа из базы
+func (o *orderCache) GetVersion(ctx context.Context, key string) (string, error) {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-get-order-version")
+	span.SetAttributes(attribute.String("order-id", key))
+	defer span.End()
+
+	version, err := o.conn.Get(ctx, fmt.Sprintf("%s:%s", orderVersionPrefix, key)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return model.CacheVersionNone, nil
+		}
+		return "", common.WrapError{Err: err, Msg: "fail to get order version from cache"}
+	}
+
+	return version, nil
+}
+
+// Delete удалить order из redis cache и увеличить его версию, чтобы запись, прочитанная
+// из базы до изменения заказа, не вернула в кэш устаревший заказ
+func (o *orderCache) Delete(ctx context.Context, key string) error {
+	ctx, span := tracer.StartTrace(ctx, "redis-cache-delete-order")
+	span.SetAttributes(attribute.String("order-id", key))
+	defer span.End()
+
+	versionKey := fmt.Sprintf("%s:%s", orderVersionPrefix, key)
+	if _, err := o.conn.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, versionKey)
+		pipe.Expire(ctx, versionKey, orderVersionTtl)
+		pipe.Del(ctx, fmt.Sprintf("%s:%s", orderObjectPrefix, key), fmt.Sprintf("%s:%s", orderETagPrefix, key))
+		return nil
+	}); err != nil {
+		return common.WrapError{Err: err, Msg: "fail to delete order from cache"}
+	}
+
+	return nil
+}
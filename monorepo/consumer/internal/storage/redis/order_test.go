@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/go-redis/redismock/v9"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
-	"time"
 	"wb_test_task/libs/model"
 )
 
@@ -86,10 +86,44 @@ func TestSet(t *testing.T) {
 				ttl: 100,
 			},
 			mock: func(key string, order interface{}, ttl int) {
-				mock.ExpectSet(fmt.Sprintf("%s:%s", orderObjectPrefix, key), order, time.Duration(ttl)*time.Second).
-					SetVal(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f","track_number":"WBILMTESTTRACK3","entry":"WBIL","delivery":{"name":"Test Testov","phone":"+9720000000","zip":"2639809","city":"Kiryat Mozkin","address":"Ploshad Mira 15","region":"Kraiot","email":"test@gmail.com"},"payment":{"transaction":"5d110e48-9e6b-4928-b436-14194b30d54f","request_id":"5d110e48-9e6b-4928-b436-14194b30d54f","currency":"USD","provider":"wbpay","amount":1817,"payment_dt":1637907727,"bank":"alpha","delivery_cost":1500,"goods_total":317,"custom_fee":0},"items":[{"chrt_id":9934930,"track_number":"WBILMTESTTRACK3","price":453,"rid":"ab4219087a764ae0btest","name":"Mascaras","sale":30,"size":"0","total_price":317,"nm_id":2389212,"brand":"Vivienne Sabo","status":202}],"locale":"en","internal_signature":"","customer_id":"test","delivery_service":"meest","shard_key":"","sm_id":99,"date_created":"2021-11-26 06:22:19 +0000 UTC","oof_shard":"1"}`)
+				mock.ExpectEval(setOrderScript, orderKeys(key), model.CacheVersionNone, order, model.ETag(order.([]byte)), ttl).
+					SetVal(int64(1))
 			},
 		},
+		{
+			name: "OK. Order was invalidated after the version was read",
+			mockInput: struct {
+				key   string
+				order *model.Order
+				ttl   int
+			}{
+				key:   "5d110e48-9e6b-4928-b436-14194b30d54f",
+				order: &model.Order{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", Items: []*model.Product{}},
+				ttl:   100,
+			},
+			mock: func(key string, order interface{}, ttl int) {
+				mock.ExpectEval(setOrderScript, orderKeys(key), model.CacheVersionNone, order, model.ETag(order.([]byte)), ttl).
+					SetVal(int64(0))
+			},
+		},
+		{
+			name: "Error from redis",
+			mockInput: struct {
+				key   string
+				order *model.Order
+				ttl   int
+			}{
+				key:   "5d110e48-9e6b-4928-b436-14194b30d54f",
+				order: &model.Order{OrderUid: "5d110e48-9e6b-4928-b436-14194b30d54f", Items: []*model.Product{}},
+				ttl:   100,
+			},
+			mock: func(key string, order interface{}, ttl int) {
+				mock.ExpectEval(setOrderScript, orderKeys(key), model.CacheVersionNone, order, model.ETag(order.([]byte)), ttl).
+					SetErr(errors.New("redis: connection refused"))
+			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
+		},
 	}
 
 	for _, test := range testCases {
@@ -101,13 +135,127 @@ func TestSet(t *testing.T) {
 
 			test.mock(test.mockInput.key, data, test.mockInput.ttl)
 
-			err = cache.Set(context.Background(), test.mockInput.key, test.mockInput.order)
+			err = cache.Set(context.Background(), test.mockInput.key, test.mockInput.order, model.CacheVersionNone)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// orderKeys ключи версии, заказа и ETag заказа для setOrderScript
+func orderKeys(key string) []string {
+	return []string{
+		fmt.Sprintf("%s:%s", orderVersionPrefix, key),
+		fmt.Sprintf("%s:%s", orderObjectPrefix, key),
+		fmt.Sprintf("%s:%s", orderETagPrefix, key),
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, 100)
+
+	testCases := []struct {
+		name           string
+		key            string
+		mock           func(key string)
+		expectedResult string
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name: "OK",
+			key:  "5d110e48-9e6b-4928-b436-14194b30d54f",
+			mock: func(key string) {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderVersionPrefix, key)).SetVal("2")
+			},
+			expectedResult: "2",
+		},
+		{
+			name: "OK. Order was never invalidated",
+			key:  "5d110e48-9e6b-4928-b436-14194b30d54f",
+			mock: func(key string) {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderVersionPrefix, key)).RedisNil()
+			},
+			expectedResult: model.CacheVersionNone,
+		},
+		{
+			name: "Error from redis",
+			key:  "5d110e48-9e6b-4928-b436-14194b30d54f",
+			mock: func(key string) {
+				mock.ExpectGet(fmt.Sprintf("%s:%s", orderVersionPrefix, key)).SetErr(errors.New("redis: connection refused"))
+			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock(test.key)
+
+			result, err := cache.GetVersion(context.Background(), test.key)
+
+			if test.wantErr {
+				assert.EqualError(t, err, test.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedResult, result)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	cache := newOrderCache(client, 100)
+	key := "5d110e48-9e6b-4928-b436-14194b30d54f"
+
+	testCases := []struct {
+		name    string
+		mock    func()
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectTxPipeline()
+				mock.ExpectIncr(fmt.Sprintf("%s:%s", orderVersionPrefix, key)).SetVal(1)
+				mock.ExpectExpire(fmt.Sprintf("%s:%s", orderVersionPrefix, key), orderVersionTtl).SetVal(true)
+				mock.ExpectDel(fmt.Sprintf("%s:%s", orderObjectPrefix, key), fmt.Sprintf("%s:%s", orderETagPrefix, key)).SetVal(2)
+				mock.ExpectTxPipelineExec()
+			},
+		},
+		{
+			name: "Error from redis",
+			mock: func() {
+				mock.ExpectTxPipeline()
+				mock.ExpectIncr(fmt.Sprintf("%s:%s", orderVersionPrefix, key)).SetErr(errors.New("redis: connection refused"))
+			},
+			wantErr: true,
+			errMsg:  "redis: connection refused",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			test.mock()
+
+			err := cache.Delete(context.Background(), key)
 
 			if test.wantErr {
 				assert.EqualError(t, err, test.errMsg)
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE order_fingerprint (
+    -- order_uid id заказа
+    order_uid UUID NOT NULL,
+
+    -- hash sha256 от содержимого принятого запроса на создание заказа
+    hash CHAR(64) NOT NULL,
+
+    -- payload принятый запрос на создание заказа
+    payload JSONB NOT NULL,
+
+    -- created_at дата приема заказа
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_order_fingerprint PRIMARY KEY (order_uid),
+    CONSTRAINT fk_order_fingerprint_order_uid FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+COMMIT;
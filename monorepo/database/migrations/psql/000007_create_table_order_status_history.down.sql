@@ -0,0 +1,3 @@
+DROP TABLE order_status_history;
+ALTER TABLE orders DROP COLUMN status;
+DROP TYPE order_status_type;
@@ -0,0 +1,40 @@
+BEGIN;
+
+CREATE TYPE order_status_type AS ENUM (
+    'created',
+    'paid',
+    'assembled',
+    'shipped',
+    'delivered',
+    'cancelled'
+);
+
+-- status текущий статус заказа
+ALTER TABLE orders ADD COLUMN status order_status_type NOT NULL DEFAULT 'created';
+
+CREATE TABLE order_status_history (
+    id BIGSERIAL NOT NULL,
+
+    -- order_uid id заказа
+    order_uid UUID NOT NULL,
+
+    -- status статус, в который перешел заказ
+    status order_status_type NOT NULL,
+
+    -- reason причина изменения статуса (например, причина отмены)
+    reason VARCHAR(500),
+
+    -- changed_at дата изменения статуса
+    changed_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_order_status_history PRIMARY KEY (id),
+    CONSTRAINT fk_order_status_history_order_uid FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_order_status_history_order_uid ON order_status_history(order_uid, changed_at);
+
+-- история уже созданных заказов начинается со статуса created
+INSERT INTO order_status_history(order_uid, status, changed_at)
+SELECT order_uid, 'created', COALESCE(date_created, now()) FROM orders;
+
+COMMIT;
@@ -0,0 +1,40 @@
+BEGIN;
+
+CREATE TABLE outbox (
+    id BIGSERIAL NOT NULL,
+
+    -- msg_id id сообщения для дедупликации в jetstream (Nats-Msg-Id)
+    msg_id VARCHAR(255) NOT NULL,
+
+    -- subject subject nats, в который публикуется событие
+    subject VARCHAR(255) NOT NULL,
+
+    -- order_uid id заказа, к которому относится событие
+    order_uid UUID NOT NULL,
+
+    -- headers заголовки сообщения: контекст трассировки и версия схемы
+    headers JSONB NOT NULL DEFAULT '{}',
+
+    -- payload тело сообщения
+    payload JSONB NOT NULL,
+
+    -- created_at дата записи события
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+
+    -- sent_at дата публикации события, NULL - событие еще не опубликовано
+    sent_at TIMESTAMP WITHOUT TIME ZONE,
+
+    -- attempts количество неудачных попыток публикации
+    attempts INTEGER NOT NULL DEFAULT 0,
+
+    -- last_error ошибка последней неудачной попытки публикации
+    last_error TEXT,
+
+    CONSTRAINT pk_outbox PRIMARY KEY (id),
+    CONSTRAINT unique_outbox_msg_id UNIQUE (msg_id)
+);
+
+-- relay выбирает только неопубликованные события в порядке записи
+CREATE INDEX idx_outbox_pending ON outbox(id) WHERE sent_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE TABLE cache_repair (
+    -- order_uid id заказа, который не удалось записать в кэш после сохранения в базе
+    order_uid UUID NOT NULL,
+
+    -- payload заказ на момент неудачной записи, статус берется из истории статусов при повторе
+    payload JSONB NOT NULL,
+
+    -- attempts количество неудачных повторных записей
+    attempts INTEGER NOT NULL DEFAULT 0,
+
+    -- last_error ошибка последней неудачной записи
+    last_error TEXT,
+
+    -- created_at дата первой неудачной записи, по ней считается отставание кэша
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+
+    -- next_attempt_at дата следующей повторной записи
+    next_attempt_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+
+    CONSTRAINT pk_cache_repair PRIMARY KEY (order_uid)
+);
+
+CREATE INDEX idx_cache_repair_next_attempt_at ON cache_repair(next_attempt_at);
+
+COMMIT;
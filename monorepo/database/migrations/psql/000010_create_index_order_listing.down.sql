@@ -0,0 +1,4 @@
+DROP INDEX idx_product_track_number;
+DROP INDEX idx_orders_delivery_service_date_created;
+DROP INDEX idx_orders_customer_id_date_created;
+DROP INDEX idx_orders_date_created;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- страницы списка заказов строятся по ключу (date_created, order_uid)
+CREATE INDEX idx_orders_date_created ON orders(date_created, order_uid);
+
+-- фильтры списка заказов по покупателю и сервису доставки с сортировкой по date_created
+CREATE INDEX idx_orders_customer_id_date_created ON orders(customer_id, date_created, order_uid);
+CREATE INDEX idx_orders_delivery_service_date_created ON orders(delivery_service, date_created, order_uid);
+
+-- items страницы заказов загружаются одним запросом по track_number
+CREATE INDEX idx_product_track_number ON product(track_number);
+
+COMMIT;
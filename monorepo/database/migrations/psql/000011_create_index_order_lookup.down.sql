@@ -0,0 +1,2 @@
+DROP INDEX idx_product_rid;
+DROP INDEX idx_transaction_request_id;
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- поиск заказа по request_id платежа
+CREATE INDEX idx_transaction_request_id ON transaction(request_id);
+
+-- поиск заказа по rid товара
+CREATE INDEX idx_product_rid ON product(rid);
+
+COMMIT;
@@ -0,0 +1,248 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+	"mime"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+const (
+	HeaderContentType     = "Content-Type"
+	HeaderContentEncoding = "Content-Encoding"
+
+	ContentTypeJSON     = "application/json"
+	ContentTypeMsgpack  = "application/msgpack"
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	EncodingS2   = "s2"
+	EncodingZstd = "zstd"
+)
+
+var (
+	ErrUnsupportedContentType     = errors.New("unsupported content type")
+	ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+	ErrUnsupportedType            = errors.New("type does not support codec")
+	// ErrMalformedPayload payload не удалось распаковать или разобрать msgpack/protobuf,
+	// ошибки JSON возвращаются без обертки
+	ErrMalformedPayload = errors.New("malformed payload")
+)
+
+// ProtoMarshaler значение, которое умеет кодироваться в protobuf
+type ProtoMarshaler interface {
+	MarshalProto() ([]byte, error)
+}
+
+// ProtoUnmarshaler значение, которое умеет разбираться из protobuf
+type ProtoUnmarshaler interface {
+	UnmarshalProto(data []byte) error
+}
+
+// Codec кодирование payload сообщения
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// MaxDecompressedSize предел размера распакованного payload: маленькое сжатое сообщение
+// не должно разворачиваться в гигабайты памяти consumer
+const MaxDecompressedSize = 64 << 20
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// zstdInit создать общие encoder и decoder zstd при первом использовании
+func zstdInit() error {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			zstdErr = fmt.Errorf("fail to create zstd encoder: %w", zstdErr)
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecompressedSize))
+		if zstdErr != nil {
+			zstdErr = fmt.Errorf("fail to create zstd decoder: %w", zstdErr)
+		}
+	})
+	return zstdErr
+}
+
+// ForContentType вернуть кодек по значению заголовка Content-Type, пустой заголовок означает JSON
+func ForContentType(contentType string) (Codec, error) {
+	if len(contentType) == 0 {
+		return jsonCodec{}, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		return jsonCodec{}, nil
+	case ContentTypeMsgpack:
+		return msgpackCodec{}, nil
+	case ContentTypeProtobuf:
+		return protobufCodec{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// Compress сжать данные, пустая кодировка оставляет данные без изменений
+func Compress(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return data, nil
+	case EncodingS2:
+		return s2.Encode(nil, data), nil
+	case EncodingZstd:
+		if err := zstdInit(); err != nil {
+			return nil, err
+		}
+		return zstdEncoder.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentEncoding, encoding)
+	}
+}
+
+// Decompress распаковать данные, сжатые Compress, не больше MaxDecompressedSize
+func Decompress(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return data, nil
+	case EncodingS2:
+		size, err := s2.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if size > MaxDecompressedSize {
+			return nil, fmt.Errorf("decompressed size %d exceeds %d", size, MaxDecompressedSize)
+		}
+		return s2.Decode(nil, data)
+	case EncodingZstd:
+		if err := zstdInit(); err != nil {
+			return nil, err
+		}
+		return zstdDecoder.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentEncoding, encoding)
+	}
+}
+
+// Encode закодировать значение выбранным кодеком, сжать и записать Content-Type и
+// Content-Encoding в заголовки. Для JSON без сжатия заголовки не пишутся, чтобы старые
+// потребители могли прочитать сообщение
+func Encode(header map[string][]string, v any, contentType, encoding string) ([]byte, error) {
+	c, err := ForContentType(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = Compress(encoding, data); err != nil {
+		return nil, err
+	}
+
+	h := textproto.MIMEHeader(header)
+	if len(contentType) != 0 {
+		h.Set(HeaderContentType, c.ContentType())
+	}
+	if len(encoding) != 0 {
+		h.Set(HeaderContentEncoding, encoding)
+	}
+
+	return data, nil
+}
+
+// Decode распаковать и разобрать payload по заголовкам Content-Encoding и Content-Type
+func Decode(header map[string][]string, data []byte, v any) error {
+	h := textproto.MIMEHeader(header)
+
+	c, err := ForContentType(h.Get(HeaderContentType))
+	if err != nil {
+		return err
+	}
+
+	data, err = Decompress(strings.TrimSpace(h.Get(HeaderContentEncoding)), data)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedContentEncoding) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+
+	return c.Unmarshal(data, v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return ContentTypeJSON }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec msgpack с именами полей из json тегов, чтобы схема совпадала с JSON
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return ContentTypeMsgpack }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+	return nil
+}
+
+// protobufCodec protobuf для значений, реализующих ProtoMarshaler и ProtoUnmarshaler
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(ProtoMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not %s", ErrUnsupportedType, v, ContentTypeProtobuf)
+	}
+	return m.MarshalProto()
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(ProtoUnmarshaler)
+	if !ok {
+		return fmt.Errorf("%w: %T is not %s", ErrUnsupportedType, v, ContentTypeProtobuf)
+	}
+	if err := m.UnmarshalProto(data); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package codec
+
+import (
+	"errors"
+	"github.com/klauspost/compress/s2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"wb_test_task/libs/model"
+)
+
+func testOrder() *model.Order {
+	return &model.Order{
+		OrderUid:    "5d110e48-9e6b-4928-b436-14194b30d54f",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: model.Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []*model.Product{{
+			ChrtID:      9934930,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       453,
+			Rid:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			Size:        "0",
+			TotalPrice:  317,
+			NmID:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		}},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		SmID:            99,
+		DateCreated:     "2021-11-26T06:22:19Z",
+		OofShard:        "1",
+		Status:          "created",
+		Timeline: []*model.StatusChange{{
+			Status:    "created",
+			ChangedAt: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		}},
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		encoding    string
+		wantHeader  map[string][]string
+	}{
+		{
+			name:       "JSON by default",
+			wantHeader: map[string][]string{},
+		},
+		{
+			name:        "Msgpack",
+			contentType: ContentTypeMsgpack,
+			wantHeader:  map[string][]string{HeaderContentType: {ContentTypeMsgpack}},
+		},
+		{
+			name:        "Protobuf with s2",
+			contentType: ContentTypeProtobuf,
+			encoding:    EncodingS2,
+			wantHeader: map[string][]string{
+				HeaderContentType:     {ContentTypeProtobuf},
+				HeaderContentEncoding: {EncodingS2},
+			},
+		},
+		{
+			name:        "JSON with zstd",
+			contentType: ContentTypeJSON,
+			encoding:    EncodingZstd,
+			wantHeader: map[string][]string{
+				HeaderContentType:     {ContentTypeJSON},
+				HeaderContentEncoding: {EncodingZstd},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order := testOrder()
+			header := map[string][]string{}
+
+			data, err := Encode(header, order, test.contentType, test.encoding)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantHeader, header)
+
+			var decoded model.Order
+			assert.NoError(t, Decode(header, data, &decoded))
+			for _, change := range decoded.Timeline {
+				// msgpack разбирает время в локальной зоне
+				change.ChangedAt = change.ChangedAt.UTC()
+			}
+			assert.Equal(t, order, &decoded)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	largeZstd, err := Compress(EncodingZstd, make([]byte, MaxDecompressedSize+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		header  map[string][]string
+		data    []byte
+		value   any
+		wantErr error
+	}{
+		{
+			name:    "Unknown content type",
+			header:  map[string][]string{HeaderContentType: {"text/xml"}},
+			value:   &model.Order{},
+			wantErr: ErrUnsupportedContentType,
+		},
+		{
+			name:    "Unknown content encoding",
+			header:  map[string][]string{HeaderContentEncoding: {"br"}},
+			value:   &model.Order{},
+			wantErr: ErrUnsupportedContentEncoding,
+		},
+		{
+			name:    "Protobuf into type without proto support",
+			header:  map[string][]string{HeaderContentType: {ContentTypeProtobuf}},
+			value:   &map[string]any{},
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Broken msgpack",
+			header:  map[string][]string{HeaderContentType: {ContentTypeMsgpack}},
+			value:   &model.Order{},
+			wantErr: ErrMalformedPayload,
+		},
+		{
+			name:    "Broken s2",
+			header:  map[string][]string{HeaderContentEncoding: {EncodingS2}},
+			value:   &model.Order{},
+			wantErr: ErrMalformedPayload,
+		},
+		{
+			name:    "Too large s2",
+			header:  map[string][]string{HeaderContentEncoding: {EncodingS2}},
+			data:    s2.Encode(nil, make([]byte, MaxDecompressedSize+1)),
+			value:   &model.Order{},
+			wantErr: ErrMalformedPayload,
+		},
+		{
+			name:    "Too large zstd",
+			header:  map[string][]string{HeaderContentEncoding: {EncodingZstd}},
+			data:    largeZstd,
+			value:   &model.Order{},
+			wantErr: ErrMalformedPayload,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := test.data
+			if data == nil {
+				data = []byte(`{}`)
+			}
+			err := Decode(test.header, data, test.value)
+			assert.True(t, errors.Is(err, test.wantErr), err)
+		})
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	c, err := ForContentType("application/json; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeJSON, c.ContentType())
+}
+
+func TestOrderCreateRequest(t *testing.T) {
+	order := testOrder()
+	data, err := model.MarshalOrderCreateRequest(order)
+	assert.NoError(t, err)
+
+	decoded, err := model.UnmarshalOrderCreateRequest(data)
+	assert.NoError(t, err)
+
+	order.Status, order.Timeline = "", nil
+	assert.Equal(t, order, decoded)
+}
@@ -0,0 +1,98 @@
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderSchemaVersion = "Schema-Version"
+	HeaderSource        = "Source"
+	HeaderProducedAt    = "Produced-At"
+
+	// LegacyVersion версия сообщений, отправленных без конверта
+	LegacyVersion = 1
+)
+
+var ErrInvalidEnvelope = errors.New("invalid envelope")
+
+// Metadata описание сообщения: версия схемы payload, сервис-источник и время отправки
+type Metadata struct {
+	SchemaVersion int       `json:"schema_version"`
+	Source        string    `json:"source,omitempty"`
+	ProducedAt    time.Time `json:"produced_at,omitempty"`
+}
+
+// Envelope конверт-обертка, используется, если транспорт не поддерживает заголовки
+type Envelope struct {
+	Metadata
+	Payload json.RawMessage `json:"payload"`
+}
+
+// SetHeader записать описание сообщения в заголовки
+func (m Metadata) SetHeader(header map[string][]string) {
+	h := textproto.MIMEHeader(header)
+	h.Set(HeaderSchemaVersion, strconv.Itoa(m.SchemaVersion))
+	if len(m.Source) != 0 {
+		h.Set(HeaderSource, m.Source)
+	}
+	if !m.ProducedAt.IsZero() {
+		h.Set(HeaderProducedAt, m.ProducedAt.UTC().Format(time.RFC3339Nano))
+	}
+}
+
+// Wrap завернуть payload в конверт
+func Wrap(meta Metadata, payload []byte) ([]byte, error) {
+	return json.Marshal(Envelope{Metadata: meta, Payload: payload})
+}
+
+// Open вернуть описание и payload сообщения. Описание ищется в заголовках, затем в
+// конверте-обертке, сообщение без конверта считается сообщением версии LegacyVersion
+func Open(header map[string][]string, data []byte) (Metadata, []byte, error) {
+	if version := textproto.MIMEHeader(header).Get(HeaderSchemaVersion); len(version) != 0 {
+		meta, err := fromHeader(header, version)
+		return meta, data, err
+	}
+
+	var wrapper struct {
+		SchemaVersion *int            `json:"schema_version"`
+		Source        string          `json:"source"`
+		ProducedAt    time.Time       `json:"produced_at"`
+		Payload       json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err == nil && wrapper.SchemaVersion != nil && wrapper.Payload != nil {
+		if *wrapper.SchemaVersion <= 0 {
+			return Metadata{}, nil, fmt.Errorf("%w: schema_version %d", ErrInvalidEnvelope, *wrapper.SchemaVersion)
+		}
+		return Metadata{
+			SchemaVersion: *wrapper.SchemaVersion,
+			Source:        wrapper.Source,
+			ProducedAt:    wrapper.ProducedAt,
+		}, wrapper.Payload, nil
+	}
+
+	return Metadata{SchemaVersion: LegacyVersion}, data, nil
+}
+
+// fromHeader разобрать описание сообщения из заголовков
+func fromHeader(header map[string][]string, version string) (Metadata, error) {
+	h := textproto.MIMEHeader(header)
+
+	schemaVersion, err := strconv.Atoi(version)
+	if err != nil || schemaVersion <= 0 {
+		return Metadata{}, fmt.Errorf("%w: %s %q", ErrInvalidEnvelope, HeaderSchemaVersion, version)
+	}
+
+	meta := Metadata{SchemaVersion: schemaVersion, Source: h.Get(HeaderSource)}
+	if producedAt := h.Get(HeaderProducedAt); len(producedAt) != 0 {
+		if meta.ProducedAt, err = time.Parse(time.RFC3339Nano, producedAt); err != nil {
+			return Metadata{}, fmt.Errorf("%w: %s %q", ErrInvalidEnvelope, HeaderProducedAt, producedAt)
+		}
+	}
+
+	return meta, nil
+}
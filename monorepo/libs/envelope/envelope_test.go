@@ -0,0 +1,80 @@
+package envelope
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestOpen(t *testing.T) {
+	producedAt := time.Date(2023, 11, 26, 6, 22, 19, 0, time.UTC)
+	meta := Metadata{SchemaVersion: 2, Source: "producer", ProducedAt: producedAt}
+	payload := []byte(`{"order_uid":"5d110e48-9e6b-4928-b436-14194b30d54f"}`)
+
+	header := map[string][]string{}
+	meta.SetHeader(header)
+
+	wrapped, err := Wrap(meta, payload)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		header      map[string][]string
+		data        []byte
+		wantMeta    Metadata
+		wantPayload []byte
+		wantErr     error
+	}{
+		{
+			name:        "Metadata in header",
+			header:      header,
+			data:        payload,
+			wantMeta:    meta,
+			wantPayload: payload,
+		},
+		{
+			name:        "Metadata in wrapper",
+			data:        wrapped,
+			wantMeta:    meta,
+			wantPayload: payload,
+		},
+		{
+			name:        "Message without envelope",
+			data:        payload,
+			wantMeta:    Metadata{SchemaVersion: LegacyVersion},
+			wantPayload: payload,
+		},
+		{
+			name:    "Invalid schema version in header",
+			header:  map[string][]string{HeaderSchemaVersion: {"v2"}},
+			data:    payload,
+			wantErr: ErrInvalidEnvelope,
+		},
+		{
+			name:    "Invalid produced at in header",
+			header:  map[string][]string{HeaderSchemaVersion: {"2"}, HeaderProducedAt: {"yesterday"}},
+			data:    payload,
+			wantErr: ErrInvalidEnvelope,
+		},
+		{
+			name:    "Invalid schema version in wrapper",
+			data:    []byte(`{"schema_version":0,"payload":{}}`),
+			wantErr: ErrInvalidEnvelope,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotMeta, gotPayload, err := Open(test.header, test.data)
+			if test.wantErr != nil {
+				assert.True(t, errors.Is(err, test.wantErr))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantMeta, gotMeta)
+			assert.JSONEq(t, string(test.wantPayload), string(gotPayload))
+		})
+	}
+}
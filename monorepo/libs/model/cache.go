@@ -0,0 +1,5 @@
+package model
+
+// CacheVersionNone версия заказа в кэше, пока consumer ни разу не инвалидировал заказ. Запись заказа
+// в кэш проходит, только если версия не изменилась с момента чтения заказа из базы
+const CacheVersionNone = "0"
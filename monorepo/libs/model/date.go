@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateCreatedLayouts форматы date_created: RFC3339 из сообщений и time.Time.String из старых версий api
+var dateCreatedLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05 -0700 MST"}
+
+// ParseDateCreated разобрать date_created заказа
+func ParseDateCreated(value string) (time.Time, error) {
+	for _, layout := range dateCreatedLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date_created format %q", value)
+}
+
+// FormatDateCreated date_created заказа в кэше и ответах api: RFC3339 в UTC с точностью до микросекунд.
+// В базе дата хранится без часового пояса, поэтому смещение отбрасывается так же, как при записи.
+// api и consumer пишут в кэш одинаковые байты заказа, и ETag не зависит от того, кто записал заказ
+func FormatDateCreated(date time.Time) string {
+	wall := time.Date(date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute(), date.Second(),
+		date.Nanosecond(), time.UTC)
+	return wall.Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// NormalizeDateCreated привести date_created к формату FormatDateCreated, пустое
+// или неразобранное значение возвращается без изменений
+func NormalizeDateCreated(value string) string {
+	if len(value) == 0 {
+		return value
+	}
+
+	date, err := ParseDateCreated(value)
+	if err != nil {
+		return value
+	}
+	return FormatDateCreated(date)
+}
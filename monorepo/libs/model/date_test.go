@@ -0,0 +1,34 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNormalizeDateCreated(t *testing.T) {
+	testCases := []struct {
+		name           string
+		value          string
+		expectedResult string
+	}{
+		{name: "RFC3339", value: "2021-11-26T06:22:19Z", expectedResult: "2021-11-26T06:22:19Z"},
+		{name: "Offset is dropped like in database", value: "2021-11-26T06:22:19+03:00", expectedResult: "2021-11-26T06:22:19Z"},
+		{name: "Nanoseconds truncated to microseconds", value: "2021-11-26T06:22:19.123456789Z", expectedResult: "2021-11-26T06:22:19.123456Z"},
+		{name: "Time string", value: "2021-11-26 06:22:19 +0000 UTC", expectedResult: "2021-11-26T06:22:19Z"},
+		{name: "Empty", value: "", expectedResult: ""},
+		{name: "Invalid", value: "yesterday", expectedResult: "yesterday"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedResult, NormalizeDateCreated(test.value))
+		})
+	}
+}
+
+func TestFormatDateCreated(t *testing.T) {
+	// дата из базы и дата из сообщения дают одинаковую строку
+	fromDatabase := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	assert.Equal(t, NormalizeDateCreated("2021-11-26T06:22:19Z"), FormatDateCreated(fromDatabase))
+}
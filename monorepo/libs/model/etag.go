@@ -0,0 +1,13 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ETag strong ETag json представления заказа: sha256 от байтов json в кавычках.
+// api и consumer считают его от одних и тех же байтов, которые пишут в кэш
+func ETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
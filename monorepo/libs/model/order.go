@@ -15,4 +15,7 @@ type Order struct {
 	SmID              int        `json:"sm_id"`
 	DateCreated       string     `json:"date_created"`
 	OofShard          string     `json:"oof_shard"`
+
+	Status   string          `json:"status,omitempty"`
+	Timeline []*StatusChange `json:"timeline,omitempty"`
 }
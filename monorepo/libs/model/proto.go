@@ -0,0 +1,215 @@
+package model
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"wb_test_task/libs/orderpb"
+)
+
+// MarshalProto закодировать заказ в protobuf
+func (o *Order) MarshalProto() ([]byte, error) {
+	timeline := make([]*orderpb.StatusChange, 0, len(o.Timeline))
+	for _, change := range o.Timeline {
+		timeline = append(timeline, &orderpb.StatusChange{
+			Status:    change.Status,
+			Reason:    change.Reason,
+			ChangedAt: timestamppb.New(change.ChangedAt),
+		})
+	}
+
+	return proto.Marshal(&orderpb.Order{
+		OrderUid:          o.OrderUid,
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Delivery:          deliveryToProto(o.Delivery),
+		Payment:           paymentToProto(o.Payment),
+		Items:             productsToProto(o.Items),
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerId:        o.CustomerID,
+		DeliveryService:   o.DeliveryService,
+		ShardKey:          o.ShardKey,
+		SmId:              int64(o.SmID),
+		DateCreated:       o.DateCreated,
+		OofShard:          o.OofShard,
+		Status:            o.Status,
+		Timeline:          timeline,
+	})
+}
+
+// UnmarshalProto разобрать заказ из protobuf
+func (o *Order) UnmarshalProto(data []byte) error {
+	var message orderpb.Order
+	if err := proto.Unmarshal(data, &message); err != nil {
+		return err
+	}
+
+	var timeline []*StatusChange
+	for _, change := range message.GetTimeline() {
+		timeline = append(timeline, &StatusChange{
+			Status:    change.GetStatus(),
+			Reason:    change.GetReason(),
+			ChangedAt: change.GetChangedAt().AsTime(),
+		})
+	}
+
+	*o = Order{
+		OrderUid:          message.GetOrderUid(),
+		TrackNumber:       message.GetTrackNumber(),
+		Entry:             message.GetEntry(),
+		Delivery:          deliveryFromProto(message.GetDelivery()),
+		Payment:           paymentFromProto(message.GetPayment()),
+		Items:             productsFromProto(message.GetItems()),
+		Locale:            message.GetLocale(),
+		InternalSignature: message.GetInternalSignature(),
+		CustomerID:        message.GetCustomerId(),
+		DeliveryService:   message.GetDeliveryService(),
+		ShardKey:          message.GetShardKey(),
+		SmID:              int(message.GetSmId()),
+		DateCreated:       message.GetDateCreated(),
+		OofShard:          message.GetOofShard(),
+		Status:            message.GetStatus(),
+		Timeline:          timeline,
+	}
+	return nil
+}
+
+// MarshalOrderCreateRequest закодировать заказ в protobuf сообщение order.create,
+// статус и история статусов не передаются
+func MarshalOrderCreateRequest(o *Order) ([]byte, error) {
+	return proto.Marshal(&orderpb.OrderCreateRequest{
+		OrderUid:          o.OrderUid,
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Delivery:          deliveryToProto(o.Delivery),
+		Payment:           paymentToProto(o.Payment),
+		Items:             productsToProto(o.Items),
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerId:        o.CustomerID,
+		DeliveryService:   o.DeliveryService,
+		ShardKey:          o.ShardKey,
+		SmId:              int64(o.SmID),
+		DateCreated:       o.DateCreated,
+		OofShard:          o.OofShard,
+	})
+}
+
+// UnmarshalOrderCreateRequest разобрать protobuf сообщение order.create в заказ без статуса
+func UnmarshalOrderCreateRequest(data []byte) (*Order, error) {
+	var message orderpb.OrderCreateRequest
+	if err := proto.Unmarshal(data, &message); err != nil {
+		return &Order{}, err
+	}
+
+	return &Order{
+		OrderUid:          message.GetOrderUid(),
+		TrackNumber:       message.GetTrackNumber(),
+		Entry:             message.GetEntry(),
+		Delivery:          deliveryFromProto(message.GetDelivery()),
+		Payment:           paymentFromProto(message.GetPayment()),
+		Items:             productsFromProto(message.GetItems()),
+		Locale:            message.GetLocale(),
+		InternalSignature: message.GetInternalSignature(),
+		CustomerID:        message.GetCustomerId(),
+		DeliveryService:   message.GetDeliveryService(),
+		ShardKey:          message.GetShardKey(),
+		SmID:              int(message.GetSmId()),
+		DateCreated:       message.GetDateCreated(),
+		OofShard:          message.GetOofShard(),
+	}, nil
+}
+
+func deliveryToProto(d Delivery) *orderpb.Delivery {
+	return &orderpb.Delivery{
+		Name:    d.Name,
+		Phone:   d.Phone,
+		Zip:     d.Zip,
+		City:    d.City,
+		Address: d.Address,
+		Region:  d.Region,
+		Email:   d.Email,
+	}
+}
+
+func deliveryFromProto(d *orderpb.Delivery) Delivery {
+	return Delivery{
+		Name:    d.GetName(),
+		Phone:   d.GetPhone(),
+		Zip:     d.GetZip(),
+		City:    d.GetCity(),
+		Address: d.GetAddress(),
+		Region:  d.GetRegion(),
+		Email:   d.GetEmail(),
+	}
+}
+
+func paymentToProto(p Payment) *orderpb.Payment {
+	return &orderpb.Payment{
+		Transaction:  p.Transaction,
+		RequestId:    p.RequestID,
+		Currency:     p.Currency,
+		Provider:     p.Provider,
+		Amount:       p.Amount,
+		PaymentDt:    p.PaymentDt,
+		Bank:         p.Bank,
+		DeliveryCost: p.DeliveryCost,
+		GoodsTotal:   int64(p.GoodsTotal),
+		CustomFee:    int64(p.CustomFee),
+	}
+}
+
+func paymentFromProto(p *orderpb.Payment) Payment {
+	return Payment{
+		Transaction:  p.GetTransaction(),
+		RequestID:    p.GetRequestId(),
+		Currency:     p.GetCurrency(),
+		Provider:     p.GetProvider(),
+		Amount:       p.GetAmount(),
+		PaymentDt:    p.GetPaymentDt(),
+		Bank:         p.GetBank(),
+		DeliveryCost: p.GetDeliveryCost(),
+		GoodsTotal:   int(p.GetGoodsTotal()),
+		CustomFee:    int(p.GetCustomFee()),
+	}
+}
+
+func productsToProto(products []*Product) []*orderpb.Product {
+	items := make([]*orderpb.Product, 0, len(products))
+	for _, p := range products {
+		items = append(items, &orderpb.Product{
+			ChrtId:      p.ChrtID,
+			TrackNumber: p.TrackNumber,
+			Price:       p.Price,
+			Rid:         p.Rid,
+			Name:        p.Name,
+			Sale:        int64(p.Sale),
+			Size:        p.Size,
+			TotalPrice:  p.TotalPrice,
+			NmId:        p.NmID,
+			Brand:       p.Brand,
+			Status:      int64(p.Status),
+		})
+	}
+	return items
+}
+
+func productsFromProto(items []*orderpb.Product) []*Product {
+	products := make([]*Product, 0, len(items))
+	for _, p := range items {
+		products = append(products, &Product{
+			ChrtID:      p.GetChrtId(),
+			TrackNumber: p.GetTrackNumber(),
+			Price:       p.GetPrice(),
+			Rid:         p.GetRid(),
+			Name:        p.GetName(),
+			Sale:        int(p.GetSale()),
+			Size:        p.GetSize(),
+			TotalPrice:  p.GetTotalPrice(),
+			NmID:        p.GetNmId(),
+			Brand:       p.GetBrand(),
+			Status:      int(p.GetStatus()),
+		})
+	}
+	return products
+}
@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+type StatusChange struct {
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
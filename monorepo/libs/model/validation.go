@@ -0,0 +1,117 @@
+package model
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"math"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// допустимая погрешность при сравнении денежных сумм
+const (
+	amountTolerance     = 0.01
+	totalPriceTolerance = 1
+)
+
+var phoneRegexp = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+
+var (
+	// AllowedCurrencies допустимые валюты оплаты, совпадают с currency_type
+	AllowedCurrencies = []string{"USD", "RUB"}
+	// AllowedProviders допустимые платежные провайдеры, совпадают с provider_type
+	AllowedProviders = []string{"wbpay"}
+	// AllowedLocales допустимые локали, совпадают с locale_type
+	AllowedLocales = []string{"en"}
+)
+
+// Violation нарушение бизнес-правила в поле заказа
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Violations список нарушений бизнес-правил
+type Violations []Violation
+
+func (v Violations) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, violation := range v {
+		messages = append(messages, violation.Field+": "+violation.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateOrder проверить бизнес-правила нового заказа, статус и история статусов не проверяются
+func ValidateOrder(order *Order) Violations {
+	var violations Violations
+	add := func(field, message string) {
+		violations = append(violations, Violation{Field: field, Message: message})
+	}
+
+	if _, err := uuid.Parse(order.OrderUid); err != nil || len(order.OrderUid) != 36 {
+		add("order_uid", "must be a uuid")
+	}
+
+	if address, err := mail.ParseAddress(order.Delivery.Email); err != nil || address.Address != order.Delivery.Email {
+		add("delivery.email", "invalid email format")
+	}
+
+	if !phoneRegexp.MatchString(order.Delivery.Phone) {
+		add("delivery.phone", "invalid phone format")
+	}
+
+	if !contains(AllowedLocales, order.Locale) {
+		add("locale", fmt.Sprintf("must be one of %v", AllowedLocales))
+	}
+
+	if !contains(AllowedCurrencies, order.Payment.Currency) {
+		add("payment.currency", fmt.Sprintf("must be one of %v", AllowedCurrencies))
+	}
+
+	if !contains(AllowedProviders, order.Payment.Provider) {
+		add("payment.provider", fmt.Sprintf("must be one of %v", AllowedProviders))
+	}
+
+	if len(order.Items) == 0 {
+		add("items", "must contain at least one item")
+	}
+
+	var itemsTotal float64
+	for i, item := range order.Items {
+		if item.TrackNumber != order.TrackNumber {
+			add(fmt.Sprintf("items[%d].track_number", i),
+				fmt.Sprintf("must be equal to order track_number %q", order.TrackNumber))
+		}
+
+		if item.Sale < 0 || item.Sale > 100 {
+			add(fmt.Sprintf("items[%d].sale", i), "must be between 0 and 100")
+		} else if expected := item.Price * float64(100-item.Sale) / 100; math.Abs(item.TotalPrice-expected) >= totalPriceTolerance {
+			add(fmt.Sprintf("items[%d].total_price", i),
+				fmt.Sprintf("must be equal to price with sale applied (%.2f)", expected))
+		}
+
+		itemsTotal += item.TotalPrice
+	}
+
+	if math.Abs(float64(order.Payment.GoodsTotal)-itemsTotal) >= amountTolerance {
+		add("payment.goods_total", fmt.Sprintf("must be equal to sum of items total_price (%.2f)", itemsTotal))
+	}
+
+	expectedAmount := float64(order.Payment.GoodsTotal) + order.Payment.DeliveryCost + float64(order.Payment.CustomFee)
+	if math.Abs(order.Payment.Amount-expectedAmount) >= amountTolerance {
+		add("payment.amount", fmt.Sprintf("must be equal to goods_total + delivery_cost + custom_fee (%.2f)", expectedAmount))
+	}
+
+	return violations
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
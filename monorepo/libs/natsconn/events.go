@@ -0,0 +1,68 @@
+package natsconn
+
+import (
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+	"log"
+)
+
+// EventLogger вывод событий соединения, keysAndValues - пары ключ, значение
+type EventLogger interface {
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+}
+
+// EventOptions вернуть обработчики отключения, переподключения и закрытия соединения.
+// onClosed вызывается после закрытия соединения, nil - не вызывать
+func EventOptions(log EventLogger, onClosed func()) []nats.Option {
+	return []nats.Option{
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			log.Warn("nats disconnected", "url", nc.ConnectedUrlRedacted(), "error", err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Info("nats reconnected", "url", nc.ConnectedUrlRedacted(), "reconnects", nc.Reconnects)
+		}),
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			log.Info("nats connection closed", "lastError", nc.LastError())
+			if onClosed != nil {
+				onClosed()
+			}
+		}),
+	}
+}
+
+// ZapLogger EventLogger поверх функций zap логгера: logger.Info и logger.Warn сервиса или методов *zap.Logger
+type ZapLogger struct {
+	InfoFunc func(msg string, fields ...zap.Field)
+	WarnFunc func(msg string, fields ...zap.Field)
+}
+
+func (l ZapLogger) Info(msg string, keysAndValues ...any) {
+	l.InfoFunc(msg, zapFields(keysAndValues)...)
+}
+
+func (l ZapLogger) Warn(msg string, keysAndValues ...any) {
+	l.WarnFunc(msg, zapFields(keysAndValues)...)
+}
+
+func zapFields(keysAndValues []any) []zap.Field {
+	fields := make([]zap.Field, 0, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fields = append(fields, zap.Any(fmt.Sprint(keysAndValues[i]), keysAndValues[i+1]))
+	}
+	return fields
+}
+
+// StdLogger EventLogger поверх стандартного log
+type StdLogger struct {
+	Logger *log.Logger
+}
+
+func (l StdLogger) Info(msg string, keysAndValues ...any) {
+	l.Logger.Println(append([]any{msg}, keysAndValues...)...)
+}
+
+func (l StdLogger) Warn(msg string, keysAndValues ...any) {
+	l.Logger.Println(append([]any{"warn:", msg}, keysAndValues...)...)
+}
@@ -0,0 +1,33 @@
+package natsconn
+
+import (
+	"errors"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type recordLogger struct {
+	events []string
+}
+
+func (l *recordLogger) Info(msg string, _ ...any) { l.events = append(l.events, "info: "+msg) }
+func (l *recordLogger) Warn(msg string, _ ...any) { l.events = append(l.events, "warn: "+msg) }
+
+func TestEventOptions(t *testing.T) {
+	log := &recordLogger{}
+	closed := false
+
+	opts := nats.GetDefaultOptions()
+	for _, opt := range EventOptions(log, func() { closed = true }) {
+		assert.NoError(t, opt(&opts))
+	}
+
+	nc := &nats.Conn{}
+	opts.DisconnectedErrCB(nc, errors.New("connection reset"))
+	opts.ReconnectedCB(nc)
+	opts.ClosedCB(nc)
+
+	assert.Equal(t, []string{"warn: nats disconnected", "info: nats reconnected", "info: nats connection closed"}, log.events)
+	assert.True(t, closed)
+}
@@ -0,0 +1,130 @@
+package natsconn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"os"
+	"time"
+)
+
+var ErrInvalidConfig = errors.New("invalid nats connection config")
+
+// Config настройки подключения к nats: имя соединения, аутентификация, TLS и переподключение.
+// Задается не больше одного способа аутентификации
+type Config struct {
+	// Name имя соединения, видно в мониторинге nats-server
+	Name     string `yaml:"name"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+	// NKeySeedFile файл с seed NKey пользователя
+	NKeySeedFile string `yaml:"nkey_seed_file"`
+	// CredsFile файл .creds с JWT и seed NKey пользователя
+	CredsFile string `yaml:"creds_file"`
+	TLS       TLS    `yaml:"tls"`
+
+	ReconnectWaitMs int `yaml:"reconnect_wait_ms" default:"2000"`
+	// MaxReconnects количество попыток переподключения, -1 - без ограничения
+	MaxReconnects int `yaml:"max_reconnects" default:"60"`
+}
+
+// TLS настройки TLS соединения, CA и клиентский сертификат необязательны
+type TLS struct {
+	Enabled  bool   `yaml:"enabled"`
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// Options вернуть опции nats.Connect по настройкам подключения
+func Options(cfg Config) ([]nats.Option, error) {
+	opts := []nats.Option{
+		nats.ReconnectWait(time.Duration(cfg.ReconnectWaitMs) * time.Millisecond),
+		nats.MaxReconnects(cfg.MaxReconnects),
+	}
+	if len(cfg.Name) != 0 {
+		opts = append(opts, nats.Name(cfg.Name))
+	}
+
+	auth, err := authOption(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if auth != nil {
+		opts = append(opts, auth)
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	return opts, nil
+}
+
+// authOption вернуть опцию аутентификации, nil - без аутентификации
+func authOption(cfg Config) (nats.Option, error) {
+	methods := 0
+	for _, set := range []bool{len(cfg.User) != 0, len(cfg.Token) != 0, len(cfg.NKeySeedFile) != 0,
+		len(cfg.CredsFile) != 0} {
+		if set {
+			methods++
+		}
+	}
+	if methods > 1 {
+		return nil, fmt.Errorf("%w: only one of user, token, nkey_seed_file and creds_file can be set", ErrInvalidConfig)
+	}
+
+	switch {
+	case len(cfg.User) != 0:
+		return nats.UserInfo(cfg.User, cfg.Password), nil
+	case len(cfg.Token) != 0:
+		return nats.Token(cfg.Token), nil
+	case len(cfg.NKeySeedFile) != 0:
+		opt, err := nats.NkeyOptionFromSeed(cfg.NKeySeedFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: nkey_seed_file: %v", ErrInvalidConfig, err)
+		}
+		return opt, nil
+	case len(cfg.CredsFile) != 0:
+		if _, err := os.Stat(cfg.CredsFile); err != nil {
+			return nil, fmt.Errorf("%w: creds_file: %v", ErrInvalidConfig, err)
+		}
+		return nats.UserCredentials(cfg.CredsFile), nil
+	default:
+		return nil, nil
+	}
+}
+
+// newTLSConfig загрузить CA и клиентский сертификат
+func newTLSConfig(cfg TLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(cfg.CAFile) != 0 {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: ca_file: %v", ErrInvalidConfig, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("%w: ca_file: no certificates in %s", ErrInvalidConfig, cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(cfg.CertFile) != 0 || len(cfg.KeyFile) != 0 {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cert_file and key_file: %v", ErrInvalidConfig, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,76 @@
+package natsconn
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	dir := t.TempDir()
+	invalidCA := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(invalidCA, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantOptions int
+		wantErr     error
+	}{
+		{
+			name:        "Without authentication",
+			cfg:         Config{ReconnectWaitMs: 2000, MaxReconnects: 60},
+			wantOptions: 2,
+		},
+		{
+			name:        "Name and user password",
+			cfg:         Config{Name: "consumer", User: "consumer", Password: "secret"},
+			wantOptions: 4,
+		},
+		{
+			name:        "Token over TLS without CA",
+			cfg:         Config{Token: "secret", TLS: TLS{Enabled: true}},
+			wantOptions: 4,
+		},
+		{
+			name:    "Several authentication methods",
+			cfg:     Config{User: "consumer", Token: "secret"},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "Missing creds file",
+			cfg:     Config{CredsFile: filepath.Join(dir, "user.creds")},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "Missing nkey seed file",
+			cfg:     Config{NKeySeedFile: filepath.Join(dir, "user.nk")},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "CA file without certificates",
+			cfg:     Config{TLS: TLS{Enabled: true, CAFile: invalidCA}},
+			wantErr: ErrInvalidConfig,
+		},
+		{
+			name:    "Client certificate without key",
+			cfg:     Config{TLS: TLS{Enabled: true, CertFile: invalidCA}},
+			wantErr: ErrInvalidConfig,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := Options(test.cfg)
+			if test.wantErr != nil {
+				assert.True(t, errors.Is(err, test.wantErr), err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, opts, test.wantOptions)
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package orderpb protobuf схема сообщений заказов
+package orderpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative order.proto
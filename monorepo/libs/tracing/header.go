@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/propagation"
+	"strings"
+)
+
+// propagator W3C trace context (traceparent, tracestate) и baggage
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// HeaderCarrier заголовки nats сообщения как носитель контекста трассировки.
+// Ключи записываются без канонизации, в том виде, как их передает propagator
+type HeaderCarrier map[string][]string
+
+// Get вернуть значение заголовка, ключ ищется без учета регистра
+func (c HeaderCarrier) Get(key string) string {
+	if values := c[key]; len(values) != 0 {
+		return values[0]
+	}
+
+	for k, values := range c {
+		if strings.EqualFold(k, key) && len(values) != 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// Set записать значение заголовка
+func (c HeaderCarrier) Set(key, value string) {
+	c[key] = []string{value}
+}
+
+// Keys вернуть ключи заголовков
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Inject записать контекст трассировки из ctx в заголовки сообщения
+func Inject(ctx context.Context, header map[string][]string) {
+	propagator.Inject(ctx, HeaderCarrier(header))
+}
+
+// Extract восстановить контекст трассировки из заголовков сообщения
+func Extract(ctx context.Context, header map[string][]string) context.Context {
+	if len(header) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, HeaderCarrier(header))
+}
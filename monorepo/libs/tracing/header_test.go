@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestInjectExtract(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	header := map[string][]string{}
+	Inject(trace.ContextWithSpanContext(context.Background(), spanContext), header)
+
+	assert.Equal(t, []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, header["traceparent"])
+
+	extracted := trace.SpanContextFromContext(Extract(context.Background(), header))
+	assert.Equal(t, traceID, extracted.TraceID())
+	assert.Equal(t, spanID, extracted.SpanID())
+	assert.True(t, extracted.IsRemote())
+}
+
+func TestExtractCanonicalHeader(t *testing.T) {
+	header := map[string][]string{
+		"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+
+	extracted := trace.SpanContextFromContext(Extract(context.Background(), header))
+	assert.True(t, extracted.IsValid())
+}
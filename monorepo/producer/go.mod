@@ -3,9 +3,11 @@ module wb_test_task/producer
 go 1.21
 
 require (
+	github.com/dany-ykl/tracer v1.0.2
 	github.com/google/uuid v1.3.1
 	github.com/nats-io/nats.go v1.30.2
 	github.com/pkg/errors v0.9.1
+	go.opentelemetry.io/otel v1.20.0
 )
 
 require (
@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"github.com/dany-ykl/tracer"
 	"github.com/google/uuid"
 	"log"
+	"wb_test_task/libs/codec"
 	"wb_test_task/libs/model"
+	"wb_test_task/libs/natsconn"
 )
 
 type OrderCreateRequest struct {
@@ -45,11 +49,11 @@ var orderCreateRequestJSON = `	{
     "request_id": "",
     "currency": "USD",
     "provider": "wbpay",
-    "amount": 1817,
+    "amount": 2134,
     "payment_dt": 1637907727,
     "bank": "alpha",
     "delivery_cost": 1500,
-    "goods_total": 317,
+    "goods_total": 634,
     "custom_fee": 0
   },
 
@@ -86,7 +90,7 @@ var orderCreateRequestJSON = `	{
   "internal_signature": "",
   "customer_id": "test",
   "delivery_service": "meest",
-  "shardkey": "9",
+  "shard_key": "9",
   "sm_id": 99,
   "date_created": "2021-11-26T06:22:19Z",
   "oof_shard": "1"
@@ -94,25 +98,96 @@ var orderCreateRequestJSON = `	{
 
 const count = 100
 
+// contentTypes кодеки, которые можно выбрать флагом -codec
+var contentTypes = map[string]string{
+	"json":     codec.ContentTypeJSON,
+	"msgpack":  codec.ContentTypeMsgpack,
+	"protobuf": codec.ContentTypeProtobuf,
+}
+
+// orderCreateSchemaVersion версия схемы OrderCreateRequest
+const orderCreateSchemaVersion = 2
+
 func main() {
-	producer, err := NewProducer(Config{Url: "http://localhost:4222"})
+	url := flag.String("url", "nats://localhost:4222", "nats server url")
+	codecName := flag.String("codec", "json", "payload codec: json, msgpack or protobuf")
+	compression := flag.String("compression", "", "payload compression: s2 or zstd")
+
+	connection := natsconn.Config{Name: "wb_test_task.producer"}
+	flag.StringVar(&connection.User, "user", "", "nats user")
+	flag.StringVar(&connection.Password, "password", "", "nats password")
+	flag.StringVar(&connection.Token, "token", "", "nats token")
+	flag.StringVar(&connection.NKeySeedFile, "nkey", "", "nats nkey seed file")
+	flag.StringVar(&connection.CredsFile, "creds", "", "nats .creds file")
+	flag.BoolVar(&connection.TLS.Enabled, "tls", false, "connect to nats over tls")
+	flag.StringVar(&connection.TLS.CAFile, "tls-ca", "", "nats tls ca file")
+	flag.StringVar(&connection.TLS.CertFile, "tls-cert", "", "nats tls client certificate file")
+	flag.StringVar(&connection.TLS.KeyFile, "tls-key", "", "nats tls client key file")
+	flag.IntVar(&connection.ReconnectWaitMs, "reconnect-wait-ms", 2000, "delay between nats reconnects")
+	flag.IntVar(&connection.MaxReconnects, "max-reconnects", 60, "nats reconnect attempts, -1 - unlimited")
+	flag.Parse()
+
+	contentType, ok := contentTypes[*codecName]
+	if !ok {
+		log.Fatalf("unknown codec %q", *codecName)
+	}
+	if contentType == codec.ContentTypeJSON {
+		// JSON без заголовка Content-Type читают и старые consumer
+		contentType = ""
+	}
+
+	shutdownTracer, err := tracer.New(&tracer.Config{
+		ServiceName:              "producer",
+		Host:                     "localhost",
+		Port:                     "4318",
+		Environment:              "dev",
+		TraceRatioFraction:       1.0,
+		OTELExporterOTLPEndpoint: "http://localhost:4317",
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer shutdownTracer(context.Background())
+
+	producer, err := NewProducer(Config{
+		Url:             *url,
+		Source:          "producer",
+		Connection:      connection,
+		ContentType:     contentType,
+		ContentEncoding: *compression,
+	})
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	for i := 0; i < count; i++ {
 		order := generateOrderCreateRequest(i)
-		data, err := json.Marshal(order)
-		if err != nil {
-			log.Fatalln(err)
-		}
-
-		if err := producer.Publish(context.Background(), "order.create", data); err != nil {
+		if err := producer.Publish(context.Background(), "order.create", orderCreateSchemaVersion, &order); err != nil {
 			log.Fatalln(err)
 		}
 	}
 }
 
+// MarshalProto закодировать запрос в protobuf сообщение order.create
+func (r *OrderCreateRequest) MarshalProto() ([]byte, error) {
+	return model.MarshalOrderCreateRequest(&model.Order{
+		OrderUid:          r.OrderUid,
+		TrackNumber:       r.TrackNumber,
+		Entry:             r.Entry,
+		Delivery:          r.Delivery,
+		Payment:           r.Payment,
+		Items:             r.Items,
+		Locale:            r.Locale,
+		InternalSignature: r.InternalSignature,
+		CustomerID:        r.CustomerID,
+		DeliveryService:   r.DeliveryService,
+		ShardKey:          r.ShardKey,
+		SmID:              r.SmID,
+		DateCreated:       r.DateCreated,
+		OofShard:          r.OofShard,
+	})
+}
+
 func generateOrderCreateRequest(n int) OrderCreateRequest {
 	var orderCreateRequest OrderCreateRequest
 	if err := json.Unmarshal([]byte(orderCreateRequestJSON), &orderCreateRequest); err != nil {
@@ -2,43 +2,106 @@ package main
 
 import (
 	"context"
+	"github.com/dany-ykl/tracer"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"log"
+	"time"
+	"wb_test_task/libs/codec"
+	"wb_test_task/libs/envelope"
+	"wb_test_task/libs/natsconn"
+	"wb_test_task/libs/tracing"
 )
 
 type Config struct {
-	Url string
+	Url        string
+	Source     string
+	Connection natsconn.Config
+	// ContentType кодек payload, по умолчанию JSON
+	ContentType string
+	// ContentEncoding сжатие payload: s2 или zstd, по умолчанию без сжатия
+	ContentEncoding string
 }
 
 type Producer struct {
-	conn   *nats.Conn
-	stream jetstream.JetStream
+	conn            *nats.Conn
+	stream          jetstream.JetStream
+	source          string
+	contentType     string
+	contentEncoding string
 }
 
 func NewProducer(cfg Config) (*Producer, error) {
-	conn, err := nats.Connect(cfg.Url)
+	if _, err := codec.ForContentType(cfg.ContentType); err != nil {
+		return &Producer{}, err
+	}
+	if _, err := codec.Compress(cfg.ContentEncoding, nil); err != nil {
+		return &Producer{}, err
+	}
+
+	opts, err := natsconn.Options(cfg.Connection)
+	if err != nil {
+		return &Producer{}, errors.Wrap(err, "fail to init nats connection options")
+	}
+	opts = append(opts, natsconn.EventOptions(natsconn.StdLogger{Logger: log.Default()}, nil)...)
+
+	conn, err := nats.Connect(cfg.Url, opts...)
 	if err != nil {
 		return &Producer{}, errors.Wrap(err, "fail to init nats connection")
 	}
 
 	stream, err := jetstream.New(conn)
 	if err != nil {
+		conn.Close()
 		return &Producer{}, errors.Wrap(err, "fail to init nats-jetstream")
 	}
 
 	return &Producer{
-		conn:   conn,
-		stream: stream,
+		conn:            conn,
+		stream:          stream,
+		source:          cfg.Source,
+		contentType:     cfg.ContentType,
+		contentEncoding: cfg.ContentEncoding,
 	}, nil
 }
 
-func (p *Producer) Publish(ctx context.Context, subject string, msg []byte) error {
+// Publish закодировать и отправить сообщение. Версия схемы, кодек и сжатие payload
+// передаются в заголовках
+func (p *Producer) Publish(ctx context.Context, subject string, schemaVersion int, value any) error {
 	select {
 	case <-ctx.Done():
 		return nil
 	default:
-		if _, err := p.stream.Publish(ctx, subject, msg); err != nil {
+		ctx, span := tracer.StartTrace(ctx, "nats-producer-publish-"+subject)
+		span.SetAttributes(attribute.String("subject", subject))
+		defer span.End()
+
+		// traceparent/tracestate передаются consumer в заголовках сообщения
+		header := nats.Header{}
+		tracing.Inject(ctx, header)
+		envelope.Metadata{
+			SchemaVersion: schemaVersion,
+			Source:        p.source,
+			ProducedAt:    time.Now(),
+		}.SetHeader(header)
+
+		msg, err := codec.Encode(header, value, p.contentType, p.contentEncoding)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return errors.Wrap(err, "fail to encode message")
+		}
+
+		if _, err := p.stream.PublishMsg(ctx, &nats.Msg{
+			Subject: subject,
+			Header:  header,
+			Data:    msg,
+		}); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 			return errors.Wrap(err, "fail to publish message")
 		}
 		return nil